@@ -0,0 +1,113 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package output implements the output formats shared by the kne_cli commands.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Format is an output format for kne_cli commands.
+type Format string
+
+const (
+	Table     Format = "table"
+	JSON      Format = "json"
+	YAML      Format = "yaml"
+	Textproto Format = "textproto"
+)
+
+// Flag is the name of the persistent flag used to select the output format.
+const Flag = "output"
+
+// Formats returns the supported output formats.
+func Formats() []Format {
+	return []Format{Table, JSON, YAML, Textproto}
+}
+
+// ParseFormat parses s into a Format. An empty string returns def.
+func ParseFormat(s string, def Format) (Format, error) {
+	if s == "" {
+		return def, nil
+	}
+	for _, f := range Formats() {
+		if Format(strings.ToLower(s)) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid output format %q, must be one of %v", s, Formats())
+}
+
+// FromCommand returns the format selected by the output flag of cmd or def if
+// the flag is not set or not defined for the command.
+func FromCommand(cmd *cobra.Command, def Format) (Format, error) {
+	if cmd.Flags().Lookup(Flag) == nil {
+		return def, nil
+	}
+	s, err := cmd.Flags().GetString(Flag)
+	if err != nil {
+		return "", err
+	}
+	return ParseFormat(s, def)
+}
+
+// Tabler is implemented by values that can be rendered as a table.
+type Tabler interface {
+	WriteTable(w io.Writer) error
+}
+
+// Write writes v to w in format f. JSON and YAML use the json tags of v.
+// Textproto writes pb and returns an error if pb is nil.
+func Write(w io.Writer, f Format, v Tabler, pb proto.Message) error {
+	switch f {
+	case Table:
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		if err := v.WriteTable(tw); err != nil {
+			return err
+		}
+		return tw.Flush()
+	case JSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case YAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case Textproto:
+		if pb == nil {
+			return fmt.Errorf("output format %q not supported", f)
+		}
+		_, err := fmt.Fprintln(w, prototext.Format(pb))
+		return err
+	default:
+		return fmt.Errorf("invalid output format %q", f)
+	}
+}
@@ -0,0 +1,241 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		def     Format
+		want    Format
+		wantErr string
+	}{{
+		desc: "default",
+		def:  Textproto,
+		want: Textproto,
+	}, {
+		desc: "json",
+		in:   "json",
+		def:  Table,
+		want: JSON,
+	}, {
+		desc: "upper case",
+		in:   "YAML",
+		def:  Table,
+		want: YAML,
+	}, {
+		desc:    "invalid",
+		in:      "xml",
+		def:     Table,
+		wantErr: "invalid output format",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseFormat(tt.in, tt.def)
+			if s := errdiff.Check(err, tt.wantErr); s != "" {
+				t.Fatalf("ParseFormat() unexpected error: %s", s)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseFormat() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var testTopo = &tpb.Topology{
+	Name: "test",
+	Nodes: []*tpb.Node{{
+		Name:   "r2",
+		Vendor: tpb.Vendor_ARISTA,
+	}, {
+		Name: "r1",
+		Type: tpb.Node_HOST,
+		Services: map[uint32]*tpb.Service{
+			22: {
+				Name:      "ssh",
+				Inside:    22,
+				OutsideIp: "100.100.100.100",
+			},
+			443: {
+				Name:   "ssl",
+				Inside: 443,
+			},
+		},
+	}},
+	Links: []*tpb.Link{{
+		ANode: "r1",
+		AInt:  "eth1",
+		ZNode: "r2",
+		ZInt:  "eth1",
+	}},
+}
+
+func TestNewTopology(t *testing.T) {
+	got := NewTopology(testTopo, cpb.TopologyState_TOPOLOGY_STATE_RUNNING, map[string]node.Status{
+		"r1": node.StatusRunning,
+		"r2": node.StatusPending,
+	}, map[string][]*corev1.Pod{
+		"r1": {{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}},
+	})
+	want := &Topology{
+		Name:  "test",
+		State: "RUNNING",
+		Nodes: []*Node{{
+			Name:   "r1",
+			Vendor: "HOST",
+			Status: "RUNNING",
+			PodIP:  "10.0.0.1",
+			Services: []*Service{{
+				Name:      "ssh",
+				Inside:    22,
+				OutsideIP: "100.100.100.100",
+			}, {
+				Name:   "ssl",
+				Inside: 443,
+			}},
+		}, {
+			Name:     "r2",
+			Vendor:   "ARISTA",
+			Status:   "PENDING",
+			Services: []*Service{},
+		}},
+		Links: []*Link{{
+			ANode: "r1",
+			AInt:  "eth1",
+			ZNode: "r2",
+			ZInt:  "eth1",
+		}},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Fatalf("NewTopology() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	out := NewTopology(testTopo, cpb.TopologyState_TOPOLOGY_STATE_RUNNING, nil, nil)
+	tests := []struct {
+		desc    string
+		format  Format
+		pb      proto.Message
+		check   func(t *testing.T, b []byte)
+		want    string
+		wantErr string
+	}{{
+		desc:   "table",
+		format: Table,
+		want: "TOPOLOGY   STATE\n" +
+			"test       RUNNING\n" +
+			"\n" +
+			"NODE   VENDOR   STATUS   POD IP   SERVICES\n" +
+			"r1     HOST                       ssh=100.100.100.100:22,ssl=<pending>\n" +
+			"r2     ARISTA                     \n" +
+			"\n" +
+			"A NODE   A INT   Z NODE   Z INT\n" +
+			"r1       eth1    r2       eth1\n",
+	}, {
+		desc:   "json",
+		format: JSON,
+		check: func(t *testing.T, b []byte) {
+			got := &Topology{}
+			if err := json.Unmarshal(b, got); err != nil {
+				t.Fatalf("failed to unmarshal json: %v", err)
+			}
+			if s := cmp.Diff(out, got); s != "" {
+				t.Fatalf("unexpected diff (-want +got):\n%s", s)
+			}
+		},
+	}, {
+		desc:   "yaml",
+		format: YAML,
+		check: func(t *testing.T, b []byte) {
+			got := &Topology{}
+			if err := yaml.Unmarshal(b, got); err != nil {
+				t.Fatalf("failed to unmarshal yaml: %v", err)
+			}
+			if s := cmp.Diff(out, got); s != "" {
+				t.Fatalf("unexpected diff (-want +got):\n%s", s)
+			}
+		},
+	}, {
+		desc:   "textproto",
+		format: Textproto,
+		pb:     testTopo,
+		check: func(t *testing.T, b []byte) {
+			got := &tpb.Topology{}
+			if err := prototext.Unmarshal(b, got); err != nil {
+				t.Fatalf("failed to unmarshal textproto: %v", err)
+			}
+			if !proto.Equal(got, testTopo) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, testTopo)
+			}
+		},
+	}, {
+		desc:    "textproto unsupported",
+		format:  Textproto,
+		wantErr: "not supported",
+	}, {
+		desc:    "invalid",
+		format:  Format("xml"),
+		wantErr: "invalid output format",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tt.format, out, tt.pb)
+			if s := errdiff.Check(err, tt.wantErr); s != "" {
+				t.Fatalf("Write() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if tt.check != nil {
+				tt.check(t, buf.Bytes())
+				return
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("Write() unexpected output: got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTopologyList(t *testing.T) {
+	created := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	got := NewTopologyList([]*topo.TopologyInfo{{
+		Name:      "t1",
+		Namespace: "t1",
+		Nodes:     2,
+		State:     cpb.TopologyState_TOPOLOGY_STATE_ERROR,
+		Created:   created,
+		Creator:   "alice",
+	}})
+	want := &TopologyList{
+		Topologies: []*TopologyInfo{{
+			Name:      "t1",
+			Namespace: "t1",
+			Nodes:     2,
+			State:     "ERROR",
+			Created:   created,
+			Creator:   "alice",
+		}},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Fatalf("NewTopologyList() unexpected diff (-want +got):\n%s", s)
+	}
+}
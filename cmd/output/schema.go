@@ -0,0 +1,199 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// The types below define the JSON and YAML schema of the kne_cli output.
+// Fields may be added but existing fields must not be renamed or removed.
+
+// Topology is the output schema of a deployed topology.
+type Topology struct {
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Nodes []*Node `json:"nodes"`
+	Links []*Link `json:"links"`
+}
+
+// Node is the output schema of a node in a topology.
+type Node struct {
+	Name     string     `json:"name"`
+	Vendor   string     `json:"vendor"`
+	Model    string     `json:"model,omitempty"`
+	Version  string     `json:"version,omitempty"`
+	Status   string     `json:"status,omitempty"`
+	PodIP    string     `json:"pod_ip,omitempty"`
+	Services []*Service `json:"services"`
+}
+
+// Service is the output schema of a service exposed by a node.
+type Service struct {
+	Name      string `json:"name"`
+	Inside    uint32 `json:"inside"`
+	Outside   uint32 `json:"outside,omitempty"`
+	InsideIP  string `json:"inside_ip,omitempty"`
+	OutsideIP string `json:"outside_ip,omitempty"`
+	NodePort  uint32 `json:"node_port,omitempty"`
+}
+
+// Endpoint returns the externally reachable address of the service.
+func (s *Service) Endpoint() string {
+	if s.OutsideIP == "" {
+		return fmt.Sprintf("%s=<pending>", s.Name)
+	}
+	return fmt.Sprintf("%s=%s:%d", s.Name, s.OutsideIP, s.Inside)
+}
+
+// Link is the output schema of a link between two nodes.
+type Link struct {
+	ANode string `json:"a_node"`
+	AInt  string `json:"a_int"`
+	ZNode string `json:"z_node"`
+	ZInt  string `json:"z_int"`
+}
+
+// NewTopology returns the output for the topology pb. The services of each
+// node are read from pb. status and pods are optional and keyed by node name.
+func NewTopology(pb *tpb.Topology, state cpb.TopologyState, status map[string]node.Status, pods map[string][]*corev1.Pod) *Topology {
+	t := &Topology{
+		Name:  pb.GetName(),
+		State: strings.TrimPrefix(state.String(), "TOPOLOGY_STATE_"),
+		Nodes: []*Node{},
+		Links: []*Link{},
+	}
+	for _, n := range pb.GetNodes() {
+		vendor := n.GetVendor().String()
+		if n.GetVendor() == tpb.Vendor_UNKNOWN && n.GetType() != tpb.Node_UNKNOWN {
+			vendor = n.GetType().String()
+		}
+		on := &Node{
+			Name:     n.GetName(),
+			Vendor:   vendor,
+			Model:    n.GetModel(),
+			Version:  n.GetVersion(),
+			Status:   string(status[n.GetName()]),
+			Services: []*Service{},
+		}
+		if p := pods[n.GetName()]; len(p) > 0 && p[0] != nil {
+			on.PodIP = p[0].Status.PodIP
+		}
+		var ports []uint32
+		for k := range n.GetServices() {
+			ports = append(ports, k)
+		}
+		sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+		for _, k := range ports {
+			s := n.GetServices()[k]
+			on.Services = append(on.Services, &Service{
+				Name:      s.GetName(),
+				Inside:    s.GetInside(),
+				Outside:   s.GetOutside(),
+				InsideIP:  s.GetInsideIp(),
+				OutsideIP: s.GetOutsideIp(),
+				NodePort:  s.GetNodePort(),
+			})
+		}
+		t.Nodes = append(t.Nodes, on)
+	}
+	sort.Slice(t.Nodes, func(i, j int) bool { return t.Nodes[i].Name < t.Nodes[j].Name })
+	for _, l := range pb.GetLinks() {
+		t.Links = append(t.Links, &Link{
+			ANode: l.GetANode(),
+			AInt:  l.GetAInt(),
+			ZNode: l.GetZNode(),
+			ZInt:  l.GetZInt(),
+		})
+	}
+	return t
+}
+
+// WriteTable writes a summary of the nodes and links of the topology.
+func (t *Topology) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "TOPOLOGY\tSTATE\n%s\t%s\n\n", t.Name, t.State)
+	fmt.Fprintln(w, "NODE\tVENDOR\tSTATUS\tPOD IP\tSERVICES")
+	for _, n := range t.Nodes {
+		var eps []string
+		for _, s := range n.Services {
+			eps = append(eps, s.Endpoint())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", n.Name, n.Vendor, n.Status, n.PodIP, strings.Join(eps, ","))
+	}
+	if len(t.Links) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nA NODE\tA INT\tZ NODE\tZ INT")
+	for _, l := range t.Links {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.ANode, l.AInt, l.ZNode, l.ZInt)
+	}
+	return nil
+}
+
+// TopologyList is the output schema of the topologies in a cluster.
+type TopologyList struct {
+	Topologies []*TopologyInfo `json:"topologies"`
+}
+
+// TopologyInfo is the output schema of a topology summary.
+type TopologyInfo struct {
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Nodes     int       `json:"nodes"`
+	State     string    `json:"state"`
+	Created   time.Time `json:"created"`
+	Creator   string    `json:"creator,omitempty"`
+}
+
+// NewTopologyList returns the output for the provided topologies.
+func NewTopologyList(infos []*topo.TopologyInfo) *TopologyList {
+	l := &TopologyList{
+		Topologies: []*TopologyInfo{},
+	}
+	for _, i := range infos {
+		l.Topologies = append(l.Topologies, &TopologyInfo{
+			Name:      i.Name,
+			Namespace: i.Namespace,
+			Nodes:     i.Nodes,
+			State:     strings.TrimPrefix(i.State.String(), "TOPOLOGY_STATE_"),
+			Created:   i.Created,
+			Creator:   i.Creator,
+		})
+	}
+	return l
+}
+
+// WriteTable writes one row per topology.
+func (l *TopologyList) WriteTable(w io.Writer) error {
+	fmt.Fprintln(w, "NAME\tNODES\tSTATE\tAGE\tCREATOR")
+	for _, t := range l.Topologies {
+		age := "<unknown>"
+		if !t.Created.IsZero() {
+			age = duration.HumanDuration(time.Since(t.Created))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", t.Name, t.Nodes, t.State, age, t.Creator)
+	}
+	return nil
+}
@@ -17,25 +17,42 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/kr/pretty"
 	"github.com/openconfig/kne/cmd/deploy"
+	"github.com/openconfig/kne/cmd/output"
 	"github.com/openconfig/kne/cmd/topology"
+	"github.com/openconfig/kne/cmd/vendor"
+	cpb "github.com/openconfig/kne/proto/controller"
+	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/node/plugin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/util/homedir"
 )
 
 var (
-	defaultKubeCfg = ""
-	kubecfg        string
-	dryrun         bool
-	timeout        time.Duration
-	logLevel       = "info"
+	defaultKubeCfg   = ""
+	defaultPluginDir = ""
+	kubecfg          string
+	pluginDir        string
+	profileFiles     []string
+	namespace        string
+	instance         string
+	plugins          *plugin.Plugins
+	dryrun           bool
+	timeout          time.Duration
+	bundleDir        string
+	matrixFile       string
+	logLevel         = "info"
+	outputFormat     string
 
 	rootCmd = &cobra.Command{
 		Use:   "kne_cli",
@@ -54,28 +71,51 @@ func rootFn(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	log.SetLevel(l)
+	// An invalid plugin must not break commands that don't use it, so
+	// invalid plugins are skipped.
+	if plugins, err = plugin.Load(pluginDir); err != nil {
+		log.Warnf("Skipping plugins of %s: %v", pluginDir, err)
+	}
 	return nil
 }
 
 // ExecuteContext executes the root command.
 func ExecuteContext(ctx context.Context) error {
+	defer func() {
+		if plugins == nil {
+			return
+		}
+		if err := plugins.Close(); err != nil {
+			log.Warnf("Failed to close plugins: %v", err)
+		}
+	}()
 	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
 	if home := homedir.HomeDir(); home != "" {
 		defaultKubeCfg = filepath.Join(home, ".kube", "config")
+		defaultPluginDir = filepath.Join(home, ".config", "kne", "plugins")
 	}
 	rootCmd.SetOut(os.Stdout)
 	rootCmd.PersistentFlags().StringVar(&kubecfg, "kubecfg", defaultKubeCfg, "kubeconfig file")
+	rootCmd.PersistentFlags().StringVar(&pluginDir, "plugin-dir", defaultPluginDir, "directory of node plugin manifests")
+	rootCmd.PersistentFlags().StringSliceVar(&profileFiles, "profile", nil, "vendor profile file overriding the default node configs, may be repeated")
+	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "", "namespace of the topology, defaults to the topology name")
+	rootCmd.PersistentFlags().StringVar(&instance, "instance", "", "instance name suffixed to the namespace of the topology, to deploy the same topology more than once")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "verbosity", "v", logLevel, "log level")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, output.Flag, "o", "", "output format (table, json, yaml or textproto), defaults to the command specific format")
 	createCmd.Flags().BoolVar(&dryrun, "dryrun", false, "Generate topology but do not push to k8s")
 	createCmd.Flags().DurationVar(&timeout, "timeout", 0, "Timeout for pod status enquiry")
+	createCmd.Flags().StringVar(&bundleDir, "bundle-dir", "", "Directory to write a support bundle to if creation fails (default is the system temp dir)")
+	createCmd.Flags().StringVar(&matrixFile, "matrix", "", "YAML file of versions, creates one instance of the topology per version combination")
+	deleteCmd.Flags().StringVar(&matrixFile, "matrix", "", "YAML file of versions, deletes the instances of the topology created with --matrix")
 	rootCmd.AddCommand(createCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(topology.New())
 	rootCmd.AddCommand(deploy.New())
+	rootCmd.AddCommand(vendor.New())
 	// rootCmd.AddCommand(graphCmd)
 }
 
@@ -110,6 +150,15 @@ func validateTopology(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// topoOptions returns the topology options of the root flags.
+func topoOptions() []topo.Option {
+	return []topo.Option{
+		topo.WithProfiles(profileFiles...),
+		topo.WithNamespace(namespace),
+		topo.WithInstance(instance),
+	}
+}
+
 func fileRelative(p string) (string, error) {
 	bp, err := filepath.Abs(p)
 	if err != nil {
@@ -127,58 +176,145 @@ func createFn(cmd *cobra.Command, args []string) error {
 	p := topo.TopologyParams{
 		TopoName:       args[0],
 		Kubecfg:        kubecfg,
-		TopoNewOptions: []topo.Option{topo.WithBasePath(bp)},
+		TopoNewOptions: append(topoOptions(), topo.WithBasePath(bp)),
 		Timeout:        timeout,
 		DryRun:         dryrun,
+		BundleDir:      bundleDir,
+	}
+	if matrixFile != "" {
+		return matrixFn(cmd, p, topo.CreateMatrix, "ready")
 	}
 	return topo.CreateTopology(cmd.Context(), p)
 }
 
 func deleteFn(cmd *cobra.Command, args []string) error {
 	p := topo.TopologyParams{
-		TopoName: args[0],
-		Kubecfg:  kubecfg,
+		TopoName:       args[0],
+		Kubecfg:        kubecfg,
+		TopoNewOptions: topoOptions(),
+	}
+	if matrixFile != "" {
+		return matrixFn(cmd, p, topo.DeleteMatrix, "deleted")
 	}
 	return topo.DeleteTopology(cmd.Context(), p)
 }
 
+// matrixResult is the result of a variant of a version matrix.
+type matrixResult struct {
+	Instance  string            `json:"instance"`
+	Namespace string            `json:"namespace"`
+	Versions  map[string]string `json:"versions"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// matrixResults are the results of the variants of a version matrix.
+type matrixResults []*matrixResult
+
+// WriteTable writes the results as a table.
+func (rs matrixResults) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "INSTANCE\tNAMESPACE\tVERSIONS\tSTATUS\tERROR"); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		var keys []string
+		for k := range r.Versions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var versions []string
+		for _, k := range keys {
+			versions = append(versions, fmt.Sprintf("%s=%s", k, r.Versions[k]))
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Instance, r.Namespace, strings.Join(versions, ","), r.Status, r.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrixFn runs fn for the version matrix of the matrix flag and writes the
+// result of each variant. An error is returned if any variant failed.
+func matrixFn(cmd *cobra.Command, p topo.TopologyParams, fn func(context.Context, topo.TopologyParams, *topo.Matrix) ([]*topo.VariantResult, error), status string) error {
+	f, err := output.FromCommand(cmd, output.Table)
+	if err != nil {
+		return err
+	}
+	m, err := topo.LoadMatrix(matrixFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	vrs, err := fn(cmd.Context(), p, m)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	var rs matrixResults
+	failed := 0
+	for _, vr := range vrs {
+		r := &matrixResult{
+			Instance:  vr.Instance,
+			Namespace: vr.Namespace,
+			Versions:  vr.Versions,
+			Status:    status,
+		}
+		if vr.Err != nil {
+			failed++
+			r.Status = "failed"
+			r.Error = vr.Err.Error()
+		}
+		rs = append(rs, r)
+	}
+	if err := output.Write(cmd.OutOrStdout(), f, rs, nil); err != nil {
+		return err
+	}
+	if failed != 0 {
+		return fmt.Errorf("%s: %d of %d variants failed", cmd.Use, failed, len(rs))
+	}
+	return nil
+}
+
 func showFn(cmd *cobra.Command, args []string) error {
+	f, err := output.FromCommand(cmd, output.Table)
+	if err != nil {
+		return err
+	}
 	topopb, err := topo.Load(args[0])
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
-	t, err := topo.New(kubecfg, topopb)
+	t, err := topo.New(kubecfg, topopb, topoOptions()...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
 	if err := t.Load(cmd.Context()); err != nil {
 		return err
 	}
-	out := cmd.OutOrStdout()
 	r, err := t.Resources(cmd.Context())
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(out, "Pods:\n")
-	for k, pods := range r.Pods {
-		fmt.Fprintf(out, "Pod %s:\n", k)
-		for _, p := range pods {
-			if p == nil {
-				fmt.Fprintf(out, "pod in unknown state: nil\n")
-				continue
-			}
-			fmt.Fprintf(out, "%s:%s IP:%s\n", topopb.Name, p.Name, p.Status.PodIP)
+	status := map[string]node.Status{}
+	for _, n := range t.Nodes() {
+		phase, err := n.Status(cmd.Context())
+		if err != nil {
+			log.Warnf("Failed to get status of node %q: %v", n.Name(), err)
 		}
+		status[n.Name()] = phase
 	}
-	fmt.Fprintf(out, "Topologies:\n")
-	for _, p := range r.Topologies {
-		fmt.Fprintf(out, "%s:%s\nSpec:\n%s\nStatus:\n%s\n", topopb.Name, p.Name, pretty.Sprint(p.Spec), pretty.Sprint(p.Status))
-	}
-	fmt.Fprintf(out, "Services:\n")
-	for _, svcs := range r.Services {
-		for _, s := range svcs {
-			fmt.Fprintf(out, "%s:%s\nSpec:\n%s\nStatus:\n%s\n", topopb.Name, s.Name, pretty.Sprint(s.Spec), pretty.Sprint(s.Status))
+	for _, n := range t.TopologyProto().GetNodes() {
+		if n.Services == nil {
+			n.Services = map[uint32]*tpb.Service{}
+		}
+		for _, svc := range r.Services[n.Name] {
+			if err := topo.ServiceToProto(svc, n.Services); err != nil {
+				log.Warnf("Failed to get service endpoints for node %q: %v", n.Name, err)
+			}
 		}
 	}
-	return nil
+	state := topo.State(status)
+	out := output.NewTopology(t.TopologyProto(), state, status, r.Pods)
+	return output.Write(cmd.OutOrStdout(), f, out, &cpb.ShowTopologyResponse{
+		State:    state,
+		Topology: t.TopologyProto(),
+	})
 }
@@ -0,0 +1,114 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/capture"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	captureFile       = "-"
+	captureFilter     string
+	capturePCAPNG     bool
+	captureDebugImage string
+)
+
+func captureFn(cmd *cobra.Command, args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ctx := cmd.Context()
+	if err := t.Load(ctx); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	n, err := t.Node(args[1])
+	if err != nil {
+		return err
+	}
+	e, ok := n.(node.Execer)
+	if !ok {
+		return fmt.Errorf("node %q does not support exec", n.Name())
+	}
+	cOpts := &capture.Options{
+		Filter: captureFilter,
+		PCAPNG: capturePCAPNG || strings.HasSuffix(captureFile, ".pcapng"),
+		Stderr: cmd.ErrOrStderr(),
+	}
+	for _, intf := range args[2:] {
+		cOpts.Interfaces = append(cOpts.Interfaces, interfaceName(n, intf))
+	}
+	if captureDebugImage != "" {
+		d, ok := n.(node.Debugger)
+		if !ok {
+			return fmt.Errorf("node %q does not support debug containers", n.Name())
+		}
+		if cOpts.Container, err = d.DebugContainer(ctx, captureDebugImage); err != nil {
+			return err
+		}
+	}
+	var w io.Writer
+	switch captureFile {
+	case "-":
+		w = cmd.OutOrStdout()
+		if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			return fmt.Errorf("%s: refusing to write capture to a terminal, use -w or pipe the output", cmd.Use)
+		}
+	default:
+		f, err := os.Create(captureFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Warnf("failed to close capture file %q", captureFile)
+			}
+		}()
+		w = f
+	}
+	log.Infof("Starting capture of %v on %q", cOpts.Interfaces, n.Name())
+	return capture.Run(ctx, e, w, cOpts)
+}
+
+// interfaceName returns the Linux interface of the node for intf which may
+// be the name of the interface in the topology or the vendor interface name.
+func interfaceName(n node.Node, intf string) string {
+	for k, v := range n.GetProto().GetInterfaces() {
+		if k == intf || v.GetIntName() == intf {
+			return k
+		}
+	}
+	return intf
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var configSaveDir = "."
+
+// configFileName returns the name of the file the config of n is saved to.
+// The extension of the node config file is kept so the vendor format is
+// recognizable, e.g. r1.json for the config.json of SR Linux.
+func configFileName(n node.Node) string {
+	ext := filepath.Ext(n.GetProto().GetConfig().GetConfigFile())
+	if ext == "" {
+		ext = ".cfg"
+	}
+	return n.Name() + ext
+}
+
+func configSaveFn(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ctx := cmd.Context()
+	if err := t.Load(ctx); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	nodes := t.Nodes()
+	if len(args) > 1 {
+		n, err := t.Node(args[1])
+		if err != nil {
+			return err
+		}
+		if _, ok := n.(node.ConfigGetter); !ok {
+			return fmt.Errorf("node %q does not support config retrieval", n.Name())
+		}
+		nodes = []node.Node{n}
+	}
+	if err := os.MkdirAll(configSaveDir, 0755); err != nil {
+		return err
+	}
+	var errList errlist.List
+	for _, n := range nodes {
+		cg, ok := n.(node.ConfigGetter)
+		if !ok {
+			log.Infof("Skipping node %q not a ConfigGetter", n.Name())
+			continue
+		}
+		b, err := cg.ConfigGet(ctx)
+		switch {
+		case status.Code(err) == codes.Unimplemented && len(args) == 1:
+			log.Infof("Skipping node %q: %v", n.Name(), err)
+			continue
+		case status.Code(err) == codes.Unimplemented:
+			errList.Add(fmt.Errorf("node %q does not support config retrieval: %w", n.Name(), err))
+			continue
+		case err != nil:
+			errList.Add(fmt.Errorf("node %q: %w", n.Name(), err))
+			continue
+		}
+		p := filepath.Join(configSaveDir, configFileName(n))
+		if err := os.WriteFile(p, b, 0644); err != nil {
+			errList.Add(err)
+			continue
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), p)
+	}
+	return errList.Err()
+}
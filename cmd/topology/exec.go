@@ -0,0 +1,190 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+var (
+	execAll      bool
+	execSelector string
+)
+
+func execFn(cmd *cobra.Command, args []string) error {
+	var command []string
+	if i := cmd.ArgsLenAtDash(); i >= 0 {
+		command = args[i:]
+		args = args[:i]
+	}
+	multi := execAll || execSelector != ""
+	switch {
+	case execAll && execSelector != "":
+		return fmt.Errorf("%s: --all and --selector are mutually exclusive", cmd.Use)
+	case multi && len(args) != 1, !multi && len(args) != 2:
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	case multi && len(command) == 0:
+		return fmt.Errorf("%s: a command is required with --all or --selector", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	ctx := cmd.Context()
+	if err := t.Load(ctx); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if !multi {
+		n, err := t.Node(args[1])
+		if err != nil {
+			return err
+		}
+		if len(command) == 0 {
+			command = node.CLICommand(n)
+		}
+		return execNode(ctx, n, command, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+	}
+	sel, err := labels.Parse(execSelector)
+	if err != nil {
+		return fmt.Errorf("%s: invalid selector: %w", cmd.Use, err)
+	}
+	var nodes []node.Node
+	for _, n := range t.Nodes() {
+		if sel.Matches(labels.Set(n.GetProto().GetLabels())) {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("%s: no nodes match selector %q", cmd.Use, execSelector)
+	}
+	return execNodes(ctx, nodes, command, cmd.OutOrStdout())
+}
+
+// execNode runs cmd on n. If stdin is a terminal it is put in raw mode and
+// a TTY is allocated for the session.
+func execNode(ctx context.Context, n node.Node, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	e, ok := n.(node.Execer)
+	if !ok {
+		return fmt.Errorf("node %q does not support exec", n.Name())
+	}
+	opts := &node.ExecOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fd := int(f.Fd())
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+		}
+		defer term.Restore(fd, state)
+		opts.TTY = true
+		if w, h, err := term.GetSize(fd); err == nil {
+			opts.TerminalSizeQueue = newSizeQueue(w, h)
+		}
+	}
+	return e.ExecWithOptions(ctx, cmd, opts)
+}
+
+// execNodes runs cmd on all nodes in parallel and writes the output of each
+// node to w grouped under a header with the node name.
+func execNodes(ctx context.Context, nodes []node.Node, cmd []string, w io.Writer) error {
+	outs := make([]bytes.Buffer, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		e, ok := n.(node.Execer)
+		if !ok {
+			errs[i] = fmt.Errorf("node %q does not support exec", n.Name())
+			continue
+		}
+		wg.Add(1)
+		go func(i int, e node.Execer) {
+			defer wg.Done()
+			out := &syncWriter{w: &outs[i]}
+			errs[i] = e.ExecWithOptions(ctx, cmd, &node.ExecOptions{
+				Stdout: out,
+				Stderr: out,
+			})
+		}(i, e)
+	}
+	wg.Wait()
+	var errList errlist.List
+	for i, n := range nodes {
+		fmt.Fprintf(w, "=== %s ===\n", n.Name())
+		out := outs[i].String()
+		fmt.Fprint(w, out)
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			fmt.Fprintln(w)
+		}
+		if errs[i] != nil {
+			fmt.Fprintf(w, "error: %v\n", errs[i])
+			errList.Add(fmt.Errorf("%s: %w", n.Name(), errs[i]))
+		}
+	}
+	return errList.Err()
+}
+
+// syncWriter serializes writes from the stdout and stderr streams of an exec.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(b)
+}
+
+// sizeQueue reports the initial terminal size to the exec session.
+type sizeQueue chan remotecommand.TerminalSize
+
+func newSizeQueue(width, height int) sizeQueue {
+	q := make(sizeQueue, 1)
+	q <- remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+	close(q)
+	return q
+}
+
+func (q sizeQueue) Next() *remotecommand.TerminalSize {
+	s, ok := <-q
+	if !ok {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/openconfig/kne/topo"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	snapshotDir       = "."
+	snapshotConfigMap bool
+	kubeClient        = func(kubecfg string) (kubernetes.Interface, error) {
+		rCfg, err := clientcmd.BuildConfigFromFlags("", kubecfg)
+		if err != nil {
+			return nil, err
+		}
+		return kubernetes.NewForConfig(rCfg)
+	}
+)
+
+// loadTopology returns the loaded topology manager of the topology file p.
+func loadTopology(cmd *cobra.Command, p string) (topo.TopologyManager, error) {
+	topopb, err := topo.Load(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return nil, err
+	}
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if err := t.Load(cmd.Context()); err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	return t, nil
+}
+
+func snapshotFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	t, err := loadTopology(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	s, err := t.Snapshot(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	if !snapshotConfigMap {
+		p := filepath.Join(snapshotDir, s.Name)
+		if err := s.WriteDir(p); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), p)
+		return nil
+	}
+	kubecfg, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	kClient, err := kubeClient(kubecfg)
+	if err != nil {
+		return err
+	}
+	cm, err := s.ConfigMap()
+	if err != nil {
+		return err
+	}
+	cm.Namespace = t.Namespace()
+	cms := kClient.CoreV1().ConfigMaps(cm.Namespace)
+	if _, err := cms.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		if _, err := cms.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "configmap/%s\n", cm.Name)
+	return nil
+}
+
+func restoreFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	t, err := loadTopology(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	var s *topo.Snapshot
+	if snapshotConfigMap {
+		kubecfg, err := cmd.Flags().GetString("kubecfg")
+		if err != nil {
+			return err
+		}
+		kClient, err := kubeClient(kubecfg)
+		if err != nil {
+			return err
+		}
+		cm, err := kClient.CoreV1().ConfigMaps(t.Namespace()).Get(ctx, topo.SnapshotConfigMapName(args[1]), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if s, err = topo.SnapshotFromConfigMap(cm); err != nil {
+			return err
+		}
+	} else if s, err = topo.ReadSnapshotDir(filepath.Join(snapshotDir, args[1])); err != nil {
+		return err
+	}
+	return t.Restore(ctx, s)
+}
@@ -19,14 +19,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/openconfig/gnmi/errlist"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/kne/cmd/output"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/pki"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func New() *cobra.Command {
@@ -46,23 +53,108 @@ func New() *cobra.Command {
 		RunE:  serviceFn,
 	}
 	certCmd := &cobra.Command{
-		Use:   "cert <topology> <device>",
-		Short: "push or generate certs for nodes in topology",
+		Use:   "cert <topology> [device]",
+		Short: "push or generate certs for nodes in topology (if device not provided all nodes with a cert config)",
 		RunE:  certFn,
 	}
+	caCmd := &cobra.Command{
+		Use:   "ca <topology>",
+		Short: "ca writes the CA bundle of the topology used to verify CA signed node certs",
+		RunE:  caFn,
+	}
 	resetCfgCmd := &cobra.Command{
 		Use:   "reset <topology> <device>",
 		Short: "reset configuration of device to vendor default (if device not provide reset all nodes)",
 		RunE:  resetCfgFn,
 	}
+	captureCmd := &cobra.Command{
+		Use:   "capture <topology> <device> <interface>...",
+		Short: "capture streams a packet capture of the device interfaces",
+		RunE:  captureFn,
+	}
+	collectCmd := &cobra.Command{
+		Use:   "collect <topology>",
+		Short: "collect writes a support bundle with the diagnostics of the topology",
+		RunE:  collectFn,
+	}
+	configSaveCmd := &cobra.Command{
+		Use:   "save <topology> [device] [-d <dir>]",
+		Short: "save writes the running config of the devices to <dir>, one file per device usable as the node config file",
+		RunE:  configSaveFn,
+	}
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Device config commands.",
+	}
+	execCmd := &cobra.Command{
+		Use:   "exec <topology> <device> [-- <command>]",
+		Short: "exec runs a command on a device, or opens the vendor CLI if no command is provided",
+		RunE:  execFn,
+	}
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot <topology> <name>",
+		Short: "snapshot saves the running config of the devices and the link state of the topology",
+		RunE:  snapshotFn,
+	}
+	restoreCmd := &cobra.Command{
+		Use:   "restore <topology> <name>",
+		Short: "restore resets the devices and pushes the configs saved in the snapshot",
+		RunE:  restoreFn,
+	}
+	verifyCmd := &cobra.Command{
+		Use:   "verify <topology>",
+		Short: "verify checks that the links of the topology are up and pass traffic",
+		RunE:  verifyFn,
+	}
+	wiringCmd := &cobra.Command{
+		Use:   "wiring <topology>",
+		Short: "wiring compares the LLDP neighbors of the devices with the links of the topology",
+		RunE:  wiringFn,
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list returns all topologies in the cluster.",
+		RunE:  listFn,
+	}
 	topoCmd := &cobra.Command{
 		Use:   "topology",
 		Short: "Topology commands.",
 	}
+	captureCmd.Flags().StringVarP(&captureFile, "write", "w", captureFile, "file to write the capture to, - for stdout")
+	captureCmd.Flags().StringVarP(&captureFilter, "filter", "f", captureFilter, "BPF filter expression for the capture")
+	captureCmd.Flags().BoolVar(&capturePCAPNG, "pcapng", capturePCAPNG, "write pcapng for a single interface, several interfaces are always written as pcapng")
+	captureCmd.Flags().StringVar(&captureDebugImage, "debug-image", captureDebugImage, "run tcpdump in an ephemeral container using this image instead of the node container")
+	topoCmd.AddCommand(caCmd)
+	topoCmd.AddCommand(captureCmd)
 	topoCmd.AddCommand(certCmd)
+	collectCmd.Flags().StringVarP(&collectDir, "dir", "d", collectDir, "directory to write the support bundle to")
+	topoCmd.AddCommand(collectCmd)
+	configSaveCmd.Flags().StringVarP(&configSaveDir, "dir", "d", configSaveDir, "directory to write the config files to")
+	configCmd.AddCommand(configSaveCmd)
+	topoCmd.AddCommand(configCmd)
+	execCmd.Flags().BoolVar(&execAll, "all", execAll, "run the command on all devices in the topology")
+	execCmd.Flags().StringVar(&execSelector, "selector", execSelector, "run the command on devices matching the label selector")
+	topoCmd.AddCommand(execCmd)
+	topoCmd.AddCommand(listCmd)
+	pushCmd.Flags().StringVar(&pushTransport, "transport", pushTransport, "transport used to push the config, cli or gnmi")
+	pushCmd.Flags().StringVar(&pushEncoding, "encoding", pushEncoding, "gNMI encoding of the config: json_ietf for OpenConfig JSON, or json, ascii or bytes for vendor native config")
+	pushCmd.Flags().StringVar(&pushOrigin, "origin", pushOrigin, "gNMI origin of the config, e.g. cli for vendor native CLI config")
+	pushCmd.Flags().StringVar(&pushUsername, "username", pushUsername, "gNMI username")
+	pushCmd.Flags().StringVar(&pushPassword, "password", pushPassword, "gNMI password")
 	topoCmd.AddCommand(pushCmd)
+	for _, c := range []*cobra.Command{snapshotCmd, restoreCmd} {
+		c.Flags().StringVarP(&snapshotDir, "dir", "d", snapshotDir, "directory containing the snapshot directories")
+		c.Flags().BoolVar(&snapshotConfigMap, "configmap", snapshotConfigMap, "store the snapshot in a ConfigMap of the topology namespace instead of a directory")
+		topoCmd.AddCommand(c)
+	}
 	topoCmd.AddCommand(serviceCmd)
+	verifyCmd.Flags().StringVar(&verifyJUnit, "junit", verifyJUnit, "file to additionally write the results to as JUnit XML")
+	topoCmd.AddCommand(verifyCmd)
 	topoCmd.AddCommand(watchCmd)
+	wiringCmd.Flags().StringVar(&wiringTransport, "transport", wiringTransport, "transport used to get the LLDP neighbors, cli or gnmi")
+	wiringCmd.Flags().StringVar(&wiringUsername, "username", wiringUsername, "gNMI username")
+	wiringCmd.Flags().StringVar(&wiringPassword, "password", wiringPassword, "gNMI password")
+	topoCmd.AddCommand(wiringCmd)
 	resetCfgCmd.Flags().BoolVar(&skipReset, "skip", skipReset, "skip nodes if they are not resetable")
 	resetCfgCmd.Flags().BoolVar(&pushConfig, "push", pushConfig, "additionally push orginal topology configuration")
 	topoCmd.AddCommand(resetCfgCmd)
@@ -86,6 +178,22 @@ var (
 	opts []topo.Option
 )
 
+// topoOptions returns opts with the vendor profiles of the profile flag and
+// the namespace and instance of the namespace and instance flags of cmd.
+func topoOptions(cmd *cobra.Command, opts []topo.Option) []topo.Option {
+	var o []topo.Option
+	if files, err := cmd.Flags().GetStringSlice("profile"); err == nil && len(files) != 0 {
+		o = append(o, topo.WithProfiles(files...))
+	}
+	if ns, err := cmd.Flags().GetString("namespace"); err == nil && ns != "" {
+		o = append(o, topo.WithNamespace(ns))
+	}
+	if instance, err := cmd.Flags().GetString("instance"); err == nil && instance != "" {
+		o = append(o, topo.WithInstance(instance))
+	}
+	return append(o, opts...)
+}
+
 func resetCfgFn(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 || len(args) > 2 {
 		return fmt.Errorf("%s: invalid args", cmd.Use)
@@ -98,7 +206,7 @@ func resetCfgFn(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	t, err := topo.New(s, topopb, opts...)
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
@@ -173,10 +281,43 @@ func resetCfgFn(cmd *cobra.Command, args []string) error {
 	return errList.Err()
 }
 
+var (
+	pushTransport = "cli"
+	pushEncoding  = "json_ietf"
+	pushOrigin    string
+	pushUsername  string
+	pushPassword  string
+)
+
+// gnmiOptions returns the gNMI options set by the push flags.
+func gnmiOptions() (*node.GNMIOptions, error) {
+	enc, ok := gpb.Encoding_value[strings.ToUpper(pushEncoding)]
+	if !ok {
+		return nil, fmt.Errorf("invalid encoding %q", pushEncoding)
+	}
+	return &node.GNMIOptions{
+		Username: pushUsername,
+		Password: pushPassword,
+		Encoding: gpb.Encoding(enc),
+		Origin:   pushOrigin,
+	}, nil
+}
+
 func pushFn(cmd *cobra.Command, args []string) error {
 	if len(args) != 3 {
 		return fmt.Errorf("%s: invalid args", cmd.Use)
 	}
+	var gOpts *node.GNMIOptions
+	switch pushTransport {
+	case "cli":
+	case "gnmi":
+		var err error
+		if gOpts, err = gnmiOptions(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid transport %q, must be cli or gnmi", pushTransport)
+	}
 	topopb, err := topo.Load(args[0])
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
@@ -185,7 +326,7 @@ func pushFn(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	t, err := topo.New(s, topopb, opts...)
+	t, err := topo.New(s, topopb, topoOptions(cmd, opts)...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
@@ -201,7 +342,21 @@ func pushFn(cmd *cobra.Command, args []string) error {
 			log.Warnf("failed to close config file %q", args[2])
 		}
 	}()
-	if err := t.ConfigPush(ctx, args[1], fp); err != nil {
+	if gOpts == nil {
+		return t.ConfigPush(ctx, args[1], fp)
+	}
+	n, err := t.Node(args[1])
+	if err != nil {
+		return err
+	}
+	gp, ok := n.(node.GNMIConfigPusher)
+	if !ok {
+		return fmt.Errorf("node %q does not support gNMI config push", n.Name())
+	}
+	if err := gp.GNMIConfigPush(ctx, fp, gOpts); err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return fmt.Errorf("node %q does not support gNMI config push: %w", n.Name(), err)
+		}
 		return err
 	}
 	return nil
@@ -219,7 +374,7 @@ func watchFn(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	t, err := topo.New(s, topopb)
+	t, err := topo.New(s, topopb, topoOptions(cmd, nil)...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
@@ -230,36 +385,114 @@ func watchFn(cmd *cobra.Command, args []string) error {
 }
 
 func certFn(cmd *cobra.Command, args []string) error {
-	if len(args) != 2 {
-		return fmt.Errorf("%s: missing args", cmd.Use)
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
 	}
 	topopb, err := topo.Load(args[0])
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
+	bp, err := fileRelative(args[0])
+	if err != nil {
+		return err
+	}
 	s, err := cmd.Flags().GetString("kubecfg")
 	if err != nil {
 		return err
 	}
-	t, err := topo.New(s, topopb)
+	t, err := topo.New(s, topopb, topoOptions(cmd, append([]topo.Option{topo.WithBasePath(bp)}, opts...))...)
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
-	t.Load(cmd.Context())
+	ctx := cmd.Context()
+	if err := t.Load(ctx); err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	if len(args) > 1 {
+		n, err := t.Node(args[1])
+		if err != nil {
+			return err
+		}
+		return t.GenerateCert(ctx, n)
+	}
+	var errList errlist.List
+	for _, n := range t.Nodes() {
+		if n.GetProto().GetConfig().GetCert() == nil {
+			continue
+		}
+		err := t.GenerateCert(ctx, n)
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			log.Infof("Skipping node %q: %v", n.Name(), err)
+		case err != nil:
+			errList.Add(fmt.Errorf("node %q: %w", n.Name(), err))
+		}
+	}
+	return errList.Err()
+}
+
+func caFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	topopb, err := topo.Load(args[0])
 	if err != nil {
 		return fmt.Errorf("%s: %w", cmd.Use, err)
 	}
-	n, err := t.Node(args[1])
+	s, err := cmd.Flags().GetString("kubecfg")
 	if err != nil {
 		return err
 	}
-	return topo.GenerateSelfSigned(cmd.Context(), n)
+	kClient, err := kubeClient(s)
+	if err != nil {
+		return err
+	}
+	ns, err := topo.Namespace(topopb, topoOptions(cmd, nil)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	b, err := pki.Bundle(cmd.Context(), kClient, ns)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(b)
+	return err
 }
 
 var (
 	getTopologyServices = topo.GetTopologyServices
+	listTopologies      = func(ctx context.Context, kubecfg string) ([]*topo.TopologyInfo, error) {
+		rCfg, err := clientcmd.BuildConfigFromFlags("", kubecfg)
+		if err != nil {
+			return nil, err
+		}
+		kClient, err := kubernetes.NewForConfig(rCfg)
+		if err != nil {
+			return nil, err
+		}
+		return topo.List(ctx, kClient)
+	}
 )
 
+func listFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	f, err := output.FromCommand(cmd, output.Table)
+	if err != nil {
+		return err
+	}
+	s, err := cmd.Flags().GetString("kubecfg")
+	if err != nil {
+		return err
+	}
+	topos, err := listTopologies(cmd.Context(), s)
+	if err != nil {
+		return err
+	}
+	return output.Write(cmd.OutOrStdout(), f, output.NewTopologyList(topos), nil)
+}
+
 func serviceFn(cmd *cobra.Command, args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("%s: missing topology", cmd.Use)
@@ -269,14 +502,17 @@ func serviceFn(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	param := topo.TopologyParams{
-		TopoName: args[0],
-		Kubecfg:  kubeCfg,
+		TopoName:       args[0],
+		Kubecfg:        kubeCfg,
+		TopoNewOptions: topoOptions(cmd, nil),
+	}
+	f, err := output.FromCommand(cmd, output.Textproto)
+	if err != nil {
+		return err
 	}
 	ts, err := getTopologyServices(cmd.Context(), param)
 	if err != nil {
 		return err
 	}
-
-	fmt.Fprintln(cmd.OutOrStdout(), prototext.Format(ts.Topology))
-	return nil
+	return output.Write(cmd.OutOrStdout(), f, output.NewTopology(ts.Topology, ts.State, nil, nil), ts.Topology)
 }
@@ -3,23 +3,39 @@ package topology
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/h-fam/errdiff"
+	topologyclientv1 "github.com/openconfig/kne/api/clientset/v1beta1"
 	tfake "github.com/openconfig/kne/api/clientset/v1beta1/fake"
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	"github.com/openconfig/kne/cmd/output"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/pki"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	rfake "k8s.io/client-go/rest/fake"
 )
 
 func NewNC(impl *node.Impl) (node.Node, error) {
@@ -314,6 +330,7 @@ func TestService(t *testing.T) {
 		args                []string
 		getTopologyServices func(ctx context.Context, params topo.TopologyParams) (*cpb.ShowTopologyResponse, error)
 		want                *tpb.Topology
+		wantJSON            *output.Topology
 		wantErr             string
 	}{
 		{
@@ -339,11 +356,32 @@ func TestService(t *testing.T) {
 			},
 			want: validProto,
 			args: []string{"service", "testdata/valid_topo.pb.txt"},
+		}, {
+			desc: "invalid output format",
+			getTopologyServices: func(context.Context, topo.TopologyParams) (*cpb.ShowTopologyResponse, error) {
+				return &cpb.ShowTopologyResponse{
+					State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+					Topology: validProto,
+				}, nil
+			},
+			wantErr: "invalid output format",
+			args:    []string{"service", "testdata/valid_topo.pb.txt", "-o", "xml"},
+		}, {
+			desc: "json output",
+			getTopologyServices: func(context.Context, topo.TopologyParams) (*cpb.ShowTopologyResponse, error) {
+				return &cpb.ShowTopologyResponse{
+					State:    cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+					Topology: validProto,
+				}, nil
+			},
+			wantJSON: output.NewTopology(validProto, cpb.TopologyState_TOPOLOGY_STATE_RUNNING, nil, nil),
+			args:     []string{"service", "testdata/valid_topo.pb.txt", "-o", "json"},
 		},
 	}
 
 	sCmd := New()
 	sCmd.PersistentFlags().String("kubecfg", "", "")
+	sCmd.PersistentFlags().StringP(output.Flag, "o", "", "")
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
 			origGetTopologyServices := getTopologyServices
@@ -362,6 +400,16 @@ func TestService(t *testing.T) {
 			if tt.wantErr != "" {
 				return
 			}
+			if tt.wantJSON != nil {
+				got := &output.Topology{}
+				if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+					t.Fatalf("Invalid buffer output: %v", err)
+				}
+				if s := cmp.Diff(tt.wantJSON, got); s != "" {
+					t.Fatalf("Service failed: unexpected diff (-want +got):\n%s", s)
+				}
+				return
+			}
 			got := &tpb.Topology{}
 			if err := prototext.Unmarshal(buf.Bytes(), got); err != nil {
 				t.Fatalf("Invalid buffer output: %v", err)
@@ -420,6 +468,18 @@ func TestPush(t *testing.T) {
 	}, {
 		desc: "valid file",
 		args: []string{"push", fConfig.Name(), "configable", confFile.Name()},
+	}, {
+		desc:    "invalid transport",
+		args:    []string{"push", fConfig.Name(), "configable", confFile.Name(), "--transport", "netconf"},
+		wantErr: `invalid transport "netconf"`,
+	}, {
+		desc:    "gnmi invalid encoding",
+		args:    []string{"push", fConfig.Name(), "configable", confFile.Name(), "--transport", "gnmi", "--encoding", "xml"},
+		wantErr: `invalid encoding "xml"`,
+	}, {
+		desc:    "gnmi device without gnmi service",
+		args:    []string{"push", fConfig.Name(), "configable", confFile.Name(), "--transport", "gnmi", "--encoding", "ascii"},
+		wantErr: "does not support gNMI config push",
 	}}
 
 	rCmd := New()
@@ -441,6 +501,7 @@ func TestPush(t *testing.T) {
 	rCmd.SetOut(buf)
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
+			pushTransport, pushEncoding = "cli", "json_ietf"
 			rCmd.SetArgs(tt.args)
 			err := rCmd.ExecuteContext(context.Background())
 			if s := errdiff.Check(err, tt.wantErr); s != "" {
@@ -452,3 +513,1104 @@ func TestPush(t *testing.T) {
 		})
 	}
 }
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		desc           string
+		args           []string
+		listTopologies func(context.Context, string) ([]*topo.TopologyInfo, error)
+		want           string
+		wantErr        string
+	}{{
+		desc:    "extra args",
+		args:    []string{"list", "foo"},
+		wantErr: "invalid args",
+	}, {
+		desc: "list error",
+		args: []string{"list"},
+		listTopologies: func(context.Context, string) ([]*topo.TopologyInfo, error) {
+			return nil, fmt.Errorf("some error")
+		},
+		wantErr: "some error",
+	}, {
+		desc: "no topologies",
+		args: []string{"list"},
+		listTopologies: func(context.Context, string) ([]*topo.TopologyInfo, error) {
+			return nil, nil
+		},
+		want: "NAME   NODES   STATE   AGE   CREATOR\n",
+	}, {
+		desc: "valid case",
+		args: []string{"list"},
+		listTopologies: func(context.Context, string) ([]*topo.TopologyInfo, error) {
+			return []*topo.TopologyInfo{{
+				Name:    "t1",
+				Nodes:   3,
+				State:   cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+				Creator: "alice",
+			}, {
+				Name:    "topology2",
+				Nodes:   10,
+				State:   cpb.TopologyState_TOPOLOGY_STATE_CREATING,
+				Creator: "bob",
+			}}, nil
+		},
+		want: `NAME        NODES   STATE      AGE         CREATOR
+t1          3       RUNNING    <unknown>   alice
+topology2   10      CREATING   <unknown>   bob
+`,
+	}, {
+		desc: "yaml output",
+		args: []string{"list", "-o", "yaml"},
+		listTopologies: func(context.Context, string) ([]*topo.TopologyInfo, error) {
+			return []*topo.TopologyInfo{{
+				Name:      "t1",
+				Namespace: "t1",
+				Nodes:     3,
+				State:     cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+				Created:   time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+				Creator:   "alice",
+			}}, nil
+		},
+		want: `topologies:
+- created: "2022-06-01T00:00:00Z"
+  creator: alice
+  name: t1
+  namespace: t1
+  nodes: 3
+  state: RUNNING
+`,
+	}}
+
+	lCmd := New()
+	lCmd.PersistentFlags().String("kubecfg", "", "")
+	lCmd.PersistentFlags().StringP(output.Flag, "o", "", "")
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			origListTopologies := listTopologies
+			listTopologies = tt.listTopologies
+			defer func() {
+				listTopologies = origListTopologies
+			}()
+			buf := bytes.NewBuffer([]byte{})
+			lCmd.SetOut(buf)
+			lCmd.SetArgs(tt.args)
+			err := lCmd.ExecuteContext(context.Background())
+			if s := errdiff.Check(err, tt.wantErr); s != "" {
+				t.Fatalf("listCmd failed: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("listCmd output unexpected: got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+type execer struct {
+	*node.Impl
+}
+
+func NewExecer(impl *node.Impl) (node.Node, error) {
+	return &execer{Impl: impl}, nil
+}
+
+func (e *execer) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	if cmd[0] == "fail" {
+		fmt.Fprintf(opts.Stderr, "%s failed", e.Name())
+		return fmt.Errorf("exit code 1")
+	}
+	fmt.Fprintf(opts.Stdout, "%s: %s\n", e.Name(), strings.Join(cmd, " "))
+	return nil
+}
+
+func TestExec(t *testing.T) {
+	tInstance := &tpb.Topology{
+		Nodes: []*tpb.Node{{
+			Name:   "r1",
+			Type:   tpb.Node_Type(1005),
+			Labels: map[string]string{"role": "spine"},
+			Config: &tpb.Config{
+				EntryCommand: "kubectl exec -it r1 -- vendor_cli",
+			},
+		}, {
+			Name:   "r2",
+			Type:   tpb.Node_Type(1005),
+			Labels: map[string]string{"role": "leaf"},
+		}, {
+			Name:   "r3",
+			Type:   tpb.Node_Type(1005),
+			Labels: map[string]string{"role": "leaf"},
+		}},
+	}
+	fTopo, closer := writeTopology(t, tInstance)
+	defer closer()
+	node.Register(tpb.Node_Type(1005), NewExecer)
+	tests := []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{{
+		desc:    "no args",
+		args:    []string{"exec"},
+		wantErr: "invalid args",
+	}, {
+		desc:    "missing device",
+		args:    []string{"exec", fTopo.Name()},
+		wantErr: "invalid args",
+	}, {
+		desc:    "invalid device",
+		args:    []string{"exec", fTopo.Name(), "dne"},
+		wantErr: `node "dne" not found`,
+	}, {
+		desc: "default cli",
+		args: []string{"exec", fTopo.Name(), "r1"},
+		want: "r1: vendor_cli\n",
+	}, {
+		desc: "command",
+		args: []string{"exec", fTopo.Name(), "r2", "--", "ip", "addr"},
+		want: "r2: ip addr\n",
+	}, {
+		desc:    "all without command",
+		args:    []string{"exec", fTopo.Name(), "--all"},
+		wantErr: "a command is required",
+	}, {
+		desc:    "all and selector",
+		args:    []string{"exec", fTopo.Name(), "--all", "--selector", "role=leaf", "--", "uptime"},
+		wantErr: "mutually exclusive",
+	}, {
+		desc: "all",
+		args: []string{"exec", fTopo.Name(), "--all", "--", "uptime"},
+		want: "=== r1 ===\nr1: uptime\n=== r2 ===\nr2: uptime\n=== r3 ===\nr3: uptime\n",
+	}, {
+		desc: "selector",
+		args: []string{"exec", fTopo.Name(), "--selector", "role=leaf", "--", "uptime"},
+		want: "=== r2 ===\nr2: uptime\n=== r3 ===\nr3: uptime\n",
+	}, {
+		desc:    "selector no match",
+		args:    []string{"exec", fTopo.Name(), "--selector", "role=dne", "--", "uptime"},
+		wantErr: "no nodes match",
+	}, {
+		desc:    "invalid selector",
+		args:    []string{"exec", fTopo.Name(), "--selector", "role=(", "--", "uptime"},
+		wantErr: "invalid selector",
+	}, {
+		desc:    "command failure",
+		args:    []string{"exec", fTopo.Name(), "--selector", "role=leaf", "--", "fail"},
+		want:    "=== r2 ===\nr2 failed\nerror: exit code 1\n=== r3 ===\nr3 failed\nerror: exit code 1\n",
+		wantErr: "exit code 1",
+	}}
+
+	origOpts := opts
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			execAll, execSelector = false, ""
+			eCmd := New()
+			eCmd.PersistentFlags().String("kubecfg", "", "")
+			eCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			eCmd.SetOut(buf)
+			eCmd.SetIn(bytes.NewReader(nil))
+			eCmd.SetArgs(tt.args)
+			err := eCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("execFn failed: %s", s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("execFn unexpected output: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	tInstance := &tpb.Topology{
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_Type(1006),
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {IntName: "Ethernet1"},
+			},
+		}},
+	}
+	fTopo, closer := writeTopology(t, tInstance)
+	defer closer()
+	node.Register(tpb.Node_Type(1006), NewExecer)
+	outFile := filepath.Join(t.TempDir(), "out.pcap")
+	tests := []struct {
+		desc     string
+		args     []string
+		want     string
+		wantFile string
+		wantErr  string
+	}{{
+		desc:    "missing interface",
+		args:    []string{"capture", fTopo.Name(), "r1"},
+		wantErr: "invalid args",
+	}, {
+		desc:    "invalid device",
+		args:    []string{"capture", fTopo.Name(), "dne", "eth1"},
+		wantErr: `node "dne" not found`,
+	}, {
+		desc: "stdout",
+		args: []string{"capture", fTopo.Name(), "r1", "eth1"},
+		want: "r1: tcpdump -U -n -i eth1 -w -\n",
+	}, {
+		desc: "vendor interface name with filter",
+		args: []string{"capture", fTopo.Name(), "r1", "Ethernet1", "-f", "tcp port 179"},
+		want: "r1: tcpdump -U -n -i eth1 -w - tcp port 179\n",
+	}, {
+		desc:     "file",
+		args:     []string{"capture", fTopo.Name(), "r1", "eth0", "-w", outFile},
+		wantFile: "r1: tcpdump -U -n -i eth0 -w -\n",
+	}, {
+		desc:    "debug container",
+		args:    []string{"capture", fTopo.Name(), "r1", "eth1", "--debug-image", "nicolaka/netshoot"},
+		wantErr: "not found",
+	}}
+
+	origOpts := opts
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			captureFile, captureFilter, captureDebugImage = "-", "", ""
+			cCmd := New()
+			cCmd.PersistentFlags().String("kubecfg", "", "")
+			cCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			cCmd.SetOut(buf)
+			cCmd.SetArgs(tt.args)
+			err := cCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("captureFn failed: %s", s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("captureFn unexpected output: got %q, want %q", got, tt.want)
+			}
+			if tt.wantFile == "" {
+				return
+			}
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("failed to read capture file: %v", err)
+			}
+			if got := string(b); got != tt.wantFile {
+				t.Fatalf("captureFn unexpected file: got %q, want %q", got, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestCollect(t *testing.T) {
+	fTopo, closer := writeTopology(t, &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_HOST,
+		}},
+	})
+	defer closer()
+	tests := []struct {
+		desc    string
+		args    []string
+		wantErr string
+	}{{
+		desc:    "no args",
+		args:    []string{"collect"},
+		wantErr: "missing topology",
+	}, {
+		desc:    "no file",
+		args:    []string{"collect", "filedne"},
+		wantErr: "no such file",
+	}, {
+		desc:    "invalid dir",
+		args:    []string{"collect", fTopo.Name(), "-d", filepath.Join(t.TempDir(), "dne")},
+		wantErr: "no such file",
+	}, {
+		desc: "valid topology",
+		args: []string{"collect", fTopo.Name(), "-d", t.TempDir()},
+	}}
+
+	origOpts := opts
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			collectDir = "."
+			cCmd := New()
+			cCmd.PersistentFlags().String("kubecfg", "", "")
+			cCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			cCmd.SetOut(buf)
+			cCmd.SetArgs(tt.args)
+			err := cCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("collectFn failed: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			p := strings.TrimSpace(buf.String())
+			if !strings.HasPrefix(filepath.Base(p), "t1-") || !strings.HasSuffix(p, ".tar.gz") {
+				t.Fatalf("collectFn unexpected bundle path %q", p)
+			}
+			if _, err := os.Stat(p); err != nil {
+				t.Fatalf("collectFn bundle not written: %v", err)
+			}
+		})
+	}
+}
+
+type configGetter struct {
+	*node.Impl
+}
+
+func NewConfigGetter(impl *node.Impl) (node.Node, error) {
+	return &configGetter{Impl: impl}, nil
+}
+
+func (c *configGetter) ConfigGet(context.Context) ([]byte, error) {
+	switch c.Name() {
+	case "fail":
+		return nil, fmt.Errorf("show running-config failed")
+	case "nocli":
+		return nil, status.Errorf(codes.Unimplemented, "no CLI")
+	}
+	return []byte(fmt.Sprintf("hostname %s\n", c.Name())), nil
+}
+
+func TestConfigSave(t *testing.T) {
+	tInstance := &tpb.Topology{
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_Type(1007),
+		}, {
+			Name: "r2",
+			Type: tpb.Node_Type(1007),
+			Config: &tpb.Config{
+				ConfigFile: "config.json",
+			},
+		}, {
+			Name: "nocli",
+			Type: tpb.Node_Type(1007),
+		}, {
+			Name: "h1",
+			Type: tpb.Node_HOST,
+		}},
+	}
+	fTopo, closer := writeTopology(t, tInstance)
+	defer closer()
+	fFail, closer := writeTopology(t, &tpb.Topology{
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_Type(1007),
+		}, {
+			Name: "fail",
+			Type: tpb.Node_Type(1007),
+		}},
+	})
+	defer closer()
+	node.Register(tpb.Node_Type(1007), NewConfigGetter)
+	tests := []struct {
+		desc      string
+		args      []string
+		wantFiles map[string]string
+		wantErr   string
+	}{{
+		desc:    "no args",
+		args:    []string{"config", "save"},
+		wantErr: "invalid args",
+	}, {
+		desc:    "no file",
+		args:    []string{"config", "save", "filedne"},
+		wantErr: "no such file",
+	}, {
+		desc:    "invalid device",
+		args:    []string{"config", "save", fTopo.Name(), "dne"},
+		wantErr: `node "dne" not found`,
+	}, {
+		desc:    "device not a config getter",
+		args:    []string{"config", "save", fTopo.Name(), "h1"},
+		wantErr: "does not support config retrieval",
+	}, {
+		desc:    "device without config retrieval",
+		args:    []string{"config", "save", fTopo.Name(), "nocli"},
+		wantErr: `node "nocli" does not support config retrieval: rpc error: code = Unimplemented desc = no CLI`,
+	}, {
+		desc: "all devices",
+		args: []string{"config", "save", fTopo.Name()},
+		wantFiles: map[string]string{
+			"r1.cfg":  "hostname r1\n",
+			"r2.json": "hostname r2\n",
+		},
+	}, {
+		desc: "device",
+		args: []string{"config", "save", fTopo.Name(), "r2"},
+		wantFiles: map[string]string{
+			"r2.json": "hostname r2\n",
+		},
+	}, {
+		desc:    "device failure",
+		args:    []string{"config", "save", fFail.Name()},
+		wantErr: "show running-config failed",
+		wantFiles: map[string]string{
+			"r1.cfg": "hostname r1\n",
+		},
+	}}
+
+	origOpts := opts
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "configs")
+			cCmd := New()
+			cCmd.PersistentFlags().String("kubecfg", "", "")
+			cCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			cCmd.SetOut(buf)
+			cCmd.SetArgs(append(tt.args, "-d", dir))
+			err := cCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("configSaveFn failed: %s", s)
+			}
+			got := map[string]string{}
+			fs, _ := os.ReadDir(dir)
+			for _, f := range fs {
+				b, err := os.ReadFile(filepath.Join(dir, f.Name()))
+				if err != nil {
+					t.Fatalf("failed to read %q: %v", f.Name(), err)
+				}
+				got[f.Name()] = string(b)
+			}
+			if tt.wantFiles == nil {
+				tt.wantFiles = map[string]string{}
+			}
+			if s := cmp.Diff(tt.wantFiles, got); s != "" {
+				t.Fatalf("configSaveFn unexpected files (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+var restored = map[string]string{}
+
+type snapshotter struct {
+	*configGetter
+}
+
+func NewSnapshotter(impl *node.Impl) (node.Node, error) {
+	return &snapshotter{&configGetter{Impl: impl}}, nil
+}
+
+func (s *snapshotter) ResetCfg(context.Context) error {
+	return nil
+}
+
+func (s *snapshotter) ConfigPush(_ context.Context, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	restored[s.Name()] = string(b)
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	fTopo, closer := writeTopology(t, &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_Type(1008),
+		}, {
+			Name: "r2",
+			Type: tpb.Node_Type(1008),
+		}},
+	})
+	defer closer()
+	node.Register(tpb.Node_Type(1008), NewSnapshotter)
+	dir := t.TempDir()
+	tests := []struct {
+		desc         string
+		args         []string
+		want         string
+		wantRestored map[string]string
+		wantErr      string
+	}{{
+		desc:    "snapshot no args",
+		args:    []string{"snapshot", fTopo.Name()},
+		wantErr: "invalid args",
+	}, {
+		desc:    "restore no args",
+		args:    []string{"restore", fTopo.Name()},
+		wantErr: "invalid args",
+	}, {
+		desc:    "restore missing snapshot",
+		args:    []string{"restore", fTopo.Name(), "base", "-d", dir},
+		wantErr: "no such file",
+	}, {
+		desc: "snapshot to dir",
+		args: []string{"snapshot", fTopo.Name(), "base", "-d", dir},
+		want: filepath.Join(dir, "base") + "\n",
+	}, {
+		desc: "restore from dir",
+		args: []string{"restore", fTopo.Name(), "base", "-d", dir},
+		wantRestored: map[string]string{
+			"r1": "hostname r1\n",
+			"r2": "hostname r2\n",
+		},
+	}, {
+		desc:    "restore missing configmap",
+		args:    []string{"restore", fTopo.Name(), "base", "--configmap"},
+		wantErr: "not found",
+	}, {
+		desc: "snapshot to configmap",
+		args: []string{"snapshot", fTopo.Name(), "base", "--configmap"},
+		want: "configmap/kne-snapshot-base\n",
+	}, {
+		desc: "snapshot to existing configmap",
+		args: []string{"snapshot", fTopo.Name(), "base", "--configmap"},
+		want: "configmap/kne-snapshot-base\n",
+	}, {
+		desc: "restore from configmap",
+		args: []string{"restore", fTopo.Name(), "base", "--configmap"},
+		wantRestored: map[string]string{
+			"r1": "hostname r1\n",
+			"r2": "hostname r2\n",
+		},
+	}}
+
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	tf.SetRestClient(&rfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		GroupVersion:         *topologyclientv1.GV(),
+		VersionedAPIPath:     topologyv1.GroupVersion,
+		Client: rfake.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"items":[]}`)),
+			}, nil
+		}),
+	})
+	kClient := kfake.NewSimpleClientset()
+	origOpts := opts
+	origKubeClient := kubeClient
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kClient),
+		topo.WithTopoClient(tf),
+	}
+	kubeClient = func(string) (kubernetes.Interface, error) {
+		return kClient, nil
+	}
+	defer func() {
+		opts = origOpts
+		kubeClient = origKubeClient
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			snapshotDir, snapshotConfigMap = ".", false
+			restored = map[string]string{}
+			sCmd := New()
+			sCmd.PersistentFlags().String("kubecfg", "", "")
+			sCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			sCmd.SetOut(buf)
+			sCmd.SetArgs(tt.args)
+			err := sCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("%s failed: %s", tt.args[0], s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("%s unexpected output: got %q, want %q", tt.args[0], got, tt.want)
+			}
+			if tt.wantRestored == nil {
+				tt.wantRestored = map[string]string{}
+			}
+			if s := cmp.Diff(tt.wantRestored, restored); s != "" {
+				t.Fatalf("%s unexpected restored configs (-want +got):\n%s", tt.args[0], s)
+			}
+		})
+	}
+}
+
+func TestCA(t *testing.T) {
+	fTopo, closer := writeTopology(t, &tpb.Topology{Name: "t1"})
+	defer closer()
+	fOther, closer := writeTopology(t, &tpb.Topology{Name: "t2"})
+	defer closer()
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	origKubeClient := kubeClient
+	kubeClient = func(string) (kubernetes.Interface, error) {
+		return kfake.NewSimpleClientset(ca.Secret("t1"), ca.Secret("alice-2")), nil
+	}
+	defer func() {
+		kubeClient = origKubeClient
+	}()
+	tests := []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{{
+		desc: "success",
+		args: []string{"ca", fTopo.Name()},
+		want: string(ca.CertPEM()),
+	}, {
+		desc: "namespace and instance",
+		args: []string{"ca", fOther.Name(), "--namespace", "alice", "--instance", "2"},
+		want: string(ca.CertPEM()),
+	}, {
+		desc:    "no ca",
+		args:    []string{"ca", fOther.Name()},
+		wantErr: "failed to get CA",
+	}, {
+		desc:    "no ca in namespace",
+		args:    []string{"ca", fTopo.Name(), "--namespace", "alice"},
+		wantErr: "failed to get CA",
+	}, {
+		desc:    "missing topology",
+		args:    []string{"ca"},
+		wantErr: "invalid args",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			caCmd := New()
+			caCmd.PersistentFlags().String("kubecfg", "", "")
+			caCmd.PersistentFlags().String("namespace", "", "")
+			caCmd.PersistentFlags().String("instance", "", "")
+			caCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			caCmd.SetOut(buf)
+			caCmd.SetArgs(tt.args)
+			err := caCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ca failed: %s", s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("ca unexpected output: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var installed = map[string]string{}
+
+type certInstaller struct {
+	*node.Impl
+}
+
+func (c *certInstaller) InstallCert(_ context.Context, cert *node.Certificate) error {
+	if c.Name() == "fail" {
+		return fmt.Errorf("install failed")
+	}
+	installed[c.Name()] = string(cert.Cert)
+	return nil
+}
+
+// runningPod returns a pod of the node name that is ready for a cert install.
+func runningPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "t1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: "10.1.1.1",
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  name,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+func TestCert(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	cert, key, err := ca.Issue(&pki.Request{CommonName: "r1", KeySize: 1024})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	loaded := &tpb.Config{
+		Cert: &tpb.CertificateCfg{
+			Config: &tpb.CertificateCfg_Loaded{
+				Loaded: &tpb.LoadedCertCfg{
+					CertName: "gnmiCert.pem",
+					KeyName:  "gnmiKey.pem",
+					Source:   &tpb.LoadedCertCfg_Files{Files: &tpb.CertFiles{Cert: "r1.crt", Key: "r1.key"}},
+				},
+			},
+		},
+	}
+	dir := t.TempDir()
+	writeFile := func(name string, b []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, b, 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return p
+	}
+	writeFile("r1.crt", cert)
+	writeFile("r1.key", key)
+	topoFile := func(name string, nodes ...*tpb.Node) string {
+		b, err := prototext.Marshal(&tpb.Topology{Name: "t1", Nodes: nodes})
+		if err != nil {
+			t.Fatalf("failed to marshal topology: %v", err)
+		}
+		return writeFile(name, b)
+	}
+	fTopo := topoFile("topo.pb.txt",
+		&tpb.Node{Name: "r1", Type: tpb.Node_Type(1009), Config: loaded},
+		&tpb.Node{Name: "r2", Type: tpb.Node_Type(1009), Config: loaded},
+		&tpb.Node{Name: "r3", Type: tpb.Node_Type(1009)},
+		&tpb.Node{Name: "h1", Type: tpb.Node_HOST, Config: loaded},
+	)
+	fFail := topoFile("fail.pb.txt",
+		&tpb.Node{Name: "r1", Type: tpb.Node_Type(1009), Config: loaded},
+		&tpb.Node{Name: "fail", Type: tpb.Node_Type(1009), Config: loaded},
+	)
+	node.Register(tpb.Node_Type(1009), func(impl *node.Impl) (node.Node, error) {
+		return &certInstaller{Impl: impl}, nil
+	})
+	tests := []struct {
+		desc          string
+		args          []string
+		wantInstalled map[string]string
+		wantErr       string
+	}{{
+		desc:    "no args",
+		args:    []string{"cert"},
+		wantErr: "invalid args",
+	}, {
+		desc:    "invalid device",
+		args:    []string{"cert", fTopo, "dne"},
+		wantErr: `node "dne" not found`,
+	}, {
+		desc:    "device not a cert installer",
+		args:    []string{"cert", fTopo, "h1"},
+		wantErr: "does not implement CertInstaller",
+	}, {
+		desc:          "device",
+		args:          []string{"cert", fTopo, "r2"},
+		wantInstalled: map[string]string{"r2": string(cert)},
+	}, {
+		desc:          "all devices",
+		args:          []string{"cert", fTopo},
+		wantInstalled: map[string]string{"r1": string(cert), "r2": string(cert)},
+	}, {
+		desc:          "device failure",
+		args:          []string{"cert", fFail},
+		wantErr:       "install failed",
+		wantInstalled: map[string]string{"r1": string(cert)},
+	}}
+
+	origOpts := opts
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset(runningPod("r1"), runningPod("r2"), runningPod("fail"))),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			installed = map[string]string{}
+			certCmd := New()
+			certCmd.PersistentFlags().String("kubecfg", "", "")
+			certCmd.SilenceUsage = true
+			certCmd.SetOut(io.Discard)
+			certCmd.SetArgs(tt.args)
+			err := certCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("cert failed: %s", s)
+			}
+			if tt.wantInstalled == nil {
+				tt.wantInstalled = map[string]string{}
+			}
+			if s := cmp.Diff(tt.wantInstalled, installed); s != "" {
+				t.Fatalf("cert unexpected installed certs (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+type linkChecker struct {
+	*node.Impl
+}
+
+// ExecWithOptions reports the interfaces of r3 as down and all others as up.
+func (l *linkChecker) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	if l.Name() == "r3" {
+		fmt.Fprintln(opts.Stdout, "down")
+		return nil
+	}
+	fmt.Fprintln(opts.Stdout, "up")
+	return nil
+}
+
+func TestVerify(t *testing.T) {
+	fTopo, closer := writeTopology(t, &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1010)},
+			{Name: "r2", Type: tpb.Node_Type(1010)},
+			{Name: "r3", Type: tpb.Node_Type(1010)},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r1", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+		},
+	})
+	defer closer()
+	node.Register(tpb.Node_Type(1010), func(impl *node.Impl) (node.Node, error) {
+		return &linkChecker{Impl: impl}, nil
+	})
+	tl := &topologyv1.TopologyList{Items: []topologyv1.Topology{{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "t1"},
+		Spec: topologyv1.TopologySpec{Links: []topologyv1.Link{
+			{LocalIntf: "eth1", PeerPod: "r2", PeerIntf: "eth1"},
+			{LocalIntf: "eth2", PeerPod: "r3", PeerIntf: "eth1"},
+		}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "r2", Namespace: "t1"},
+		Spec:       topologyv1.TopologySpec{Links: []topologyv1.Link{{LocalIntf: "eth1", PeerPod: "r1", PeerIntf: "eth1"}}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "r3", Namespace: "t1"},
+		Spec:       topologyv1.TopologySpec{Links: []topologyv1.Link{{LocalIntf: "eth1", PeerPod: "r1", PeerIntf: "eth2"}}},
+	}}}
+	b, err := json.Marshal(tl)
+	if err != nil {
+		t.Fatalf("failed to marshal topologies: %v", err)
+	}
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	tf.SetRestClient(&rfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		GroupVersion:         *topologyclientv1.GV(),
+		VersionedAPIPath:     topologyv1.GroupVersion,
+		Client: rfake.CreateHTTPClient(func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(b)),
+			}, nil
+		}),
+	})
+	origOpts := opts
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	junit := filepath.Join(t.TempDir(), "verify.xml")
+	tests := []struct {
+		desc      string
+		args      []string
+		want      string
+		wantJUnit []string
+		wantErr   string
+	}{{
+		desc:    "no args",
+		args:    []string{"verify"},
+		wantErr: "invalid args",
+	}, {
+		desc: "table",
+		args: []string{"verify", fTopo.Name()},
+		want: "A         Z         A INTERFACE   Z INTERFACE   MESHNET   PING   MESSAGE\n" +
+			"r1:eth1   r2:eth1   PASS          PASS          PASS      SKIP   no peer addresses\n" +
+			"r1:eth2   r3:eth1   PASS          FAIL          PASS      SKIP   interface eth1 is down; interfaces not up\n",
+		wantErr: "1 of 2 links failed",
+	}, {
+		desc: "junit",
+		args: []string{"verify", fTopo.Name(), "--junit", junit},
+		want: "A         Z         A INTERFACE   Z INTERFACE   MESHNET   PING   MESSAGE\n" +
+			"r1:eth1   r2:eth1   PASS          PASS          PASS      SKIP   no peer addresses\n" +
+			"r1:eth2   r3:eth1   PASS          FAIL          PASS      SKIP   interface eth1 is down; interfaces not up\n",
+		wantJUnit: []string{
+			`<testsuite name="t1" tests="8" failures="1" skipped="2">`,
+			`<testcase classname="r1:eth2 r3:eth1" name="z_interface">`,
+			`<failure message="interface eth1 is down"></failure>`,
+		},
+		wantErr: "1 of 2 links failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			verifyJUnit = ""
+			vCmd := New()
+			vCmd.PersistentFlags().String("kubecfg", "", "")
+			vCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			vCmd.SetOut(buf)
+			vCmd.SetArgs(tt.args)
+			err := vCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("verify failed: %s", s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("verify unexpected output: got %q, want %q", got, tt.want)
+			}
+			if len(tt.wantJUnit) == 0 {
+				return
+			}
+			b, err := os.ReadFile(junit)
+			if err != nil {
+				t.Fatalf("failed to read JUnit file: %v", err)
+			}
+			for _, want := range tt.wantJUnit {
+				if !strings.Contains(string(b), want) {
+					t.Errorf("verify JUnit file missing %q:\n%s", want, b)
+				}
+			}
+		})
+	}
+}
+
+type lldpReporter struct {
+	*node.Impl
+}
+
+// LLDPNeighbors reports r1:eth2 as wired to r2:eth3 and r2:eth2 without
+// neighbor.
+func (l *lldpReporter) LLDPNeighbors(context.Context) ([]*node.LLDPNeighbor, error) {
+	if l.Name() == "r1" {
+		return []*node.LLDPNeighbor{
+			{Interface: "eth1", SystemName: "r2", PortID: "eth1"},
+			{Interface: "eth2", SystemName: "r2", PortID: "eth3"},
+		}, nil
+	}
+	return []*node.LLDPNeighbor{{Interface: "eth1", SystemName: "r1", PortID: "eth1"}}, nil
+}
+
+func TestWiring(t *testing.T) {
+	fTopo, closer := writeTopology(t, &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1011)},
+			{Name: "r2", Type: tpb.Node_Type(1011)},
+		},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r1", AInt: "eth2", ZNode: "r2", ZInt: "eth2"},
+		},
+	})
+	defer closer()
+	node.Register(tpb.Node_Type(1011), func(impl *node.Impl) (node.Node, error) {
+		return &lldpReporter{Impl: impl}, nil
+	})
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	origOpts := opts
+	opts = []topo.Option{
+		topo.WithClusterConfig(&rest.Config{}),
+		topo.WithKubeClient(kfake.NewSimpleClientset()),
+		topo.WithTopoClient(tf),
+	}
+	defer func() {
+		opts = origOpts
+	}()
+	tests := []struct {
+		desc    string
+		args    []string
+		want    string
+		wantErr string
+	}{{
+		desc:    "no args",
+		args:    []string{"wiring"},
+		wantErr: "invalid args",
+	}, {
+		desc:    "invalid transport",
+		args:    []string{"wiring", fTopo.Name(), "--transport", "ssh"},
+		wantErr: `invalid transport "ssh"`,
+	}, {
+		desc: "table",
+		args: []string{"wiring", fTopo.Name()},
+		want: "NODE   INTERFACE   EXPECTED   ACTUAL    STATUS     MESSAGE\n" +
+			"r1     eth1        r2:eth1    r2:eth1   OK         \n" +
+			"r2     eth1        r1:eth1    r1:eth1   OK         \n" +
+			"r1     eth2        r2:eth2    r2:eth3   MISWIRED   \n" +
+			"r2     eth2        r1:eth2              MISSING    no LLDP neighbor\n",
+		wantErr: "2 of 4 interfaces not wired as expected",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			wiringTransport = "cli"
+			wCmd := New()
+			wCmd.PersistentFlags().String("kubecfg", "", "")
+			wCmd.SilenceUsage = true
+			buf := bytes.NewBuffer([]byte{})
+			wCmd.SetOut(buf)
+			wCmd.SetArgs(tt.args)
+			err := wCmd.ExecuteContext(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("wiring failed: %s", s)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("wiring unexpected output: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
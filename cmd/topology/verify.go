@@ -0,0 +1,155 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openconfig/kne/cmd/output"
+	"github.com/openconfig/kne/topo"
+	"github.com/spf13/cobra"
+)
+
+var verifyJUnit string
+
+// verifyResults are the results of the verification of the links of a
+// topology.
+type verifyResults []*topo.LinkResult
+
+// WriteTable writes the results as a pass/fail matrix of the links and
+// their checks.
+func (rs verifyResults) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "A\tZ\tA INTERFACE\tZ INTERFACE\tMESHNET\tPING\tMESSAGE"); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		var msgs []string
+		for _, c := range r.Checks() {
+			if c.Message != "" {
+				msgs = append(msgs, c.Message)
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.A, r.Z, r.AInterface.Status, r.ZInterface.Status, r.Meshnet.Status, r.Ping.Status, strings.Join(msgs, "; ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the results as JUnit XML with a test suite named name
+// and a test case for each check of each link.
+func (rs verifyResults) writeJUnit(w io.Writer, name string) error {
+	s := junitTestSuite{Name: name}
+	for _, r := range rs {
+		for i, c := range r.Checks() {
+			tc := junitTestCase{
+				ClassName: fmt.Sprintf("%s %s", r.A, r.Z),
+				Name:      topo.CheckNames[i],
+			}
+			switch c.Status {
+			case topo.CheckFail:
+				tc.Failure = &junitMessage{Message: c.Message}
+				s.Failures++
+			case topo.CheckSkip:
+				tc.Skipped = &junitMessage{Message: c.Message}
+				s.Skipped++
+			}
+			s.Cases = append(s.Cases, tc)
+			s.Tests++
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{s}}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func verifyFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	f, err := output.FromCommand(cmd, output.Table)
+	if err != nil {
+		return err
+	}
+	t, err := loadTopology(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	lrs, err := t.Verify(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	rs := verifyResults(lrs)
+	if err := output.Write(cmd.OutOrStdout(), f, rs, nil); err != nil {
+		return err
+	}
+	if verifyJUnit != "" {
+		fp, err := os.Create(verifyJUnit)
+		if err != nil {
+			return err
+		}
+		if err := rs.writeJUnit(fp, t.TopologyProto().GetName()); err != nil {
+			fp.Close()
+			return err
+		}
+		if err := fp.Close(); err != nil {
+			return err
+		}
+	}
+	failed := 0
+	for _, r := range rs {
+		if !r.Passed() {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%s: %d of %d links failed", cmd.Use, failed, len(rs))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topology
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/openconfig/kne/cmd/output"
+	"github.com/openconfig/kne/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/spf13/cobra"
+)
+
+var (
+	wiringTransport = "cli"
+	wiringUsername  string
+	wiringPassword  string
+)
+
+// wiringResults are the results of the audit of the wiring of a topology.
+type wiringResults []*topo.WiringResult
+
+// WriteTable writes the results with a row for each interface.
+func (rs wiringResults) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "NODE\tINTERFACE\tEXPECTED\tACTUAL\tSTATUS\tMESSAGE"); err != nil {
+		return err
+	}
+	for _, r := range rs {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Node, r.Interface, r.Expected, r.Actual, r.Status, r.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func wiringFn(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s: invalid args", cmd.Use)
+	}
+	var gOpts *node.GNMIOptions
+	switch wiringTransport {
+	case "cli":
+	case "gnmi":
+		gOpts = &node.GNMIOptions{
+			Username: wiringUsername,
+			Password: wiringPassword,
+		}
+	default:
+		return fmt.Errorf("invalid transport %q, must be cli or gnmi", wiringTransport)
+	}
+	f, err := output.FromCommand(cmd, output.Table)
+	if err != nil {
+		return err
+	}
+	t, err := loadTopology(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	wrs, err := t.AuditWiring(cmd.Context(), gOpts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cmd.Use, err)
+	}
+	rs := wiringResults(wrs)
+	if err := output.Write(cmd.OutOrStdout(), f, rs, nil); err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range rs {
+		if !r.Passed() {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%s: %d of %d interfaces not wired as expected", cmd.Use, failed, len(rs))
+	}
+	return nil
+}
@@ -34,6 +34,8 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/prototext"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
@@ -63,7 +65,7 @@ type server struct {
 	muDeploy    sync.Mutex // guards deployements map
 	deployments map[string]*deploy.Deployment
 	muTopo      sync.Mutex        // guards topos map
-	topos       map[string][]byte // stores the topology protobuf from the initial topology creation request
+	topos       map[string][]byte // stores the topology protobuf from the initial topology creation request by namespace
 }
 
 func newServer() *server {
@@ -212,7 +214,27 @@ func (s *server) ShowCluster(ctx context.Context, req *cpb.ShowClusterRequest) (
 	if err := d.Healthy(ctx); err != nil {
 		return &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_ERROR}, nil
 	}
-	return &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_RUNNING}, nil
+	kcfg, err := validatePath(defaultKubeCfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "default kubecfg %q does not exist: %v", defaultKubeCfg, err)
+	}
+	rCfg, err := clientcmd.BuildConfigFromFlags("", kcfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build config from kubecfg %q: %v", kcfg, err)
+	}
+	kClient, err := kubernetes.NewForConfig(rCfg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create kubernetes client: %v", err)
+	}
+	topos, err := topo.List(ctx, kClient)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list topologies: %v", err)
+	}
+	resp := &cpb.ShowClusterResponse{State: cpb.ClusterState_CLUSTER_STATE_RUNNING}
+	for _, t := range topos {
+		resp.TopologyNames = append(resp.TopologyNames, t.Name)
+	}
+	return resp, nil
 }
 
 func (s *server) CreateTopology(ctx context.Context, req *cpb.CreateTopologyRequest) (*cpb.CreateTopologyResponse, error) {
@@ -225,10 +247,16 @@ func (s *server) CreateTopology(ctx context.Context, req *cpb.CreateTopologyRequ
 		return nil, status.Errorf(codes.InvalidArgument, "missing topology name")
 	}
 
+	opts := []topo.Option{topo.WithTopology(topoPb), topo.WithNamespace(req.GetNamespace()), topo.WithInstance(req.GetInstance())}
+	ns, err := topo.Namespace(topoPb, opts...)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid namespace: %v", err)
+	}
+
 	s.muTopo.Lock()
 	defer s.muTopo.Unlock()
-	if _, ok := s.topos[topoPb.GetName()]; ok {
-		return nil, status.Errorf(codes.AlreadyExists, "topology %q already exists", req.Topology.GetName())
+	if _, ok := s.topos[ns]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "topology %q already exists in namespace %q", req.Topology.GetName(), ns)
 	}
 
 	for _, node := range topoPb.Nodes {
@@ -261,16 +289,17 @@ func (s *server) CreateTopology(ctx context.Context, req *cpb.CreateTopologyRequ
 		return nil, status.Errorf(codes.InvalidArgument, "kubecfg %q does not exist: %v", path, err)
 	}
 	if err := topo.CreateTopology(ctx, topo.TopologyParams{
-		TopoNewOptions: []topo.Option{topo.WithTopology(topoPb)},
+		TopoNewOptions: opts,
 		Kubecfg:        kcfg,
 	}); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create topology: %v", err)
 	}
 
-	s.topos[topoPb.GetName()] = txtPb
+	s.topos[ns] = txtPb
 	return &cpb.CreateTopologyResponse{
 		TopologyName: req.Topology.GetName(),
 		State:        cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+		Namespace:    ns,
 	}, nil
 }
 
@@ -278,9 +307,13 @@ func (s *server) DeleteTopology(ctx context.Context, req *cpb.DeleteTopologyRequ
 	log.Infof("Received DeleteTopology request: %v", req)
 	s.muTopo.Lock()
 	defer s.muTopo.Unlock()
-	txtPb, ok := s.topos[req.GetTopologyName()]
+	ns := req.GetNamespace()
+	if ns == "" {
+		ns = req.GetTopologyName()
+	}
+	txtPb, ok := s.topos[ns]
 	if !ok {
-		return nil, status.Errorf(codes.NotFound, "topology %q not found", req.GetTopologyName())
+		return nil, status.Errorf(codes.NotFound, "topology %q not found in namespace %q", req.GetTopologyName(), ns)
 	}
 	topoPb := &tpb.Topology{}
 	if err := prototext.Unmarshal(txtPb, topoPb); err != nil {
@@ -291,11 +324,12 @@ func (s *server) DeleteTopology(ctx context.Context, req *cpb.DeleteTopologyRequ
 		return nil, status.Errorf(codes.Internal, "default kubecfg %q does not exist: %v", defaultKubeCfg, err)
 	}
 	if err := topo.DeleteTopology(ctx, topo.TopologyParams{
-		TopoNewOptions: []topo.Option{topo.WithTopology(topoPb)},
+		TopoNewOptions: []topo.Option{topo.WithTopology(topoPb), topo.WithNamespace(ns)},
 		Kubecfg:        kcfg,
 	}); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to delete topology: %v", err)
 	}
+	delete(s.topos, ns)
 	return &cpb.DeleteTopologyResponse{}, nil
 }
 
@@ -303,9 +337,13 @@ func (s *server) ShowTopology(ctx context.Context, req *cpb.ShowTopologyRequest)
 	log.Infof("Received ShowTopology request: %v", req)
 	s.muTopo.Lock()
 	defer s.muTopo.Unlock()
-	txtPb, ok := s.topos[req.GetTopologyName()]
+	ns := req.GetNamespace()
+	if ns == "" {
+		ns = req.GetTopologyName()
+	}
+	txtPb, ok := s.topos[ns]
 	if !ok {
-		return nil, status.Errorf(codes.NotFound, "topology %q not found", req.GetTopologyName())
+		return nil, status.Errorf(codes.NotFound, "topology %q not found in namespace %q", req.GetTopologyName(), ns)
 	}
 	topoPb := &tpb.Topology{}
 	if err := prototext.Unmarshal(txtPb, topoPb); err != nil {
@@ -316,7 +354,7 @@ func (s *server) ShowTopology(ctx context.Context, req *cpb.ShowTopologyRequest)
 		return nil, status.Errorf(codes.Internal, "default kubecfg %q does not exist: %v", defaultKubeCfg, err)
 	}
 	resp, err := topo.GetTopologyServices(ctx, topo.TopologyParams{
-		TopoNewOptions: []topo.Option{topo.WithTopology(topoPb)},
+		TopoNewOptions: []topo.Option{topo.WithTopology(topoPb), topo.WithNamespace(ns)},
 		Kubecfg:        kcfg,
 	})
 	if err != nil {
@@ -49,21 +49,23 @@ const (
 	Vendor_QUAGGA   Vendor = 7
 	Vendor_GOBGP    Vendor = 8
 	Vendor_NOKIA    Vendor = 9
+	Vendor_QEMU     Vendor = 10
 )
 
 // Enum value maps for Vendor.
 var (
 	Vendor_name = map[int32]string{
-		0: "UNKNOWN",
-		1: "HOST",
-		2: "ARISTA",
-		3: "CISCO",
-		4: "JUNIPER",
-		5: "KEYSIGHT",
-		6: "FRR",
-		7: "QUAGGA",
-		8: "GOBGP",
-		9: "NOKIA",
+		0:  "UNKNOWN",
+		1:  "HOST",
+		2:  "ARISTA",
+		3:  "CISCO",
+		4:  "JUNIPER",
+		5:  "KEYSIGHT",
+		6:  "FRR",
+		7:  "QUAGGA",
+		8:  "GOBGP",
+		9:  "NOKIA",
+		10: "QEMU",
 	}
 	Vendor_value = map[string]int32{
 		"UNKNOWN":  0,
@@ -76,6 +78,7 @@ var (
 		"QUAGGA":   7,
 		"GOBGP":    8,
 		"NOKIA":    9,
+		"QEMU":     10,
 	}
 )
 
@@ -188,6 +191,52 @@ func (Node_Type) EnumDescriptor() ([]byte, []int) {
 	return file_topo_proto_rawDescGZIP(), []int{1, 0}
 }
 
+type BGPConfig_Policy int32
+
+const (
+	BGPConfig_ACCEPT BGPConfig_Policy = 0
+	BGPConfig_REJECT BGPConfig_Policy = 1
+)
+
+// Enum value maps for BGPConfig_Policy.
+var (
+	BGPConfig_Policy_name = map[int32]string{
+		0: "ACCEPT",
+		1: "REJECT",
+	}
+	BGPConfig_Policy_value = map[string]int32{
+		"ACCEPT": 0,
+		"REJECT": 1,
+	}
+)
+
+func (x BGPConfig_Policy) Enum() *BGPConfig_Policy {
+	p := new(BGPConfig_Policy)
+	*p = x
+	return p
+}
+
+func (x BGPConfig_Policy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BGPConfig_Policy) Descriptor() protoreflect.EnumDescriptor {
+	return file_topo_proto_enumTypes[2].Descriptor()
+}
+
+func (BGPConfig_Policy) Type() protoreflect.EnumType {
+	return &file_topo_proto_enumTypes[2]
+}
+
+func (x BGPConfig_Policy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BGPConfig_Policy.Descriptor instead.
+func (BGPConfig_Policy) EnumDescriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{8, 0}
+}
+
 // Topology message defines what nodes and links will be created
 // inside the mesh.
 type Topology struct {
@@ -409,6 +458,12 @@ type Interface struct {
 	Uid int64 `protobuf:"varint,6,opt,name=uid,proto3" json:"uid,omitempty"`
 	// Name of group to which this interface belongs
 	Group string `protobuf:"bytes,7,opt,name=group,proto3" json:"group,omitempty"`
+	// Addresses of the interface in CIDR notation, such as 192.168.0.1/31.
+	Addresses []string `protobuf:"bytes,8,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	// Addresses of the peer interface. Assigned by KNE.
+	PeerAddresses []string `protobuf:"bytes,9,rep,name=peer_addresses,json=peerAddresses,proto3" json:"peer_addresses,omitempty"`
+	// VLAN subinterfaces of the interface.
+	Vlans []*VLAN `protobuf:"bytes,10,rep,name=vlans,proto3" json:"vlans,omitempty"`
 }
 
 func (x *Interface) Reset() {
@@ -492,6 +547,84 @@ func (x *Interface) GetGroup() string {
 	return ""
 }
 
+func (x *Interface) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *Interface) GetPeerAddresses() []string {
+	if x != nil {
+		return x.PeerAddresses
+	}
+	return nil
+}
+
+func (x *Interface) GetVlans() []*VLAN {
+	if x != nil {
+		return x.Vlans
+	}
+	return nil
+}
+
+// VLAN is a VLAN subinterface.
+type VLAN struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"` // VLAN ID of the subinterface.
+	// Addresses of the subinterface in CIDR notation.
+	Addresses []string `protobuf:"bytes,2,rep,name=addresses,proto3" json:"addresses,omitempty"`
+}
+
+func (x *VLAN) Reset() {
+	*x = VLAN{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VLAN) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VLAN) ProtoMessage() {}
+
+func (x *VLAN) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VLAN.ProtoReflect.Descriptor instead.
+func (*VLAN) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VLAN) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *VLAN) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
 // Link is single link between nodes in the topology.
 // Interfaces must start eth1 - eth0 is the default k8s interface.
 type Link struct {
@@ -508,7 +641,7 @@ type Link struct {
 func (x *Link) Reset() {
 	*x = Link{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_topo_proto_msgTypes[3]
+		mi := &file_topo_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -521,7 +654,7 @@ func (x *Link) String() string {
 func (*Link) ProtoMessage() {}
 
 func (x *Link) ProtoReflect() protoreflect.Message {
-	mi := &file_topo_proto_msgTypes[3]
+	mi := &file_topo_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -534,7 +667,7 @@ func (x *Link) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Link.ProtoReflect.Descriptor instead.
 func (*Link) Descriptor() ([]byte, []int) {
-	return file_topo_proto_rawDescGZIP(), []int{3}
+	return file_topo_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *Link) GetANode() string {
@@ -591,12 +724,18 @@ type Config struct {
 	ConfigData isConfig_ConfigData `protobuf_oneof:"config_data"`
 	// Docker image to use as an init container for the pod.
 	InitImage string `protobuf:"bytes,10,opt,name=init_image,json=initImage,proto3" json:"init_image,omitempty"`
+	// VM configuration for nodes which boot a VM image in the pod.
+	Vm *VMConfig `protobuf:"bytes,11,opt,name=vm,proto3" json:"vm,omitempty"`
+	// BGP parameters of nodes generating their config from the topology.
+	Bgp *BGPConfig `protobuf:"bytes,12,opt,name=bgp,proto3" json:"bgp,omitempty"`
+	// Network configuration of nodes with interfaces configured by KNE.
+	Network *NetworkConfig `protobuf:"bytes,13,opt,name=network,proto3" json:"network,omitempty"`
 }
 
 func (x *Config) Reset() {
 	*x = Config{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_topo_proto_msgTypes[4]
+		mi := &file_topo_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -609,7 +748,7 @@ func (x *Config) String() string {
 func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
-	mi := &file_topo_proto_msgTypes[4]
+	mi := &file_topo_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -622,7 +761,7 @@ func (x *Config) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Config.ProtoReflect.Descriptor instead.
 func (*Config) Descriptor() ([]byte, []int) {
-	return file_topo_proto_rawDescGZIP(), []int{4}
+	return file_topo_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Config) GetCommand() []string {
@@ -716,6 +855,27 @@ func (x *Config) GetInitImage() string {
 	return ""
 }
 
+func (x *Config) GetVm() *VMConfig {
+	if x != nil {
+		return x.Vm
+	}
+	return nil
+}
+
+func (x *Config) GetBgp() *BGPConfig {
+	if x != nil {
+		return x.Bgp
+	}
+	return nil
+}
+
+func (x *Config) GetNetwork() *NetworkConfig {
+	if x != nil {
+		return x.Network
+	}
+	return nil
+}
+
 type isConfig_ConfigData interface {
 	isConfig_ConfigData()
 }
@@ -734,33 +894,37 @@ func (*Config_Data) isConfig_ConfigData() {}
 
 func (*Config_File) isConfig_ConfigData() {}
 
-type CertificateCfg struct {
+// NetworkConfig configures the interface addresses, VLANs and routes of a
+// node after its interfaces are wired.
+type NetworkConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Config:
-	//	*CertificateCfg_SelfSigned
-	Config isCertificateCfg_Config `protobuf_oneof:"config"`
+	// Static routes of the node.
+	Routes []*Route `protobuf:"bytes,1,rep,name=routes,proto3" json:"routes,omitempty"`
+	// Interface whose peer interface addresses in the subnets of its own
+	// addresses are the next hops of the default routes.
+	DefaultGateway string `protobuf:"bytes,2,opt,name=default_gateway,json=defaultGateway,proto3" json:"default_gateway,omitempty"`
 }
 
-func (x *CertificateCfg) Reset() {
-	*x = CertificateCfg{}
+func (x *NetworkConfig) Reset() {
+	*x = NetworkConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_topo_proto_msgTypes[5]
+		mi := &file_topo_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CertificateCfg) String() string {
+func (x *NetworkConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CertificateCfg) ProtoMessage() {}
+func (*NetworkConfig) ProtoMessage() {}
 
-func (x *CertificateCfg) ProtoReflect() protoreflect.Message {
-	mi := &file_topo_proto_msgTypes[5]
+func (x *NetworkConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -771,68 +935,52 @@ func (x *CertificateCfg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CertificateCfg.ProtoReflect.Descriptor instead.
-func (*CertificateCfg) Descriptor() ([]byte, []int) {
-	return file_topo_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use NetworkConfig.ProtoReflect.Descriptor instead.
+func (*NetworkConfig) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{6}
 }
 
-func (m *CertificateCfg) GetConfig() isCertificateCfg_Config {
-	if m != nil {
-		return m.Config
+func (x *NetworkConfig) GetRoutes() []*Route {
+	if x != nil {
+		return x.Routes
 	}
 	return nil
 }
 
-func (x *CertificateCfg) GetSelfSigned() *SelfSignedCertCfg {
-	if x, ok := x.GetConfig().(*CertificateCfg_SelfSigned); ok {
-		return x.SelfSigned
+func (x *NetworkConfig) GetDefaultGateway() string {
+	if x != nil {
+		return x.DefaultGateway
 	}
-	return nil
-}
-
-type isCertificateCfg_Config interface {
-	isCertificateCfg_Config()
-}
-
-type CertificateCfg_SelfSigned struct {
-	// self_signed will generate local certificates on the node.
-	SelfSigned *SelfSignedCertCfg `protobuf:"bytes,1,opt,name=self_signed,json=selfSigned,proto3,oneof"`
+	return ""
 }
 
-func (*CertificateCfg_SelfSigned) isCertificateCfg_Config() {}
-
-type SelfSignedCertCfg struct {
+// Route is a static route.
+type Route struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Certificate name on the node.
-	CertName string `protobuf:"bytes,1,opt,name=cert_name,json=certName,proto3" json:"cert_name,omitempty"`
-	// Key name on the node.
-	KeyName string `protobuf:"bytes,2,opt,name=key_name,json=keyName,proto3" json:"key_name,omitempty"`
-	// RSA keysize to use for key generation.
-	KeySize uint32 `protobuf:"varint,3,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
-	// Common name to set in the cert.
-	CommonName string `protobuf:"bytes,4,opt,name=common_name,json=commonName,proto3" json:"common_name,omitempty"`
+	Prefix  string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`                  // Prefix in CIDR notation.
+	NextHop string `protobuf:"bytes,2,opt,name=next_hop,json=nextHop,proto3" json:"next_hop,omitempty"` // Next hop address.
 }
 
-func (x *SelfSignedCertCfg) Reset() {
-	*x = SelfSignedCertCfg{}
+func (x *Route) Reset() {
+	*x = Route{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_topo_proto_msgTypes[6]
+		mi := &file_topo_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SelfSignedCertCfg) String() string {
+func (x *Route) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SelfSignedCertCfg) ProtoMessage() {}
+func (*Route) ProtoMessage() {}
 
-func (x *SelfSignedCertCfg) ProtoReflect() protoreflect.Message {
-	mi := &file_topo_proto_msgTypes[6]
+func (x *Route) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -843,76 +991,147 @@ func (x *SelfSignedCertCfg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SelfSignedCertCfg.ProtoReflect.Descriptor instead.
-func (*SelfSignedCertCfg) Descriptor() ([]byte, []int) {
-	return file_topo_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use Route.ProtoReflect.Descriptor instead.
+func (*Route) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SelfSignedCertCfg) GetCertName() string {
+func (x *Route) GetPrefix() string {
 	if x != nil {
-		return x.CertName
+		return x.Prefix
 	}
 	return ""
 }
 
-func (x *SelfSignedCertCfg) GetKeyName() string {
+func (x *Route) GetNextHop() string {
 	if x != nil {
-		return x.KeyName
+		return x.NextHop
 	}
 	return ""
 }
 
-func (x *SelfSignedCertCfg) GetKeySize() uint32 {
+// BGPConfig is the BGP configuration of a node. Nodes supporting it generate
+// their config with a neighbor for each peer interface address in the subnet
+// of an interface address.
+type BGPConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// AS number of the node.
+	As uint32 `protobuf:"varint,1,opt,name=as,proto3" json:"as,omitempty"`
+	// Router ID of the node, defaults to the first IPv4 interface address.
+	RouterId string `protobuf:"bytes,2,opt,name=router_id,json=routerId,proto3" json:"router_id,omitempty"`
+	// AS numbers of the neighbors by peer node name, defaults to the AS number
+	// of the node.
+	PeerAs map[string]uint32 `protobuf:"bytes,3,rep,name=peer_as,json=peerAs,proto3" json:"peer_as,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Policy for routes not matching an import policy.
+	DefaultImportPolicy BGPConfig_Policy `protobuf:"varint,4,opt,name=default_import_policy,json=defaultImportPolicy,proto3,enum=topo.BGPConfig_Policy" json:"default_import_policy,omitempty"`
+	// Policy for routes not matching an export policy.
+	DefaultExportPolicy BGPConfig_Policy `protobuf:"varint,5,opt,name=default_export_policy,json=defaultExportPolicy,proto3,enum=topo.BGPConfig_Policy" json:"default_export_policy,omitempty"`
+}
+
+func (x *BGPConfig) Reset() {
+	*x = BGPConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BGPConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BGPConfig) ProtoMessage() {}
+
+func (x *BGPConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BGPConfig.ProtoReflect.Descriptor instead.
+func (*BGPConfig) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BGPConfig) GetAs() uint32 {
 	if x != nil {
-		return x.KeySize
+		return x.As
 	}
 	return 0
 }
 
-func (x *SelfSignedCertCfg) GetCommonName() string {
+func (x *BGPConfig) GetRouterId() string {
 	if x != nil {
-		return x.CommonName
+		return x.RouterId
 	}
 	return ""
 }
 
-// Service is k8s Service to exposed to the cluster. The initial input can be
-// provided by the user for which services they would like exposed. Once the
-// service is created KNE will fill in the outside information for the user to
-// access the services.
-// The user should specify inside port for this is the port the container will
-// listen on. The outside port if provided will be the load balanced advertised
-// port.  If no outsideport is provided the inside port will be used.
-type Service struct {
+func (x *BGPConfig) GetPeerAs() map[string]uint32 {
+	if x != nil {
+		return x.PeerAs
+	}
+	return nil
+}
+
+func (x *BGPConfig) GetDefaultImportPolicy() BGPConfig_Policy {
+	if x != nil {
+		return x.DefaultImportPolicy
+	}
+	return BGPConfig_ACCEPT
+}
+
+func (x *BGPConfig) GetDefaultExportPolicy() BGPConfig_Policy {
+	if x != nil {
+		return x.DefaultExportPolicy
+	}
+	return BGPConfig_ACCEPT
+}
+
+type VMConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                            // Name of the service (optional)
-	Inside    uint32 `protobuf:"varint,2,opt,name=inside,proto3" json:"inside,omitempty"`                       // Inside port to map Node (container listening port)
-	Outside   uint32 `protobuf:"varint,3,opt,name=outside,proto3" json:"outside,omitempty"`                     // Outside port to map (target port on loadbalancer)
-	InsideIp  string `protobuf:"bytes,4,opt,name=inside_ip,json=insideIp,proto3" json:"inside_ip,omitempty"`    // Assigned by KNE.
-	OutsideIp string `protobuf:"bytes,5,opt,name=outside_ip,json=outsideIp,proto3" json:"outside_ip,omitempty"` // Assigned by KNE.
-	NodePort  uint32 `protobuf:"varint,6,opt,name=node_port,json=nodePort,proto3" json:"node_port,omitempty"`   // Assigned by KNE.
+	// Path of the disk image of the VM inside the container.
+	DiskImage string `protobuf:"bytes,1,opt,name=disk_image,json=diskImage,proto3" json:"disk_image,omitempty"`
+	// Number of virtual CPUs of the VM.
+	Cpus uint32 `protobuf:"varint,2,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	// Memory of the VM in MiB.
+	MemoryMb uint32 `protobuf:"varint,3,opt,name=memory_mb,json=memoryMb,proto3" json:"memory_mb,omitempty"`
+	// qemu model of the VM NICs, such as virtio-net-pci or e1000.
+	NicModel string `protobuf:"bytes,4,opt,name=nic_model,json=nicModel,proto3" json:"nic_model,omitempty"`
+	// Console output which marks the VM as booted.
+	ReadyPattern string `protobuf:"bytes,5,opt,name=ready_pattern,json=readyPattern,proto3" json:"ready_pattern,omitempty"`
 }
 
-func (x *Service) Reset() {
-	*x = Service{}
+func (x *VMConfig) Reset() {
+	*x = VMConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_topo_proto_msgTypes[7]
+		mi := &file_topo_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Service) String() string {
+func (x *VMConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Service) ProtoMessage() {}
+func (*VMConfig) ProtoMessage() {}
 
-func (x *Service) ProtoReflect() protoreflect.Message {
-	mi := &file_topo_proto_msgTypes[7]
+func (x *VMConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -923,50 +1142,610 @@ func (x *Service) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Service.ProtoReflect.Descriptor instead.
-func (*Service) Descriptor() ([]byte, []int) {
-	return file_topo_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use VMConfig.ProtoReflect.Descriptor instead.
+func (*VMConfig) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *Service) GetName() string {
+func (x *VMConfig) GetDiskImage() string {
 	if x != nil {
-		return x.Name
+		return x.DiskImage
 	}
 	return ""
 }
 
-func (x *Service) GetInside() uint32 {
+func (x *VMConfig) GetCpus() uint32 {
 	if x != nil {
-		return x.Inside
+		return x.Cpus
 	}
 	return 0
 }
 
-func (x *Service) GetOutside() uint32 {
+func (x *VMConfig) GetMemoryMb() uint32 {
 	if x != nil {
-		return x.Outside
+		return x.MemoryMb
 	}
 	return 0
 }
 
-func (x *Service) GetInsideIp() string {
+func (x *VMConfig) GetNicModel() string {
 	if x != nil {
-		return x.InsideIp
+		return x.NicModel
 	}
 	return ""
 }
 
-func (x *Service) GetOutsideIp() string {
+func (x *VMConfig) GetReadyPattern() string {
 	if x != nil {
-		return x.OutsideIp
+		return x.ReadyPattern
 	}
 	return ""
 }
 
-func (x *Service) GetNodePort() uint32 {
-	if x != nil {
-		return x.NodePort
-	}
+type CertificateCfg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Config:
+	//	*CertificateCfg_SelfSigned
+	//	*CertificateCfg_CaSigned
+	//	*CertificateCfg_Loaded
+	Config isCertificateCfg_Config `protobuf_oneof:"config"`
+}
+
+func (x *CertificateCfg) Reset() {
+	*x = CertificateCfg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CertificateCfg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertificateCfg) ProtoMessage() {}
+
+func (x *CertificateCfg) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertificateCfg.ProtoReflect.Descriptor instead.
+func (*CertificateCfg) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{10}
+}
+
+func (m *CertificateCfg) GetConfig() isCertificateCfg_Config {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (x *CertificateCfg) GetSelfSigned() *SelfSignedCertCfg {
+	if x, ok := x.GetConfig().(*CertificateCfg_SelfSigned); ok {
+		return x.SelfSigned
+	}
+	return nil
+}
+
+func (x *CertificateCfg) GetCaSigned() *CASignedCertCfg {
+	if x, ok := x.GetConfig().(*CertificateCfg_CaSigned); ok {
+		return x.CaSigned
+	}
+	return nil
+}
+
+func (x *CertificateCfg) GetLoaded() *LoadedCertCfg {
+	if x, ok := x.GetConfig().(*CertificateCfg_Loaded); ok {
+		return x.Loaded
+	}
+	return nil
+}
+
+type isCertificateCfg_Config interface {
+	isCertificateCfg_Config()
+}
+
+type CertificateCfg_SelfSigned struct {
+	// self_signed will generate local certificates on the node.
+	SelfSigned *SelfSignedCertCfg `protobuf:"bytes,1,opt,name=self_signed,json=selfSigned,proto3,oneof"`
+}
+
+type CertificateCfg_CaSigned struct {
+	// ca_signed will issue certificates from the topology CA and install them
+	// on the node.
+	CaSigned *CASignedCertCfg `protobuf:"bytes,2,opt,name=ca_signed,json=caSigned,proto3,oneof"`
+}
+
+type CertificateCfg_Loaded struct {
+	// loaded will install pregenerated certificates on the node.
+	Loaded *LoadedCertCfg `protobuf:"bytes,3,opt,name=loaded,proto3,oneof"` // Additional options will be for CSR and generation workflow.
+}
+
+func (*CertificateCfg_SelfSigned) isCertificateCfg_Config() {}
+
+func (*CertificateCfg_CaSigned) isCertificateCfg_Config() {}
+
+func (*CertificateCfg_Loaded) isCertificateCfg_Config() {}
+
+type SelfSignedCertCfg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Certificate name on the node.
+	CertName string `protobuf:"bytes,1,opt,name=cert_name,json=certName,proto3" json:"cert_name,omitempty"`
+	// Key name on the node.
+	KeyName string `protobuf:"bytes,2,opt,name=key_name,json=keyName,proto3" json:"key_name,omitempty"`
+	// RSA keysize to use for key generation.
+	KeySize uint32 `protobuf:"varint,3,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	// Common name to set in the cert.
+	CommonName string `protobuf:"bytes,4,opt,name=common_name,json=commonName,proto3" json:"common_name,omitempty"`
+}
+
+func (x *SelfSignedCertCfg) Reset() {
+	*x = SelfSignedCertCfg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SelfSignedCertCfg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfSignedCertCfg) ProtoMessage() {}
+
+func (x *SelfSignedCertCfg) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfSignedCertCfg.ProtoReflect.Descriptor instead.
+func (*SelfSignedCertCfg) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SelfSignedCertCfg) GetCertName() string {
+	if x != nil {
+		return x.CertName
+	}
+	return ""
+}
+
+func (x *SelfSignedCertCfg) GetKeyName() string {
+	if x != nil {
+		return x.KeyName
+	}
+	return ""
+}
+
+func (x *SelfSignedCertCfg) GetKeySize() uint32 {
+	if x != nil {
+		return x.KeySize
+	}
+	return 0
+}
+
+func (x *SelfSignedCertCfg) GetCommonName() string {
+	if x != nil {
+		return x.CommonName
+	}
+	return ""
+}
+
+type CASignedCertCfg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Certificate name on the node.
+	CertName string `protobuf:"bytes,1,opt,name=cert_name,json=certName,proto3" json:"cert_name,omitempty"`
+	// Key name on the node.
+	KeyName string `protobuf:"bytes,2,opt,name=key_name,json=keyName,proto3" json:"key_name,omitempty"`
+	// RSA keysize to use for key generation.
+	KeySize uint32 `protobuf:"varint,3,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	// Common name to set in the cert, defaults to the node name.
+	CommonName string `protobuf:"bytes,4,opt,name=common_name,json=commonName,proto3" json:"common_name,omitempty"`
+}
+
+func (x *CASignedCertCfg) Reset() {
+	*x = CASignedCertCfg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CASignedCertCfg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CASignedCertCfg) ProtoMessage() {}
+
+func (x *CASignedCertCfg) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CASignedCertCfg.ProtoReflect.Descriptor instead.
+func (*CASignedCertCfg) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CASignedCertCfg) GetCertName() string {
+	if x != nil {
+		return x.CertName
+	}
+	return ""
+}
+
+func (x *CASignedCertCfg) GetKeyName() string {
+	if x != nil {
+		return x.KeyName
+	}
+	return ""
+}
+
+func (x *CASignedCertCfg) GetKeySize() uint32 {
+	if x != nil {
+		return x.KeySize
+	}
+	return 0
+}
+
+func (x *CASignedCertCfg) GetCommonName() string {
+	if x != nil {
+		return x.CommonName
+	}
+	return ""
+}
+
+type LoadedCertCfg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Certificate name on the node.
+	CertName string `protobuf:"bytes,1,opt,name=cert_name,json=certName,proto3" json:"cert_name,omitempty"`
+	// Key name on the node.
+	KeyName string `protobuf:"bytes,2,opt,name=key_name,json=keyName,proto3" json:"key_name,omitempty"`
+	// Types that are assignable to Source:
+	//	*LoadedCertCfg_Files
+	//	*LoadedCertCfg_Secret
+	Source isLoadedCertCfg_Source `protobuf_oneof:"source"`
+}
+
+func (x *LoadedCertCfg) Reset() {
+	*x = LoadedCertCfg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadedCertCfg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadedCertCfg) ProtoMessage() {}
+
+func (x *LoadedCertCfg) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadedCertCfg.ProtoReflect.Descriptor instead.
+func (*LoadedCertCfg) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *LoadedCertCfg) GetCertName() string {
+	if x != nil {
+		return x.CertName
+	}
+	return ""
+}
+
+func (x *LoadedCertCfg) GetKeyName() string {
+	if x != nil {
+		return x.KeyName
+	}
+	return ""
+}
+
+func (m *LoadedCertCfg) GetSource() isLoadedCertCfg_Source {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (x *LoadedCertCfg) GetFiles() *CertFiles {
+	if x, ok := x.GetSource().(*LoadedCertCfg_Files); ok {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *LoadedCertCfg) GetSecret() *CertSecret {
+	if x, ok := x.GetSource().(*LoadedCertCfg_Secret); ok {
+		return x.Secret
+	}
+	return nil
+}
+
+type isLoadedCertCfg_Source interface {
+	isLoadedCertCfg_Source()
+}
+
+type LoadedCertCfg_Files struct {
+	// PEM encoded files of the certificate.
+	Files *CertFiles `protobuf:"bytes,3,opt,name=files,proto3,oneof"`
+}
+
+type LoadedCertCfg_Secret struct {
+	// Kubernetes Secret holding the certificate.
+	Secret *CertSecret `protobuf:"bytes,4,opt,name=secret,proto3,oneof"`
+}
+
+func (*LoadedCertCfg_Files) isLoadedCertCfg_Source() {}
+
+func (*LoadedCertCfg_Secret) isLoadedCertCfg_Source() {}
+
+type CertFiles struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Files are always relative to the topology configuration file.
+	// Certificate file.
+	Cert string `protobuf:"bytes,1,opt,name=cert,proto3" json:"cert,omitempty"`
+	// Key file.
+	Key string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Optional file of the issuing CA certificates.
+	Ca string `protobuf:"bytes,3,opt,name=ca,proto3" json:"ca,omitempty"`
+}
+
+func (x *CertFiles) Reset() {
+	*x = CertFiles{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CertFiles) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertFiles) ProtoMessage() {}
+
+func (x *CertFiles) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertFiles.ProtoReflect.Descriptor instead.
+func (*CertFiles) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CertFiles) GetCert() string {
+	if x != nil {
+		return x.Cert
+	}
+	return ""
+}
+
+func (x *CertFiles) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CertFiles) GetCa() string {
+	if x != nil {
+		return x.Ca
+	}
+	return ""
+}
+
+type CertSecret struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of a kubernetes.io/tls Secret with the certificate in tls.crt, the
+	// key in tls.key and optionally the issuing CA certificates in ca.crt.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Namespace of the Secret, defaults to the topology namespace.
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+}
+
+func (x *CertSecret) Reset() {
+	*x = CertSecret{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CertSecret) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertSecret) ProtoMessage() {}
+
+func (x *CertSecret) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertSecret.ProtoReflect.Descriptor instead.
+func (*CertSecret) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CertSecret) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CertSecret) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// Service is k8s Service to exposed to the cluster. The initial input can be
+// provided by the user for which services they would like exposed. Once the
+// service is created KNE will fill in the outside information for the user to
+// access the services.
+// The user should specify inside port for this is the port the container will
+// listen on. The outside port if provided will be the load balanced advertised
+// port.  If no outsideport is provided the inside port will be used.
+type Service struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                            // Name of the service (optional)
+	Inside    uint32 `protobuf:"varint,2,opt,name=inside,proto3" json:"inside,omitempty"`                       // Inside port to map Node (container listening port)
+	Outside   uint32 `protobuf:"varint,3,opt,name=outside,proto3" json:"outside,omitempty"`                     // Outside port to map (target port on loadbalancer)
+	InsideIp  string `protobuf:"bytes,4,opt,name=inside_ip,json=insideIp,proto3" json:"inside_ip,omitempty"`    // Assigned by KNE.
+	OutsideIp string `protobuf:"bytes,5,opt,name=outside_ip,json=outsideIp,proto3" json:"outside_ip,omitempty"` // Assigned by KNE.
+	NodePort  uint32 `protobuf:"varint,6,opt,name=node_port,json=nodePort,proto3" json:"node_port,omitempty"`   // Assigned by KNE.
+}
+
+func (x *Service) Reset() {
+	*x = Service{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_topo_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Service) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Service) ProtoMessage() {}
+
+func (x *Service) ProtoReflect() protoreflect.Message {
+	mi := &file_topo_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Service.ProtoReflect.Descriptor instead.
+func (*Service) Descriptor() ([]byte, []int) {
+	return file_topo_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Service) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Service) GetInside() uint32 {
+	if x != nil {
+		return x.Inside
+	}
+	return 0
+}
+
+func (x *Service) GetOutside() uint32 {
+	if x != nil {
+		return x.Outside
+	}
+	return 0
+}
+
+func (x *Service) GetInsideIp() string {
+	if x != nil {
+		return x.InsideIp
+	}
+	return ""
+}
+
+func (x *Service) GetOutsideIp() string {
+	if x != nil {
+		return x.OutsideIp
+	}
+	return ""
+}
+
+func (x *Service) GetNodePort() uint32 {
+	if x != nil {
+		return x.NodePort
+	}
 	return 0
 }
 
@@ -1037,7 +1816,7 @@ var file_topo_proto_rawDesc = []byte{
 	0x10, 0x09, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x58, 0x49, 0x41, 0x5f, 0x54, 0x47, 0x10, 0x0a, 0x12,
 	0x09, 0x0a, 0x05, 0x47, 0x4f, 0x42, 0x47, 0x50, 0x10, 0x0b, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x49,
 	0x53, 0x43, 0x4f, 0x5f, 0x58, 0x52, 0x44, 0x10, 0x0c, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x49, 0x53,
-	0x43, 0x4f, 0x5f, 0x45, 0x38, 0x30, 0x30, 0x30, 0x10, 0x0d, 0x22, 0xb5, 0x01, 0x0a, 0x09, 0x49,
+	0x43, 0x4f, 0x5f, 0x45, 0x38, 0x30, 0x30, 0x30, 0x10, 0x0d, 0x22, 0x9c, 0x02, 0x0a, 0x09, 0x49,
 	0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08,
 	0x69, 0x6e, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
@@ -1049,75 +1828,169 @@ var file_topo_proto_rawDesc = []byte{
 	0x65, 0x65, 0x72, 0x49, 0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69,
 	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05,
 	0x67, 0x72, 0x6f, 0x75, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f,
-	0x75, 0x70, 0x22, 0x5e, 0x0a, 0x04, 0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x15, 0x0a, 0x06, 0x61, 0x5f,
-	0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x4e, 0x6f, 0x64,
-	0x65, 0x12, 0x13, 0x0a, 0x05, 0x61, 0x5f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x61, 0x49, 0x6e, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x7a, 0x5f, 0x6e, 0x6f, 0x64, 0x65,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x7a, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x13, 0x0a,
-	0x05, 0x7a, 0x5f, 0x69, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x49,
-	0x6e, 0x74, 0x22, 0xae, 0x03, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x18, 0x0a,
-	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
-	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18,
-	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x69,
-	0x6d, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67,
-	0x65, 0x12, 0x27, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
-	0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x45, 0x6e, 0x76,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x6e,
-	0x74, 0x72, 0x79, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0c, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12,
-	0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x06,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x50, 0x61, 0x74, 0x68,
-	0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18,
-	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x46, 0x69, 0x6c,
-	0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x6c, 0x65, 0x65, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x05, 0x73, 0x6c, 0x65, 0x65, 0x70, 0x12, 0x28, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18,
-	0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x43, 0x65, 0x72,
-	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x66, 0x67, 0x52, 0x04, 0x63, 0x65, 0x72,
-	0x74, 0x12, 0x14, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x65, 0x20, 0x01, 0x28, 0x0c, 0x48,
-	0x00, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18,
-	0x66, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a,
-	0x0a, 0x69, 0x6e, 0x69, 0x74, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x09, 0x69, 0x6e, 0x69, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x1a, 0x36, 0x0a, 0x08,
-	0x45, 0x6e, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
-	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x3a, 0x02, 0x38, 0x01, 0x42, 0x0d, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x64,
-	0x61, 0x74, 0x61, 0x22, 0x56, 0x0a, 0x0e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x75, 0x70, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18,
+	0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73,
+	0x12, 0x25, 0x0a, 0x0e, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x65, 0x65, 0x72, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x05, 0x76, 0x6c, 0x61, 0x6e, 0x73,
+	0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x56, 0x4c,
+	0x41, 0x4e, 0x52, 0x05, 0x76, 0x6c, 0x61, 0x6e, 0x73, 0x22, 0x34, 0x0a, 0x04, 0x56, 0x4c, 0x41,
+	0x4e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x22,
+	0x5e, 0x0a, 0x04, 0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x15, 0x0a, 0x06, 0x61, 0x5f, 0x6e, 0x6f, 0x64,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x13,
+	0x0a, 0x05, 0x61, 0x5f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61,
+	0x49, 0x6e, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x7a, 0x5f, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x7a, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x13, 0x0a, 0x05, 0x7a, 0x5f,
+	0x69, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x49, 0x6e, 0x74, 0x22,
+	0xa0, 0x04, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x27,
+	0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x74, 0x6f,
+	0x70, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x45, 0x6e, 0x76, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x6e, 0x74, 0x72, 0x79,
+	0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x65, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1f, 0x0a, 0x0b,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x50, 0x61, 0x74, 0x68, 0x12, 0x1f, 0x0a,
+	0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x6c, 0x65, 0x65, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x73,
+	0x6c, 0x65, 0x65, 0x70, 0x12, 0x28, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x66, 0x67, 0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x12, 0x14,
+	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x65, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x66, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6e,
+	0x69, 0x74, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x69, 0x6e, 0x69, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x1e, 0x0a, 0x02, 0x76, 0x6d, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x56, 0x4d, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x02, 0x76, 0x6d, 0x12, 0x21, 0x0a, 0x03, 0x62, 0x67, 0x70,
+	0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x42, 0x47,
+	0x50, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x03, 0x62, 0x67, 0x70, 0x12, 0x2d, 0x0a, 0x07,
+	0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x52, 0x07, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x1a, 0x36, 0x0a, 0x08, 0x45,
+	0x6e, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x42, 0x0d, 0x0a, 0x0b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x64, 0x61,
+	0x74, 0x61, 0x22, 0x5d, 0x0a, 0x0d, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x23, 0x0a, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x65,
+	0x52, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x64, 0x65, 0x66, 0x61,
+	0x75, 0x6c, 0x74, 0x5f, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0e, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61,
+	0x79, 0x22, 0x3a, 0x0a, 0x05, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66,
+	0x69, 0x78, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x68, 0x6f, 0x70, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65, 0x78, 0x74, 0x48, 0x6f, 0x70, 0x22, 0xe3, 0x02,
+	0x0a, 0x09, 0x42, 0x47, 0x50, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x0e, 0x0a, 0x02, 0x61,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x61, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x72,
+	0x6f, 0x75, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x49, 0x64, 0x12, 0x34, 0x0a, 0x07, 0x70, 0x65, 0x65, 0x72,
+	0x5f, 0x61, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x6f, 0x70, 0x6f,
+	0x2e, 0x42, 0x47, 0x50, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x41,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x70, 0x65, 0x65, 0x72, 0x41, 0x73, 0x12, 0x4a,
+	0x0a, 0x15, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74,
+	0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e,
+	0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x42, 0x47, 0x50, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x13, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x49, 0x6d,
+	0x70, 0x6f, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x4a, 0x0a, 0x15, 0x64, 0x65,
+	0x66, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x70, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x74, 0x6f, 0x70, 0x6f,
+	0x2e, 0x42, 0x47, 0x50, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x52, 0x13, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x1a, 0x39, 0x0a, 0x0b, 0x50, 0x65, 0x65, 0x72, 0x41, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x20, 0x0a, 0x06, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x0a, 0x0a, 0x06, 0x41,
+	0x43, 0x43, 0x45, 0x50, 0x54, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x52, 0x45, 0x4a, 0x45, 0x43,
+	0x54, 0x10, 0x01, 0x22, 0x9c, 0x01, 0x0a, 0x08, 0x56, 0x4d, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x69, 0x73, 0x6b, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x69, 0x73, 0x6b, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x70, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x63,
+	0x70, 0x75, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x5f, 0x6d, 0x62,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x4d, 0x62,
+	0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x69, 0x63, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x6c, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x69, 0x63, 0x4d, 0x6f, 0x64, 0x65, 0x6c, 0x12, 0x23, 0x0a,
+	0x0d, 0x72, 0x65, 0x61, 0x64, 0x79, 0x5f, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x72, 0x65, 0x61, 0x64, 0x79, 0x50, 0x61, 0x74, 0x74, 0x65,
+	0x72, 0x6e, 0x22, 0xbb, 0x01, 0x0a, 0x0e, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
 	0x74, 0x65, 0x43, 0x66, 0x67, 0x12, 0x3a, 0x0a, 0x0b, 0x73, 0x65, 0x6c, 0x66, 0x5f, 0x73, 0x69,
 	0x67, 0x6e, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x6f, 0x70,
 	0x6f, 0x2e, 0x53, 0x65, 0x6c, 0x66, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74,
 	0x43, 0x66, 0x67, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x65, 0x6c, 0x66, 0x53, 0x69, 0x67, 0x6e, 0x65,
-	0x64, 0x42, 0x08, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x87, 0x01, 0x0a, 0x11,
-	0x53, 0x65, 0x6c, 0x66, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x43, 0x66,
-	0x67, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19,
-	0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x6b, 0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79,
-	0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6b, 0x65, 0x79,
-	0x53, 0x69, 0x7a, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x5f, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x6f,
-	0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xa8, 0x01, 0x0a, 0x07, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x12, 0x18, 0x0a,
-	0x07, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
-	0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x69, 0x64,
-	0x65, 0x5f, 0x69, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x69,
-	0x64, 0x65, 0x49, 0x70, 0x12, 0x1d, 0x0a, 0x0a, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x5f,
-	0x69, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64,
-	0x65, 0x49, 0x70, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x70, 0x6f, 0x72, 0x74,
-	0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6e, 0x6f, 0x64, 0x65, 0x50, 0x6f, 0x72, 0x74,
-	0x2a, 0x7c, 0x0a, 0x06, 0x56, 0x65, 0x6e, 0x64, 0x6f, 0x72, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e,
-	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x4f, 0x53, 0x54, 0x10,
-	0x01, 0x12, 0x0a, 0x0a, 0x06, 0x41, 0x52, 0x49, 0x53, 0x54, 0x41, 0x10, 0x02, 0x12, 0x09, 0x0a,
-	0x05, 0x43, 0x49, 0x53, 0x43, 0x4f, 0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x4a, 0x55, 0x4e, 0x49,
-	0x50, 0x45, 0x52, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x4b, 0x45, 0x59, 0x53, 0x49, 0x47, 0x48,
-	0x54, 0x10, 0x05, 0x12, 0x07, 0x0a, 0x03, 0x46, 0x52, 0x52, 0x10, 0x06, 0x12, 0x0a, 0x0a, 0x06,
-	0x51, 0x55, 0x41, 0x47, 0x47, 0x41, 0x10, 0x07, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x4f, 0x42, 0x47,
-	0x50, 0x10, 0x08, 0x12, 0x09, 0x0a, 0x05, 0x4e, 0x4f, 0x4b, 0x49, 0x41, 0x10, 0x09, 0x42, 0x22,
-	0x5a, 0x20, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x6f,
-	0x67, 0x6c, 0x65, 0x2f, 0x6b, 0x6e, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x6f,
-	0x70, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x64, 0x12, 0x34, 0x0a, 0x09, 0x63, 0x61, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x43, 0x41, 0x53, 0x69,
+	0x67, 0x6e, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x43, 0x66, 0x67, 0x48, 0x00, 0x52, 0x08, 0x63,
+	0x61, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x12, 0x2d, 0x0a, 0x06, 0x6c, 0x6f, 0x61, 0x64, 0x65,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x4c,
+	0x6f, 0x61, 0x64, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x43, 0x66, 0x67, 0x48, 0x00, 0x52, 0x06,
+	0x6c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x42, 0x08, 0x0a, 0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x22, 0x87, 0x01, 0x0a, 0x11, 0x53, 0x65, 0x6c, 0x66, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43,
+	0x65, 0x72, 0x74, 0x43, 0x66, 0x67, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19,
+	0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x6b, 0x65, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6d,
+	0x6d, 0x6f, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x85, 0x01, 0x0a, 0x0f, 0x43,
+	0x41, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43, 0x65, 0x72, 0x74, 0x43, 0x66, 0x67, 0x12, 0x1b,
+	0x0a, 0x09, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6b,
+	0x65, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6b,
+	0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x53, 0x69, 0x7a,
+	0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x4e, 0x61,
+	0x6d, 0x65, 0x22, 0xa6, 0x01, 0x0a, 0x0d, 0x4c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x43, 0x65, 0x72,
+	0x74, 0x43, 0x66, 0x67, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x65, 0x72, 0x74, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x6b, 0x65, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x05,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x74, 0x6f,
+	0x70, 0x6f, 0x2e, 0x43, 0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x48, 0x00, 0x52, 0x05,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x74, 0x6f, 0x70, 0x6f, 0x2e, 0x43, 0x65, 0x72,
+	0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x48, 0x00, 0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x42, 0x08, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x22, 0x41, 0x0a, 0x09, 0x43,
+	0x65, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x0e,
+	0x0a, 0x02, 0x63, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x63, 0x61, 0x22, 0x3e,
+	0x0a, 0x0a, 0x43, 0x65, 0x72, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x22, 0xa8,
+	0x01, 0x0a, 0x07, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06,
+	0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65,
+	0x12, 0x1b, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x5f, 0x69, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x49, 0x70, 0x12, 0x1d, 0x0a,
+	0x0a, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x5f, 0x69, 0x70, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x49, 0x70, 0x12, 0x1b, 0x0a, 0x09,
+	0x6e, 0x6f, 0x64, 0x65, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x08, 0x6e, 0x6f, 0x64, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x2a, 0x86, 0x01, 0x0a, 0x06, 0x56, 0x65,
+	0x6e, 0x64, 0x6f, 0x72, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10,
+	0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x4f, 0x53, 0x54, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x41,
+	0x52, 0x49, 0x53, 0x54, 0x41, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x43, 0x49, 0x53, 0x43, 0x4f,
+	0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x4a, 0x55, 0x4e, 0x49, 0x50, 0x45, 0x52, 0x10, 0x04, 0x12,
+	0x0c, 0x0a, 0x08, 0x4b, 0x45, 0x59, 0x53, 0x49, 0x47, 0x48, 0x54, 0x10, 0x05, 0x12, 0x07, 0x0a,
+	0x03, 0x46, 0x52, 0x52, 0x10, 0x06, 0x12, 0x0a, 0x0a, 0x06, 0x51, 0x55, 0x41, 0x47, 0x47, 0x41,
+	0x10, 0x07, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x4f, 0x42, 0x47, 0x50, 0x10, 0x08, 0x12, 0x09, 0x0a,
+	0x05, 0x4e, 0x4f, 0x4b, 0x49, 0x41, 0x10, 0x09, 0x12, 0x08, 0x0a, 0x04, 0x51, 0x45, 0x4d, 0x55,
+	0x10, 0x0a, 0x42, 0x26, 0x5a, 0x24, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x6b, 0x6e, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x6f, 0x70, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -1132,45 +2005,68 @@ func file_topo_proto_rawDescGZIP() []byte {
 	return file_topo_proto_rawDescData
 }
 
-var file_topo_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_topo_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_topo_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_topo_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_topo_proto_goTypes = []interface{}{
 	(Vendor)(0),               // 0: topo.Vendor
 	(Node_Type)(0),            // 1: topo.Node.Type
-	(*Topology)(nil),          // 2: topo.Topology
-	(*Node)(nil),              // 3: topo.Node
-	(*Interface)(nil),         // 4: topo.Interface
-	(*Link)(nil),              // 5: topo.Link
-	(*Config)(nil),            // 6: topo.Config
-	(*CertificateCfg)(nil),    // 7: topo.CertificateCfg
-	(*SelfSignedCertCfg)(nil), // 8: topo.SelfSignedCertCfg
-	(*Service)(nil),           // 9: topo.Service
-	nil,                       // 10: topo.Node.LabelsEntry
-	nil,                       // 11: topo.Node.ServicesEntry
-	nil,                       // 12: topo.Node.ConstraintsEntry
-	nil,                       // 13: topo.Node.InterfacesEntry
-	nil,                       // 14: topo.Config.EnvEntry
+	(BGPConfig_Policy)(0),     // 2: topo.BGPConfig.Policy
+	(*Topology)(nil),          // 3: topo.Topology
+	(*Node)(nil),              // 4: topo.Node
+	(*Interface)(nil),         // 5: topo.Interface
+	(*VLAN)(nil),              // 6: topo.VLAN
+	(*Link)(nil),              // 7: topo.Link
+	(*Config)(nil),            // 8: topo.Config
+	(*NetworkConfig)(nil),     // 9: topo.NetworkConfig
+	(*Route)(nil),             // 10: topo.Route
+	(*BGPConfig)(nil),         // 11: topo.BGPConfig
+	(*VMConfig)(nil),          // 12: topo.VMConfig
+	(*CertificateCfg)(nil),    // 13: topo.CertificateCfg
+	(*SelfSignedCertCfg)(nil), // 14: topo.SelfSignedCertCfg
+	(*CASignedCertCfg)(nil),   // 15: topo.CASignedCertCfg
+	(*LoadedCertCfg)(nil),     // 16: topo.LoadedCertCfg
+	(*CertFiles)(nil),         // 17: topo.CertFiles
+	(*CertSecret)(nil),        // 18: topo.CertSecret
+	(*Service)(nil),           // 19: topo.Service
+	nil,                       // 20: topo.Node.LabelsEntry
+	nil,                       // 21: topo.Node.ServicesEntry
+	nil,                       // 22: topo.Node.ConstraintsEntry
+	nil,                       // 23: topo.Node.InterfacesEntry
+	nil,                       // 24: topo.Config.EnvEntry
+	nil,                       // 25: topo.BGPConfig.PeerAsEntry
 }
 var file_topo_proto_depIdxs = []int32{
-	3,  // 0: topo.Topology.nodes:type_name -> topo.Node
-	5,  // 1: topo.Topology.links:type_name -> topo.Link
+	4,  // 0: topo.Topology.nodes:type_name -> topo.Node
+	7,  // 1: topo.Topology.links:type_name -> topo.Link
 	1,  // 2: topo.Node.type:type_name -> topo.Node.Type
-	10, // 3: topo.Node.labels:type_name -> topo.Node.LabelsEntry
-	6,  // 4: topo.Node.config:type_name -> topo.Config
-	11, // 5: topo.Node.services:type_name -> topo.Node.ServicesEntry
-	12, // 6: topo.Node.constraints:type_name -> topo.Node.ConstraintsEntry
+	20, // 3: topo.Node.labels:type_name -> topo.Node.LabelsEntry
+	8,  // 4: topo.Node.config:type_name -> topo.Config
+	21, // 5: topo.Node.services:type_name -> topo.Node.ServicesEntry
+	22, // 6: topo.Node.constraints:type_name -> topo.Node.ConstraintsEntry
 	0,  // 7: topo.Node.vendor:type_name -> topo.Vendor
-	13, // 8: topo.Node.interfaces:type_name -> topo.Node.InterfacesEntry
-	14, // 9: topo.Config.env:type_name -> topo.Config.EnvEntry
-	7,  // 10: topo.Config.cert:type_name -> topo.CertificateCfg
-	8,  // 11: topo.CertificateCfg.self_signed:type_name -> topo.SelfSignedCertCfg
-	9,  // 12: topo.Node.ServicesEntry.value:type_name -> topo.Service
-	4,  // 13: topo.Node.InterfacesEntry.value:type_name -> topo.Interface
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	23, // 8: topo.Node.interfaces:type_name -> topo.Node.InterfacesEntry
+	6,  // 9: topo.Interface.vlans:type_name -> topo.VLAN
+	24, // 10: topo.Config.env:type_name -> topo.Config.EnvEntry
+	13, // 11: topo.Config.cert:type_name -> topo.CertificateCfg
+	12, // 12: topo.Config.vm:type_name -> topo.VMConfig
+	11, // 13: topo.Config.bgp:type_name -> topo.BGPConfig
+	9,  // 14: topo.Config.network:type_name -> topo.NetworkConfig
+	10, // 15: topo.NetworkConfig.routes:type_name -> topo.Route
+	25, // 16: topo.BGPConfig.peer_as:type_name -> topo.BGPConfig.PeerAsEntry
+	2,  // 17: topo.BGPConfig.default_import_policy:type_name -> topo.BGPConfig.Policy
+	2,  // 18: topo.BGPConfig.default_export_policy:type_name -> topo.BGPConfig.Policy
+	14, // 19: topo.CertificateCfg.self_signed:type_name -> topo.SelfSignedCertCfg
+	15, // 20: topo.CertificateCfg.ca_signed:type_name -> topo.CASignedCertCfg
+	16, // 21: topo.CertificateCfg.loaded:type_name -> topo.LoadedCertCfg
+	17, // 22: topo.LoadedCertCfg.files:type_name -> topo.CertFiles
+	18, // 23: topo.LoadedCertCfg.secret:type_name -> topo.CertSecret
+	19, // 24: topo.Node.ServicesEntry.value:type_name -> topo.Service
+	5,  // 25: topo.Node.InterfacesEntry.value:type_name -> topo.Interface
+	26, // [26:26] is the sub-list for method output_type
+	26, // [26:26] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_topo_proto_init() }
@@ -1216,7 +2112,7 @@ func file_topo_proto_init() {
 			}
 		}
 		file_topo_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Link); i {
+			switch v := v.(*VLAN); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1228,7 +2124,7 @@ func file_topo_proto_init() {
 			}
 		}
 		file_topo_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Config); i {
+			switch v := v.(*Link); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1240,7 +2136,7 @@ func file_topo_proto_init() {
 			}
 		}
 		file_topo_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CertificateCfg); i {
+			switch v := v.(*Config); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1252,7 +2148,7 @@ func file_topo_proto_init() {
 			}
 		}
 		file_topo_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SelfSignedCertCfg); i {
+			switch v := v.(*NetworkConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1264,6 +2160,114 @@ func file_topo_proto_init() {
 			}
 		}
 		file_topo_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Route); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BGPConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VMConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CertificateCfg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SelfSignedCertCfg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CASignedCertCfg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadedCertCfg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CertFiles); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CertSecret); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_topo_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Service); i {
 			case 0:
 				return &v.state
@@ -1276,20 +2280,26 @@ func file_topo_proto_init() {
 			}
 		}
 	}
-	file_topo_proto_msgTypes[4].OneofWrappers = []interface{}{
+	file_topo_proto_msgTypes[5].OneofWrappers = []interface{}{
 		(*Config_Data)(nil),
 		(*Config_File)(nil),
 	}
-	file_topo_proto_msgTypes[5].OneofWrappers = []interface{}{
+	file_topo_proto_msgTypes[10].OneofWrappers = []interface{}{
 		(*CertificateCfg_SelfSigned)(nil),
+		(*CertificateCfg_CaSigned)(nil),
+		(*CertificateCfg_Loaded)(nil),
+	}
+	file_topo_proto_msgTypes[13].OneofWrappers = []interface{}{
+		(*LoadedCertCfg_Files)(nil),
+		(*LoadedCertCfg_Secret)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_topo_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   13,
+			NumEnums:      3,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,161 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package capture streams packet captures from the interfaces of KNE nodes.
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+)
+
+// Options configures a capture.
+type Options struct {
+	// Interfaces are the node interfaces to capture.
+	Interfaces []string
+	// Filter is a BPF filter expression applied to all interfaces.
+	Filter string
+	// Container is the container running tcpdump. It defaults to the node
+	// container.
+	Container string
+	// PCAPNG writes a pcapng stream for a single interface. Captures of
+	// several interfaces are always written as pcapng.
+	PCAPNG bool
+	// Stderr receives the diagnostics of tcpdump.
+	Stderr io.Writer
+}
+
+// Command returns the tcpdump command writing a pcap stream of intf to
+// stdout.
+func Command(intf, filter string) []string {
+	cmd := []string{"tcpdump", "-U", "-n", "-i", intf, "-w", "-"}
+	if filter != "" {
+		cmd = append(cmd, filter)
+	}
+	return cmd
+}
+
+// Run captures the interfaces in opts on the node and writes the capture to w
+// until the capture is stopped or ctx is canceled. A single interface is
+// written as a pcap stream unless opts.PCAPNG is set, several interfaces are
+// merged into a single pcapng stream with one interface per capture.
+func Run(ctx context.Context, e node.Execer, w io.Writer, opts *Options) error {
+	if len(opts.Interfaces) == 0 {
+		return fmt.Errorf("no interfaces to capture")
+	}
+	if len(opts.Interfaces) == 1 && !opts.PCAPNG {
+		return e.ExecWithOptions(ctx, Command(opts.Interfaces[0], opts.Filter), &node.ExecOptions{
+			Stdout:    w,
+			Stderr:    opts.Stderr,
+			Container: opts.Container,
+		})
+	}
+	ngw, err := newPCAPNGWriter(w)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type record struct {
+		idx int
+		hdr *pcapHeader
+		pkt *packet
+	}
+	records := make(chan record)
+	errs := make([]error, len(opts.Interfaces))
+	var wg sync.WaitGroup
+	for i, intf := range opts.Interfaces {
+		pr, pw := io.Pipe()
+		wg.Add(2)
+		go func(i int, intf string) {
+			defer wg.Done()
+			err := e.ExecWithOptions(ctx, Command(intf, opts.Filter), &node.ExecOptions{
+				Stdout:    pw,
+				Stderr:    opts.Stderr,
+				Container: opts.Container,
+			})
+			if err != nil {
+				err = fmt.Errorf("capture of %q failed: %w", intf, err)
+			}
+			pw.CloseWithError(err)
+		}(i, intf)
+		go func(i int) {
+			defer wg.Done()
+			// Closing the reader unblocks the capture if the stream is
+			// abandoned before it ends.
+			defer pr.Close()
+			r, err := newPCAPReader(pr)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			send := func(rec record) bool {
+				select {
+				case records <- rec:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			if !send(record{idx: i, hdr: &r.hdr}) {
+				return
+			}
+			for {
+				p, err := r.next()
+				if err != nil {
+					if err != io.EOF {
+						errs[i] = err
+					}
+					return
+				}
+				if !send(record{idx: i, pkt: p}) {
+					return
+				}
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(records)
+	}()
+
+	ids := map[int]uint32{}
+	for rec := range records {
+		if rec.hdr != nil {
+			intf := opts.Interfaces[rec.idx]
+			log.Infof("Capturing %q", intf)
+			id, err := ngw.addInterface(intf, *rec.hdr)
+			if err != nil {
+				return err
+			}
+			ids[rec.idx] = id
+			continue
+		}
+		if err := ngw.writePacket(ids[rec.idx], rec.pkt); err != nil {
+			return err
+		}
+	}
+	var errList errlist.List
+	for _, err := range errs {
+		errList.Add(err)
+	}
+	return errList.Err()
+}
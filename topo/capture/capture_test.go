@@ -0,0 +1,214 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	"github.com/openconfig/kne/topo/node"
+)
+
+// pcapStream returns a little endian microsecond pcap stream with one
+// packet per payload.
+func pcapStream(payloads ...string) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{pcapMagicMicro, 4 | 2<<16, 0, 0, 65535, 1})
+	for i, p := range payloads {
+		binary.Write(&b, binary.LittleEndian, []uint32{uint32(i + 1), 500, uint32(len(p)), uint32(len(p))})
+		b.WriteString(p)
+	}
+	return b.Bytes()
+}
+
+type fakeExecer struct {
+	streams map[string][]byte
+	cmds    [][]string
+}
+
+func (f *fakeExecer) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	intf := cmd[4]
+	s, ok := f.streams[intf]
+	if !ok {
+		fmt.Fprintf(opts.Stderr, "tcpdump: %s: No such device exists\n", intf)
+		return fmt.Errorf("command terminated with exit code 1")
+	}
+	_, err := opts.Stdout.Write(s)
+	return err
+}
+
+type block struct {
+	typ  uint32
+	body []byte
+}
+
+func readBlocks(t *testing.T, b []byte) []block {
+	t.Helper()
+	var blocks []block
+	for len(b) > 0 {
+		if len(b) < 12 {
+			t.Fatalf("truncated block: %x", b)
+		}
+		l := binary.LittleEndian.Uint32(b[4:])
+		if l%4 != 0 || int(l) > len(b) || binary.LittleEndian.Uint32(b[l-4:]) != l {
+			t.Fatalf("invalid block length %d", l)
+		}
+		blocks = append(blocks, block{typ: binary.LittleEndian.Uint32(b), body: b[8 : l-4]})
+		b = b[l:]
+	}
+	return blocks
+}
+
+func TestCommand(t *testing.T) {
+	if got, want := Command("eth1", ""), []string{"tcpdump", "-U", "-n", "-i", "eth1", "-w", "-"}; !cmp.Equal(got, want) {
+		t.Errorf("Command() got %q, want %q", got, want)
+	}
+	if got, want := Command("eth1", "tcp port 179"), []string{"tcpdump", "-U", "-n", "-i", "eth1", "-w", "-", "tcp port 179"}; !cmp.Equal(got, want) {
+		t.Errorf("Command() got %q, want %q", got, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	e := &fakeExecer{streams: map[string][]byte{
+		"eth1": pcapStream("abc", "defgh"),
+		"eth2": pcapStream("ijkl"),
+		"bad":  []byte("not a pcap stream at all, really"),
+	}}
+	tests := []struct {
+		desc       string
+		opts       *Options
+		wantPCAP   []byte
+		wantIntfs  []string
+		wantPkts   []string
+		wantErr    string
+		wantStderr string
+	}{{
+		desc:    "no interfaces",
+		opts:    &Options{},
+		wantErr: "no interfaces",
+	}, {
+		desc:     "single interface",
+		opts:     &Options{Interfaces: []string{"eth1"}},
+		wantPCAP: e.streams["eth1"],
+	}, {
+		desc:       "single interface failure",
+		opts:       &Options{Interfaces: []string{"eth3"}},
+		wantErr:    "exit code 1",
+		wantStderr: "tcpdump: eth3: No such device exists\n",
+	}, {
+		desc:      "single interface pcapng",
+		opts:      &Options{Interfaces: []string{"eth1"}, PCAPNG: true},
+		wantIntfs: []string{"eth1"},
+		wantPkts:  []string{"abc", "defgh"},
+	}, {
+		desc:      "multiple interfaces",
+		opts:      &Options{Interfaces: []string{"eth1", "eth2"}},
+		wantIntfs: []string{"eth1", "eth2"},
+		wantPkts:  []string{"abc", "defgh", "ijkl"},
+	}, {
+		desc:       "multiple interfaces with failure",
+		opts:       &Options{Interfaces: []string{"eth1", "eth3"}},
+		wantIntfs:  []string{"eth1"},
+		wantPkts:   []string{"abc", "defgh"},
+		wantErr:    `capture of "eth3" failed`,
+		wantStderr: "tcpdump: eth3: No such device exists\n",
+	}, {
+		desc:      "invalid stream",
+		opts:      &Options{Interfaces: []string{"eth2", "bad"}},
+		wantIntfs: []string{"eth2"},
+		wantPkts:  []string{"ijkl"},
+		wantErr:   "invalid pcap magic",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var out, stderr bytes.Buffer
+			tt.opts.Stderr = &stderr
+			err := Run(context.Background(), e, &out, tt.opts)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Run() unexpected error: %s", s)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("Run() unexpected stderr: got %q, want %q", got, tt.wantStderr)
+			}
+			if tt.wantPCAP != nil {
+				if !bytes.Equal(out.Bytes(), tt.wantPCAP) {
+					t.Fatalf("Run() got %x, want %x", out.Bytes(), tt.wantPCAP)
+				}
+				return
+			}
+			if tt.wantIntfs == nil {
+				return
+			}
+			blocks := readBlocks(t, out.Bytes())
+			if blocks[0].typ != pcapngSectionHeader {
+				t.Fatalf("Run() first block type %x, want section header", blocks[0].typ)
+			}
+			var intfs []string
+			var pkts []string
+			for _, b := range blocks[1:] {
+				switch b.typ {
+				case pcapngInterfaceDesc:
+					if lt := binary.LittleEndian.Uint16(b.body); lt != 1 {
+						t.Errorf("Run() got link type %d, want 1", lt)
+					}
+					opt := b.body[8:]
+					if code := binary.LittleEndian.Uint16(opt); code != pcapngOptInterfaceName {
+						t.Fatalf("Run() got option %d, want interface name", code)
+					}
+					intfs = append(intfs, string(opt[4:4+binary.LittleEndian.Uint16(opt[2:])]))
+				case pcapngEnhancedPacket:
+					id := binary.LittleEndian.Uint32(b.body)
+					if int(id) >= len(intfs) {
+						t.Fatalf("Run() packet for undefined interface %d", id)
+					}
+					ts := uint64(binary.LittleEndian.Uint32(b.body[4:]))<<32 | uint64(binary.LittleEndian.Uint32(b.body[8:]))
+					if ts%1000000 != 500 {
+						t.Errorf("Run() got timestamp %d, want 500us fraction", ts)
+					}
+					capLen := binary.LittleEndian.Uint32(b.body[12:])
+					pkts = append(pkts, string(b.body[20:20+capLen]))
+				default:
+					t.Fatalf("Run() unexpected block type %x", b.typ)
+				}
+			}
+			sort.Strings(intfs)
+			sort.Strings(pkts)
+			if s := cmp.Diff(tt.wantIntfs, intfs); s != "" {
+				t.Errorf("Run() unexpected interfaces (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantPkts, pkts); s != "" {
+				t.Errorf("Run() unexpected packets (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestPCAPReader(t *testing.T) {
+	var be bytes.Buffer
+	binary.Write(&be, binary.BigEndian, []uint32{pcapMagicNano, 4 | 2<<16, 0, 0, 1500, 113})
+	binary.Write(&be, binary.BigEndian, []uint32{7, 9, 2, 60})
+	be.WriteString("hi")
+	r, err := newPCAPReader(&be)
+	if err != nil {
+		t.Fatalf("newPCAPReader() failed: %v", err)
+	}
+	if !r.hdr.nano || r.hdr.linkType != 113 || r.hdr.snapLen != 1500 {
+		t.Fatalf("newPCAPReader() unexpected header: %+v", r.hdr)
+	}
+	p, err := r.next()
+	if err != nil {
+		t.Fatalf("next() failed: %v", err)
+	}
+	want := &packet{sec: 7, subsec: 9, origLen: 60, data: []byte("hi")}
+	if s := cmp.Diff(want, p, cmp.AllowUnexported(packet{})); s != "" {
+		t.Fatalf("next() unexpected diff (-want +got):\n%s", s)
+	}
+	if _, err := r.next(); err != io.EOF {
+		t.Fatalf("next() got %v, want EOF", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package capture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	pcapMagicMicro = 0xa1b2c3d4
+	pcapMagicNano  = 0xa1b23c4d
+
+	pcapngSectionHeader    = 0x0a0d0d0a
+	pcapngInterfaceDesc    = 0x00000001
+	pcapngEnhancedPacket   = 0x00000006
+	pcapngByteOrderMagic   = 0x1a2b3c4d
+	pcapngOptEnd           = 0
+	pcapngOptInterfaceName = 2
+	pcapngOptTSResolution  = 9
+
+	// maxSnapLen is the largest record accepted when the snap length of the
+	// stream is smaller, it matches the tcpdump default.
+	maxSnapLen = 262144
+)
+
+// pcapHeader is the global header of a pcap stream.
+type pcapHeader struct {
+	order    binary.ByteOrder
+	nano     bool
+	snapLen  uint32
+	linkType uint32
+}
+
+// packet is a single record of a pcap stream.
+type packet struct {
+	sec     uint32
+	subsec  uint32
+	origLen uint32
+	data    []byte
+}
+
+// pcapReader reads the records of a pcap stream.
+type pcapReader struct {
+	r   io.Reader
+	hdr pcapHeader
+}
+
+// newPCAPReader reads the global header of the pcap stream in r.
+func newPCAPReader(r io.Reader) (*pcapReader, error) {
+	b := make([]byte, 24)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("failed to read pcap header: %w", err)
+	}
+	pr := &pcapReader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(b) == pcapMagicMicro:
+		pr.hdr.order = binary.LittleEndian
+	case binary.LittleEndian.Uint32(b) == pcapMagicNano:
+		pr.hdr.order, pr.hdr.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(b) == pcapMagicMicro:
+		pr.hdr.order = binary.BigEndian
+	case binary.BigEndian.Uint32(b) == pcapMagicNano:
+		pr.hdr.order, pr.hdr.nano = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("invalid pcap magic %x", b[:4])
+	}
+	pr.hdr.snapLen = pr.hdr.order.Uint32(b[16:])
+	pr.hdr.linkType = pr.hdr.order.Uint32(b[20:])
+	return pr, nil
+}
+
+// next returns the next packet of the stream or io.EOF at the end of the
+// stream.
+func (pr *pcapReader) next() (*packet, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(pr.r, b); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated pcap record header: %w", err)
+		}
+		return nil, err
+	}
+	p := &packet{
+		sec:     pr.hdr.order.Uint32(b),
+		subsec:  pr.hdr.order.Uint32(b[4:]),
+		origLen: pr.hdr.order.Uint32(b[12:]),
+	}
+	capLen := pr.hdr.order.Uint32(b[8:])
+	if capLen > pr.hdr.snapLen && capLen > maxSnapLen {
+		return nil, fmt.Errorf("invalid pcap record length %d", capLen)
+	}
+	p.data = make([]byte, capLen)
+	if _, err := io.ReadFull(pr.r, p.data); err != nil {
+		return nil, fmt.Errorf("truncated pcap record: %w", err)
+	}
+	return p, nil
+}
+
+// pcapngWriter writes a pcapng stream with one section and one interface per
+// captured link.
+type pcapngWriter struct {
+	w    io.Writer
+	hdrs []pcapHeader
+}
+
+// newPCAPNGWriter writes the section header to w.
+func newPCAPNGWriter(w io.Writer) (*pcapngWriter, error) {
+	b := make([]byte, 28)
+	binary.LittleEndian.PutUint32(b, pcapngSectionHeader)
+	binary.LittleEndian.PutUint32(b[4:], 28)
+	binary.LittleEndian.PutUint32(b[8:], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(b[12:], 1)
+	binary.LittleEndian.PutUint16(b[14:], 0)
+	// Section length is unknown for a stream.
+	binary.LittleEndian.PutUint64(b[16:], 0xffffffffffffffff)
+	binary.LittleEndian.PutUint32(b[24:], 28)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	return &pcapngWriter{w: w}, nil
+}
+
+// addInterface writes an interface description block for the pcap stream
+// described by hdr and returns the interface id.
+func (pw *pcapngWriter) addInterface(name string, hdr pcapHeader) (uint32, error) {
+	var opts []byte
+	opts = appendOption(opts, pcapngOptInterfaceName, []byte(name))
+	if hdr.nano {
+		opts = appendOption(opts, pcapngOptTSResolution, []byte{9})
+	}
+	opts = appendOption(opts, pcapngOptEnd, nil)
+	l := 20 + len(opts)
+	b := make([]byte, 16, l)
+	binary.LittleEndian.PutUint32(b, pcapngInterfaceDesc)
+	binary.LittleEndian.PutUint32(b[4:], uint32(l))
+	binary.LittleEndian.PutUint16(b[8:], uint16(hdr.linkType))
+	binary.LittleEndian.PutUint32(b[12:], hdr.snapLen)
+	b = append(b, opts...)
+	b = appendUint32(b, uint32(l))
+	if _, err := pw.w.Write(b); err != nil {
+		return 0, err
+	}
+	pw.hdrs = append(pw.hdrs, hdr)
+	return uint32(len(pw.hdrs) - 1), nil
+}
+
+// writePacket writes p as an enhanced packet block of interface id.
+func (pw *pcapngWriter) writePacket(id uint32, p *packet) error {
+	if int(id) >= len(pw.hdrs) {
+		return fmt.Errorf("unknown interface id %d", id)
+	}
+	units := uint64(1000000)
+	if pw.hdrs[id].nano {
+		units = 1000000000
+	}
+	ts := uint64(p.sec)*units + uint64(p.subsec)
+	pad := (4 - len(p.data)%4) % 4
+	l := 32 + len(p.data) + pad
+	b := make([]byte, 28, l)
+	binary.LittleEndian.PutUint32(b, pcapngEnhancedPacket)
+	binary.LittleEndian.PutUint32(b[4:], uint32(l))
+	binary.LittleEndian.PutUint32(b[8:], id)
+	binary.LittleEndian.PutUint32(b[12:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(b[16:], uint32(ts))
+	binary.LittleEndian.PutUint32(b[20:], uint32(len(p.data)))
+	binary.LittleEndian.PutUint32(b[24:], p.origLen)
+	b = append(b, p.data...)
+	b = append(b, make([]byte, pad)...)
+	b = appendUint32(b, uint32(l))
+	_, err := pw.w.Write(b)
+	return err
+}
+
+// appendOption appends a pcapng option padded to 32 bits.
+func appendOption(b []byte, code uint16, v []byte) []byte {
+	o := make([]byte, 4)
+	binary.LittleEndian.PutUint16(o, code)
+	binary.LittleEndian.PutUint16(o[2:], uint16(len(v)))
+	b = append(b, o...)
+	b = append(b, v...)
+	return append(b, make([]byte, (4-len(v)%4)%4)...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	u := make([]byte, 4)
+	binary.LittleEndian.PutUint32(u, v)
+	return append(b, u...)
+}
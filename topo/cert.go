@@ -0,0 +1,311 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topo
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/pki"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// certWaitInterval is the interval between checks for the pods and
+	// services of a node to be ready before installing its cert.
+	certWaitInterval = time.Second
+	// lbIPTimeout is how long to wait for the loadbalancer IPs of a node
+	// before issuing its cert without them.
+	lbIPTimeout = time.Minute
+)
+
+// caCertKey is the key of the issuing CA certificates in a TLS Secret.
+const caCertKey = "ca.crt"
+
+// GenerateCert creates the cert of the node from its cert config. CA signed
+// certs are issued by the topology CA, loaded certs are read from files or a
+// Secret and other certs are generated on the node.
+func (m *Manager) GenerateCert(ctx context.Context, n node.Node) error {
+	switch {
+	case n.GetProto().GetConfig().GetCert().GetCaSigned() != nil:
+		return m.IssueCert(ctx, n)
+	case n.GetProto().GetConfig().GetCert().GetLoaded() != nil:
+		return m.LoadCert(ctx, n)
+	}
+	return GenerateSelfSigned(ctx, n)
+}
+
+// CA returns the certificate authority of the topology. The CA is created
+// and stored in the topology namespace the first time it is requested.
+func (m *Manager) CA(ctx context.Context) (*pki.CA, error) {
+	secrets := m.kClient.CoreV1().Secrets(m.namespace)
+	s, err := secrets.Get(ctx, pki.SecretName, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		return pki.FromSecret(s)
+	case !apierrors.IsNotFound(err):
+		return nil, err
+	}
+	log.Infof("Creating CA for topology %q", m.proto.Name)
+	ca, err := pki.NewCA(fmt.Sprintf("KNE %s CA", m.proto.Name))
+	if err != nil {
+		return nil, err
+	}
+	s = ca.Secret(m.namespace)
+	s.Labels = map[string]string{TopologyLabel: m.proto.Name}
+	if _, err := secrets.Create(ctx, s, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to store CA: %w", err)
+	}
+	return ca, nil
+}
+
+// deleteCA deletes the certificate authority of the topology from the
+// topology namespace, if it was created by CA.
+func (m *Manager) deleteCA(ctx context.Context) error {
+	secrets := m.kClient.CoreV1().Secrets(m.namespace)
+	s, err := secrets.Get(ctx, pki.SecretName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	case s.Labels[TopologyLabel] != m.proto.Name:
+		return nil
+	}
+	return secrets.Delete(ctx, pki.SecretName, metav1.DeleteOptions{})
+}
+
+// IssueCert issues a cert for the node from the topology CA and installs it
+// on the node. The cert is valid for the node name, pod IP and service IPs of
+// the node. If the node doesn't fulfil CertInstaller then
+// status.Unimplemented will be returned.
+func (m *Manager) IssueCert(ctx context.Context, n node.Node) error {
+	cfg := n.GetProto().GetConfig().GetCert().GetCaSigned()
+	if cfg == nil {
+		log.Debugf("No CA signed cert info for %s", n.Name())
+		return nil
+	}
+	ci, ok := n.(node.CertInstaller)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "node %s does not implement CertInstaller interface", n.Name())
+	}
+	ca, err := m.CA(ctx)
+	if err != nil {
+		return err
+	}
+	if err := waitForCertInstall(ctx, n); err != nil {
+		return err
+	}
+	dnsNames, ips, err := certSANs(ctx, n)
+	if err != nil {
+		return err
+	}
+	cn := cfg.GetCommonName()
+	if cn == "" {
+		cn = n.Name()
+	}
+	log.Infof("Issuing cert for node %s with SANs %v %v", n.Name(), dnsNames, ips)
+	cert, key, err := ca.Issue(&pki.Request{
+		CommonName: cn,
+		KeySize:    int(cfg.GetKeySize()),
+		DNSNames:   dnsNames,
+		IPs:        ips,
+	})
+	if err != nil {
+		return err
+	}
+	return ci.InstallCert(ctx, &node.Certificate{
+		Name:    cfg.GetCertName(),
+		KeyName: cfg.GetKeyName(),
+		Cert:    cert,
+		Key:     key,
+		CA:      ca.CertPEM(),
+	})
+}
+
+// LoadCert installs the pregenerated cert of the node read from the files or
+// Secret of its cert config. Files are relative to the topology base path. If
+// the node doesn't fulfil CertInstaller then status.Unimplemented will be
+// returned.
+func (m *Manager) LoadCert(ctx context.Context, n node.Node) error {
+	cfg := n.GetProto().GetConfig().GetCert().GetLoaded()
+	if cfg == nil {
+		log.Debugf("No loaded cert info for %s", n.Name())
+		return nil
+	}
+	ci, ok := n.(node.CertInstaller)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "node %s does not implement CertInstaller interface", n.Name())
+	}
+	c := &node.Certificate{
+		Name:    cfg.GetCertName(),
+		KeyName: cfg.GetKeyName(),
+	}
+	switch v := cfg.GetSource().(type) {
+	case *tpb.LoadedCertCfg_Files:
+		for _, f := range []struct {
+			p string
+			b *[]byte
+		}{
+			{v.Files.GetCert(), &c.Cert},
+			{v.Files.GetKey(), &c.Key},
+			{v.Files.GetCa(), &c.CA},
+		} {
+			if f.p == "" {
+				continue
+			}
+			p := f.p
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(m.BasePath, p)
+			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return fmt.Errorf("failed to read cert of node %s: %w", n.Name(), err)
+			}
+			*f.b = b
+		}
+	case *tpb.LoadedCertCfg_Secret:
+		ns := v.Secret.GetNamespace()
+		if ns == "" {
+			ns = m.namespace
+		}
+		s, err := m.kClient.CoreV1().Secrets(ns).Get(ctx, v.Secret.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get cert of node %s: %w", n.Name(), err)
+		}
+		c.Cert = s.Data[corev1.TLSCertKey]
+		c.Key = s.Data[corev1.TLSPrivateKeyKey]
+		c.CA = s.Data[caCertKey]
+	default:
+		return fmt.Errorf("node %s has no cert files or secret", n.Name())
+	}
+	if _, err := tls.X509KeyPair(c.Cert, c.Key); err != nil {
+		return fmt.Errorf("invalid cert of node %s: %w", n.Name(), err)
+	}
+	if err := waitForCertInstall(ctx, n); err != nil {
+		return err
+	}
+	log.Infof("Loading cert for node %s", n.Name())
+	return ci.InstallCert(ctx, c)
+}
+
+// podsRunning returns true if all pods of the node are running with an IP and
+// all their containers are running, so files can be written into them.
+func podsRunning(pods []*corev1.Pod) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, p := range pods {
+		if p.Status.Phase != corev1.PodRunning || net.ParseIP(p.Status.PodIP) == nil || len(p.Status.ContainerStatuses) == 0 {
+			return false
+		}
+		for _, c := range p.Status.ContainerStatuses {
+			if c.State.Running == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lbIPsAssigned returns true if all loadbalancer services have an ingress IP.
+func lbIPsAssigned(svcs []*corev1.Service) bool {
+	for _, s := range svcs {
+		if s.Spec.Type == corev1.ServiceTypeLoadBalancer && len(s.Status.LoadBalancer.Ingress) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForCertInstall waits for the containers of the node to be running, as
+// certs are installed by execing into them, and for its loadbalancer
+// services to be assigned an IP, which is included in the cert. A cert is
+// issued without the loadbalancer IPs if they are not assigned within
+// lbIPTimeout.
+func waitForCertInstall(ctx context.Context, n node.Node) error {
+	lbDeadline := time.Now().Add(lbIPTimeout)
+	for {
+		pods, err := n.Pods(ctx)
+		if err != nil {
+			return err
+		}
+		running := podsRunning(pods)
+		svcs, err := n.Services(ctx)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		lbIPs := lbIPsAssigned(svcs)
+		switch {
+		case running && lbIPs:
+			return nil
+		case running && time.Now().After(lbDeadline):
+			log.Warnf("No loadbalancer IP assigned to node %s, issuing cert without it", n.Name())
+			return nil
+		case !running:
+			log.Infof("Waiting for pod of node %s to be running", n.Name())
+		default:
+			log.Infof("Waiting for loadbalancer IP of node %s", n.Name())
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node %s not ready for cert install: %w", n.Name(), ctx.Err())
+		case <-time.After(certWaitInterval):
+		}
+	}
+}
+
+// certSANs returns the subject alternative names of the cert of the node: its
+// name and service names, and its pod and service IPs.
+func certSANs(ctx context.Context, n node.Node) ([]string, []net.IP, error) {
+	pods, err := n.Pods(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ips []net.IP
+	for _, p := range pods {
+		if ip := net.ParseIP(p.Status.PodIP); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	dnsNames := []string{n.Name()}
+	svcs, err := n.Services(ctx)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	for _, s := range svcs {
+		dnsNames = append(dnsNames, s.Name, fmt.Sprintf("%s.%s.svc", s.Name, s.Namespace))
+		if ip := net.ParseIP(s.Spec.ClusterIP); ip != nil {
+			ips = append(ips, ip)
+		}
+		for _, in := range s.Status.LoadBalancer.Ingress {
+			if ip := net.ParseIP(in.IP); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return dnsNames, ips, nil
+}
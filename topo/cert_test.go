@@ -0,0 +1,359 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tfake "github.com/openconfig/kne/api/clientset/v1beta1/fake"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/pki"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+type installer struct {
+	*node.Impl
+	cert *node.Certificate
+}
+
+// InstallCert fails like an exec into a container that is not running yet.
+func (i *installer) InstallCert(ctx context.Context, c *node.Certificate) error {
+	pods, err := i.Pods(ctx)
+	if err != nil {
+		return err
+	}
+	if !podsRunning(pods) {
+		return fmt.Errorf("unable to upgrade connection: container not found (%q)", i.Name())
+	}
+	i.cert = c
+	return nil
+}
+
+func runningPod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "t1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: ip,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  name,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+}
+
+func init() {
+	node.Register(tpb.Node_Type(1003), func(impl *node.Impl) (node.Node, error) {
+		return &installer{Impl: impl}, nil
+	})
+}
+
+func caSignedNode(name string, t tpb.Node_Type) *tpb.Node {
+	return &tpb.Node{
+		Name: name,
+		Type: t,
+		Config: &tpb.Config{
+			Cert: &tpb.CertificateCfg{
+				Config: &tpb.CertificateCfg_CaSigned{
+					CaSigned: &tpb.CASignedCertCfg{
+						CertName: "gnmiCert.pem",
+						KeyName:  "gnmiKey.pem",
+						KeySize:  1024,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestIssueCert(t *testing.T) {
+	oldInterval, oldLBTimeout := certWaitInterval, lbIPTimeout
+	defer func() { certWaitInterval, lbIPTimeout = oldInterval, oldLBTimeout }()
+	certWaitInterval = time.Millisecond
+	lbIPTimeout = 20 * time.Millisecond
+
+	lbService := func(name string, ingress ...corev1.LoadBalancerIngress) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-" + name, Namespace: "t1"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.96.0.10"},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: ingress},
+			},
+		}
+	}
+	initWait := runningPod("r1", "10.1.1.1")
+	initWait.Status.Phase = corev1.PodPending
+	initWait.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}
+	noLBIP := lbService("r1")
+	tests := []struct {
+		desc      string
+		node      *tpb.Node
+		objs      []runtime.Object
+		update    []runtime.Object
+		wantNames []string
+		wantErr   string
+		wantCode  codes.Code
+	}{{
+		desc:      "success",
+		node:      caSignedNode("r1", tpb.Node_Type(1003)),
+		objs:      []runtime.Object{runningPod("r1", "10.1.1.1"), lbService("r1", corev1.LoadBalancerIngress{IP: "192.168.18.100"})},
+		wantNames: []string{"r1", "service-r1", "service-r1.t1.svc", "10.1.1.1", "10.96.0.10", "192.168.18.100"},
+	}, {
+		desc:      "pod not running yet",
+		node:      caSignedNode("r1", tpb.Node_Type(1003)),
+		objs:      []runtime.Object{initWait, noLBIP},
+		update:    []runtime.Object{runningPod("r1", "10.1.1.1"), lbService("r1", corev1.LoadBalancerIngress{IP: "192.168.18.100"})},
+		wantNames: []string{"r1", "service-r1", "service-r1.t1.svc", "10.1.1.1", "10.96.0.10", "192.168.18.100"},
+	}, {
+		desc:      "no loadbalancer ip",
+		node:      caSignedNode("r1", tpb.Node_Type(1003)),
+		objs:      []runtime.Object{runningPod("r1", "10.1.1.1"), noLBIP},
+		wantNames: []string{"r1", "service-r1", "service-r1.t1.svc", "10.1.1.1", "10.96.0.10"},
+	}, {
+		desc: "no cert config",
+		node: &tpb.Node{Name: "r1", Type: tpb.Node_Type(1003)},
+	}, {
+		desc:     "not a cert installer",
+		node:     caSignedNode("r1", tpb.Node_HOST),
+		wantErr:  "does not implement CertInstaller",
+		wantCode: codes.Unimplemented,
+	}, {
+		desc:    "pod never running",
+		node:    caSignedNode("r1", tpb.Node_Type(1003)),
+		objs:    []runtime.Object{initWait, noLBIP},
+		wantErr: "not ready for cert install",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tf, err := tfake.NewSimpleClientset()
+			if err != nil {
+				t.Fatalf("cannot create fake topology clientset")
+			}
+			kClient := kfake.NewSimpleClientset(tt.objs...)
+			if tt.update != nil {
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					for _, o := range tt.update {
+						if err := kClient.Tracker().Update(podOrServiceGVR(o), o, "t1"); err != nil {
+							t.Errorf("Update() failed: %v", err)
+						}
+					}
+				}()
+			}
+			m, err := New("", &tpb.Topology{Name: "t1", Nodes: []*tpb.Node{tt.node}},
+				WithClusterConfig(&rest.Config{}), WithKubeClient(kClient), WithTopoClient(tf))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			if err := m.Load(context.Background()); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			n, err := m.Node(tt.node.Name)
+			if err != nil {
+				t.Fatalf("Node() failed: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			err = m.GenerateCert(ctx, n)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GenerateCert() unexpected error: %s", s)
+			}
+			if tt.wantCode != codes.OK && status.Code(err) != tt.wantCode {
+				t.Fatalf("GenerateCert() got code %v, want %v", status.Code(err), tt.wantCode)
+			}
+			if tt.wantNames == nil {
+				return
+			}
+			c := n.(*installer).cert
+			if c.Name != "gnmiCert.pem" || c.KeyName != "gnmiKey.pem" {
+				t.Errorf("GenerateCert() installed %q/%q, want gnmiCert.pem/gnmiKey.pem", c.Name, c.KeyName)
+			}
+			bundle, err := pki.Bundle(ctx, kClient, "t1")
+			if err != nil {
+				t.Fatalf("Bundle() failed: %v", err)
+			}
+			if string(bundle) != string(c.CA) {
+				t.Errorf("GenerateCert() installed CA that is not the topology CA")
+			}
+			pool, err := pki.CertPool(bundle)
+			if err != nil {
+				t.Fatalf("CertPool() failed: %v", err)
+			}
+			b, _ := pem.Decode(c.Cert)
+			cert, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				t.Fatalf("GenerateCert() installed invalid cert: %v", err)
+			}
+			for _, name := range tt.wantNames {
+				if _, err := cert.Verify(x509.VerifyOptions{DNSName: name, Roots: pool}); err != nil {
+					t.Errorf("Verify(%q) failed: %v", name, err)
+				}
+			}
+			// The CA is reused by later certs of the topology.
+			if err := m.GenerateCert(ctx, n); err != nil {
+				t.Fatalf("GenerateCert() failed: %v", err)
+			}
+			if string(n.(*installer).cert.CA) != string(bundle) {
+				t.Errorf("GenerateCert() created a new CA for the second cert")
+			}
+		})
+	}
+}
+
+func podOrServiceGVR(o runtime.Object) schema.GroupVersionResource {
+	if _, ok := o.(*corev1.Pod); ok {
+		return corev1.SchemeGroupVersion.WithResource("pods")
+	}
+	return corev1.SchemeGroupVersion.WithResource("services")
+}
+
+func loadedNode(cfg *tpb.LoadedCertCfg) *tpb.Node {
+	cfg.CertName, cfg.KeyName = "gnmiCert.pem", "gnmiKey.pem"
+	return &tpb.Node{
+		Name: "r1",
+		Type: tpb.Node_Type(1003),
+		Config: &tpb.Config{
+			Cert: &tpb.CertificateCfg{
+				Config: &tpb.CertificateCfg_Loaded{Loaded: cfg},
+			},
+		},
+	}
+}
+
+func TestLoadCert(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	cert, key, err := ca.Issue(&pki.Request{CommonName: "r1", KeySize: 1024})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	dir := t.TempDir()
+	for name, b := range map[string][]byte{"r1.crt": cert, "r1.key": key, "ca.crt": ca.CertPEM(), "bad.key": []byte("bad")} {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	secret := func(name, ns string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Type:       corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       cert,
+				corev1.TLSPrivateKeyKey: key,
+				"ca.crt":                ca.CertPEM(),
+			},
+		}
+	}
+	kClient := kfake.NewSimpleClientset(secret("r1-cert", "t1"), secret("pki-cert", "pki"), runningPod("r1", "10.1.1.1"))
+	tests := []struct {
+		desc    string
+		node    *tpb.Node
+		want    *node.Certificate
+		wantErr string
+	}{{
+		desc: "files",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Files{Files: &tpb.CertFiles{Cert: "r1.crt", Key: "r1.key", Ca: "ca.crt"}},
+		}),
+		want: &node.Certificate{Name: "gnmiCert.pem", KeyName: "gnmiKey.pem", Cert: cert, Key: key, CA: ca.CertPEM()},
+	}, {
+		desc: "files without ca",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Files{Files: &tpb.CertFiles{Cert: "r1.crt", Key: filepath.Join(dir, "r1.key")}},
+		}),
+		want: &node.Certificate{Name: "gnmiCert.pem", KeyName: "gnmiKey.pem", Cert: cert, Key: key},
+	}, {
+		desc: "topology secret",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Secret{Secret: &tpb.CertSecret{Name: "r1-cert"}},
+		}),
+		want: &node.Certificate{Name: "gnmiCert.pem", KeyName: "gnmiKey.pem", Cert: cert, Key: key, CA: ca.CertPEM()},
+	}, {
+		desc: "secret in namespace",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Secret{Secret: &tpb.CertSecret{Name: "pki-cert", Namespace: "pki"}},
+		}),
+		want: &node.Certificate{Name: "gnmiCert.pem", KeyName: "gnmiKey.pem", Cert: cert, Key: key, CA: ca.CertPEM()},
+	}, {
+		desc: "missing file",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Files{Files: &tpb.CertFiles{Cert: "r2.crt", Key: "r1.key"}},
+		}),
+		wantErr: "failed to read cert",
+	}, {
+		desc: "mismatched key",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Files{Files: &tpb.CertFiles{Cert: "r1.crt", Key: "bad.key"}},
+		}),
+		wantErr: "invalid cert",
+	}, {
+		desc: "missing secret",
+		node: loadedNode(&tpb.LoadedCertCfg{
+			Source: &tpb.LoadedCertCfg_Secret{Secret: &tpb.CertSecret{Name: "r2-cert"}},
+		}),
+		wantErr: "failed to get cert",
+	}, {
+		desc:    "no source",
+		node:    loadedNode(&tpb.LoadedCertCfg{}),
+		wantErr: "no cert files or secret",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tf, err := tfake.NewSimpleClientset()
+			if err != nil {
+				t.Fatalf("cannot create fake topology clientset")
+			}
+			m, err := New("", &tpb.Topology{Name: "t1", Nodes: []*tpb.Node{tt.node}},
+				WithClusterConfig(&rest.Config{}), WithKubeClient(kClient), WithTopoClient(tf), WithBasePath(dir))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			if err := m.Load(context.Background()); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			n, err := m.Node(tt.node.Name)
+			if err != nil {
+				t.Fatalf("Node() failed: %v", err)
+			}
+			err = m.GenerateCert(context.Background(), n)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GenerateCert() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, n.(*installer).cert); s != "" {
+				t.Errorf("GenerateCert() unexpected cert (-want +got):\n%s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// bundleTimeFormat is the timestamp format used in support bundle names.
+const bundleTimeFormat = "20060102-150405"
+
+var now = time.Now
+
+// Collect writes a gzipped tarball with the diagnostics of the topology to w.
+// The bundle contains the resolved topology, the meshnet topologies and
+// namespace events, and for each node its pods, services, container logs and
+// the vendor diagnostics of nodes implementing node.Collector. Items that
+// cannot be collected are listed in errors.txt of the bundle.
+func (m *Manager) Collect(ctx context.Context, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	b := &bundle{
+		tw:  tar.NewWriter(gw),
+		dir: fmt.Sprintf("%s-%s", m.namespace, now().UTC().Format(bundleTimeFormat)),
+		now: now(),
+	}
+	if err := b.add("topology.pb.txt", []byte(prototext.Format(m.proto))); err != nil {
+		return err
+	}
+	if ts, err := m.TopologyResources(ctx); err != nil {
+		b.failed("topologies.yaml", err)
+	} else if err := b.addYAML("topologies.yaml", ts); err != nil {
+		return err
+	}
+	if es, err := m.kClient.CoreV1().Events(m.namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		b.failed("events.yaml", err)
+	} else {
+		sort.SliceStable(es.Items, func(i, j int) bool {
+			return es.Items[i].LastTimestamp.Before(&es.Items[j].LastTimestamp)
+		})
+		if err := b.addYAML("events.yaml", es.Items); err != nil {
+			return err
+		}
+	}
+	for _, n := range m.Nodes() {
+		if err := m.collectNode(ctx, b, n); err != nil {
+			return err
+		}
+	}
+	if len(b.errs) != 0 {
+		if err := b.add("errors.txt", []byte(strings.Join(b.errs, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// collectNode adds the diagnostics of n to the bundle. Only errors writing the
+// bundle are returned.
+func (m *Manager) collectNode(ctx context.Context, b *bundle, n node.Node) error {
+	dir := n.Name()
+	pods, err := n.Pods(ctx)
+	if err != nil {
+		b.failed(path.Join(dir, "pods.yaml"), err)
+	} else {
+		if err := b.addYAML(path.Join(dir, "pods.yaml"), pods); err != nil {
+			return err
+		}
+		for _, p := range pods {
+			if err := m.collectLogs(ctx, b, dir, p); err != nil {
+				return err
+			}
+		}
+	}
+	if svcs, err := n.Services(ctx); err != nil {
+		b.failed(path.Join(dir, "services.yaml"), err)
+	} else if err := b.addYAML(path.Join(dir, "services.yaml"), svcs); err != nil {
+		return err
+	}
+	c, ok := n.(node.Collector)
+	if !ok {
+		return nil
+	}
+	log.Infof("Collecting vendor diagnostics from %q", n.Name())
+	files, err := c.Collect(ctx)
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		log.Infof("Skipping vendor diagnostics of %q: %v", n.Name(), err)
+	case err != nil:
+		b.failed(path.Join(dir, "vendor"), err)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := b.add(path.Join(dir, "vendor", name), files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectLogs adds the current and previous logs of each container in p.
+func (m *Manager) collectLogs(ctx context.Context, b *bundle, dir string, p *corev1.Pod) error {
+	restarts := map[string]int32{}
+	for _, s := range p.Status.ContainerStatuses {
+		restarts[s.Name] = s.RestartCount
+	}
+	var containers []string
+	for _, c := range p.Spec.InitContainers {
+		containers = append(containers, c.Name)
+	}
+	for _, c := range p.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	for _, c := range containers {
+		prefix := path.Join(dir, "logs", p.Name+"_"+c)
+		logs, err := m.kClient.CoreV1().Pods(p.Namespace).GetLogs(p.Name, &corev1.PodLogOptions{Container: c}).DoRaw(ctx)
+		if err != nil {
+			b.failed(prefix+".log", err)
+		} else if err := b.add(prefix+".log", logs); err != nil {
+			return err
+		}
+		if restarts[c] == 0 {
+			continue
+		}
+		logs, err = m.kClient.CoreV1().Pods(p.Namespace).GetLogs(p.Name, &corev1.PodLogOptions{Container: c, Previous: true}).DoRaw(ctx)
+		if err != nil {
+			b.failed(prefix+".previous.log", err)
+		} else if err := b.add(prefix+".previous.log", logs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CollectToDir writes the support bundle of the topology to a timestamped
+// tarball in dir and returns its path. The tarball is removed if the bundle
+// cannot be collected.
+func CollectToDir(ctx context.Context, t TopologyManager, dir string) (string, error) {
+	name := fmt.Sprintf("%s-%s.tar.gz", t.Namespace(), now().UTC().Format(bundleTimeFormat))
+	p := filepath.Join(dir, name)
+	f, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	if err := t.Collect(ctx, f); err != nil {
+		f.Close()
+		os.Remove(p)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(p)
+		return "", err
+	}
+	return p, nil
+}
+
+// bundle writes the files of a support bundle into a tarball.
+type bundle struct {
+	tw   *tar.Writer
+	dir  string
+	now  time.Time
+	errs []string
+}
+
+func (b *bundle) add(name string, data []byte) error {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:    path.Join(b.dir, name),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: b.now,
+	}); err != nil {
+		return err
+	}
+	_, err := b.tw.Write(data)
+	return err
+}
+
+func (b *bundle) addYAML(name string, v interface{}) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		b.failed(name, err)
+		return nil
+	}
+	return b.add(name, data)
+}
+
+// failed records that the item could not be collected.
+func (b *bundle) failed(item string, err error) {
+	log.Warnf("Failed to collect %s: %v", item, err)
+	b.errs = append(b.errs, fmt.Sprintf("%s: %v", item, err))
+}
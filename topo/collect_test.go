@@ -0,0 +1,289 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tfake "github.com/openconfig/kne/api/clientset/v1beta1/fake"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+type collector struct {
+	*node.Impl
+}
+
+func (c *collector) Collect(context.Context) (map[string][]byte, error) {
+	return map[string][]byte{"show-version.txt": []byte("version 1.0")}, fmt.Errorf("show tech failed")
+}
+
+func init() {
+	node.Register(tpb.Node_Type(1001), func(impl *node.Impl) (node.Node, error) {
+		return &collector{Impl: impl}, nil
+	})
+}
+
+// readBundle returns the files of a gzipped tarball keyed by name.
+func readBundle(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("failed to read gzip: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	files := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %v", err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read %q: %v", h.Name, err)
+		}
+		files[h.Name] = string(b)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	origNow := now
+	now = func() time.Time { return time.Date(2022, 6, 1, 10, 20, 30, 0, time.UTC) }
+	defer func() {
+		now = origNow
+	}()
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	kClient := kfake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "t1"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init-r1"}},
+			Containers:     []corev1.Container{{Name: "r1"}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "r1", RestartCount: 1}},
+		},
+	}, &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1.1", Namespace: "t1"},
+		Reason:     "Started",
+	})
+	m, err := New("", &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: tpb.Node_Type(1001),
+		}, {
+			Name: "r2",
+			Type: tpb.Node_HOST,
+		}},
+	}, WithClusterConfig(&rest.Config{}), WithKubeClient(kClient), WithTopoClient(tf))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := m.Collect(context.Background(), &buf); err != nil {
+		t.Fatalf("Collect() failed: %v", err)
+	}
+	files := readBundle(t, &buf)
+	var got []string
+	for name := range files {
+		got = append(got, name)
+	}
+	want := []string{
+		"t1-20220601-102030/topology.pb.txt",
+		"t1-20220601-102030/events.yaml",
+		"t1-20220601-102030/r1/pods.yaml",
+		"t1-20220601-102030/r1/logs/r1_init-r1.log",
+		"t1-20220601-102030/r1/logs/r1_r1.log",
+		"t1-20220601-102030/r1/logs/r1_r1.previous.log",
+		"t1-20220601-102030/r1/vendor/show-version.txt",
+		"t1-20220601-102030/errors.txt",
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if s := cmp.Diff(want, got); s != "" {
+		t.Fatalf("Collect() unexpected files (-want +got):\n%s", s)
+	}
+	for name, wantSub := range map[string]string{
+		"t1-20220601-102030/topology.pb.txt":            `"alpine:latest"`,
+		"t1-20220601-102030/events.yaml":                "reason: Started",
+		"t1-20220601-102030/r1/pods.yaml":               "name: r1",
+		"t1-20220601-102030/r1/logs/r1_r1.log":          "fake logs",
+		"t1-20220601-102030/r1/vendor/show-version.txt": "version 1.0",
+		"t1-20220601-102030/errors.txt":                 "r1/vendor: show tech failed",
+	} {
+		if !strings.Contains(files[name], wantSub) {
+			t.Errorf("Collect() file %q missing %q, got:\n%s", name, wantSub, files[name])
+		}
+	}
+	for _, sub := range []string{"topologies.yaml", "r1/services.yaml", "r2/pods.yaml", "r2/services.yaml"} {
+		if !strings.Contains(files["t1-20220601-102030/errors.txt"], sub) {
+			t.Errorf("Collect() errors.txt missing %q", sub)
+		}
+	}
+}
+
+type collectTopology struct {
+	defaultFakeTopology
+	pErr error
+	cErr error
+}
+
+func (f *collectTopology) TopologyProto() *tpb.Topology {
+	return &tpb.Topology{Name: "t1"}
+}
+
+func (f *collectTopology) Namespace() string {
+	return "t1"
+}
+
+func (f *collectTopology) Push(context.Context) error {
+	return f.pErr
+}
+
+func (f *collectTopology) Resources(context.Context) (*Resources, error) {
+	return &Resources{}, nil
+}
+
+func (f *collectTopology) Collect(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("bundle")); err != nil {
+		return err
+	}
+	return f.cErr
+}
+
+func TestCollectToDir(t *testing.T) {
+	origNow := now
+	now = func() time.Time { return time.Date(2022, 6, 1, 10, 20, 30, 0, time.UTC) }
+	defer func() { now = origNow }()
+	tests := []struct {
+		desc    string
+		cErr    error
+		wantErr string
+	}{{
+		desc: "success",
+	}, {
+		desc:    "collect failure",
+		cErr:    fmt.Errorf("collect failed"),
+		wantErr: "collect failed",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			p, err := CollectToDir(context.Background(), &collectTopology{cErr: tt.cErr}, dir)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("CollectToDir() unexpected error: %s", s)
+			}
+			files, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read dir: %v", err)
+			}
+			if tt.wantErr != "" {
+				if len(files) != 0 {
+					t.Fatalf("CollectToDir() left %d files in %s, want none", len(files), dir)
+				}
+				return
+			}
+			if want := filepath.Join(dir, "t1-20220601-102030.tar.gz"); p != want {
+				t.Fatalf("CollectToDir() got path %q, want %q", p, want)
+			}
+			if len(files) != 1 {
+				t.Fatalf("CollectToDir() wrote %d files, want 1", len(files))
+			}
+		})
+	}
+}
+
+func TestCreateTopologyCollect(t *testing.T) {
+	origNow := now
+	now = func() time.Time { return time.Date(2022, 6, 1, 10, 20, 30, 0, time.UTC) }
+	origNew := new
+	defer func() {
+		now = origNow
+		new = origNew
+	}()
+	tests := []struct {
+		desc       string
+		pErr       error
+		cancel     bool
+		wantErr    string
+		wantBundle bool
+	}{{
+		desc: "success",
+	}, {
+		desc:       "push failure",
+		pErr:       fmt.Errorf("push failed"),
+		wantErr:    "push failed",
+		wantBundle: true,
+	}, {
+		desc:       "create context cancelled",
+		pErr:       context.Canceled,
+		cancel:     true,
+		wantErr:    "context canceled",
+		wantBundle: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			new = func(string, *tpb.Topology, ...Option) (TopologyManager, error) {
+				return &collectTopology{pErr: tt.pErr}, nil
+			}
+			dir := t.TempDir()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			err := CreateTopology(ctx, TopologyParams{BundleDir: dir})
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("CreateTopology() unexpected error: %s", s)
+			}
+			b, err := os.ReadFile(filepath.Join(dir, "t1-20220601-102030.tar.gz"))
+			if gotBundle := err == nil; gotBundle != tt.wantBundle {
+				t.Fatalf("CreateTopology() wrote bundle: %v, want %v", gotBundle, tt.wantBundle)
+			}
+			if tt.wantBundle && string(b) != "bundle" {
+				t.Fatalf("CreateTopology() unexpected bundle: %q", b)
+			}
+		})
+	}
+}
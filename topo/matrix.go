@@ -0,0 +1,234 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/profile"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// Matrix is a set of versions to run a topology with. Each key is a node name
+// or a vendor, such as ARISTA, and is mapped to the versions of the node or of
+// all nodes of the vendor. Node keys take precedence over vendor keys.
+type Matrix struct {
+	Versions map[string][]string `json:"versions"`
+}
+
+// LoadMatrix loads a Matrix from the YAML file fName.
+func LoadMatrix(fName string) (*Matrix, error) {
+	b, err := os.ReadFile(fName)
+	if err != nil {
+		return nil, err
+	}
+	m := &Matrix{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid matrix %s: %w", fName, err)
+	}
+	return m, nil
+}
+
+// Variant is a combination of the versions of a matrix.
+type Variant struct {
+	// Instance is the instance name of the topology of the variant.
+	Instance string `json:"instance"`
+	// Versions maps the keys of the matrix to the version of the variant.
+	Versions map[string]string `json:"versions"`
+}
+
+var invalidInstanceChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Variants returns all combinations of the versions of the matrix. The
+// instance name of a variant is made of its versions, in the order of the
+// sorted matrix keys.
+func (m *Matrix) Variants() ([]*Variant, error) {
+	var keys []string
+	for k, vs := range m.Versions {
+		if len(vs) == 0 {
+			return nil, fmt.Errorf("matrix key %q has no versions", k)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("matrix has no versions")
+	}
+	sort.Strings(keys)
+	vs := []*Variant{{Versions: map[string]string{}}}
+	for _, k := range keys {
+		var next []*Variant
+		for _, v := range vs {
+			for _, version := range m.Versions[k] {
+				nv := &Variant{Versions: map[string]string{k: version}}
+				for vk, vv := range v.Versions {
+					nv.Versions[vk] = vv
+				}
+				next = append(next, nv)
+			}
+		}
+		vs = next
+	}
+	seen := map[string]bool{}
+	for _, v := range vs {
+		var parts []string
+		for _, k := range keys {
+			parts = append(parts, strings.Trim(invalidInstanceChars.ReplaceAllString(strings.ToLower(v.Versions[k]), "-"), "-"))
+		}
+		v.Instance = strings.Join(parts, "-")
+		if seen[v.Instance] {
+			return nil, fmt.Errorf("versions of matrix have the same instance name %q", v.Instance)
+		}
+		seen[v.Instance] = true
+	}
+	return vs, nil
+}
+
+// Topology returns a copy of pb with the versions of the variant set. An error
+// is returned if a key of the variant matches no node or vendor of pb, if a
+// node it matches pins its image or if the version of a node does not resolve
+// to an image catalogued in the profiles p.
+func (v *Variant) Topology(pb *tpb.Topology, p *profile.Profiles) (*tpb.Topology, error) {
+	pb = proto.Clone(pb).(*tpb.Topology)
+	used := map[string]bool{}
+	for _, n := range pb.GetNodes() {
+		for _, k := range []string{n.GetName(), n.GetVendor().String()} {
+			version, ok := v.Versions[k]
+			if !ok {
+				continue
+			}
+			if img := n.GetConfig().GetImage(); img != "" {
+				return nil, fmt.Errorf("matrix key %q sets the version of node %s which pins image %q", k, n.GetName(), img)
+			}
+			n.Version = version
+			if err := versionImage(n, p); err != nil {
+				return nil, fmt.Errorf("matrix key %q: %w", k, err)
+			}
+			used[k] = true
+			break
+		}
+	}
+	for k := range v.Versions {
+		if !used[k] {
+			return nil, fmt.Errorf("matrix key %q matches no node or vendor of topology %q", k, pb.GetName())
+		}
+	}
+	return pb, nil
+}
+
+// versionImage returns an error if the version of n is not catalogued in the
+// profiles p or does not resolve to an image, as the version would otherwise
+// not change the image the node runs.
+func versionImage(n *tpb.Node, p *profile.Profiles) error {
+	n = proto.Clone(n).(*tpb.Node)
+	if err := p.Apply(n); err != nil {
+		return err
+	}
+	known := false
+	for _, v := range p.Versions(n.GetVendor(), n.GetModel()) {
+		known = known || v == n.GetVersion()
+	}
+	if !known || n.GetConfig().GetImage() == "" {
+		return fmt.Errorf("node %s: version %q of vendor %v model %q has no catalogued image", n.GetName(), n.GetVersion(), n.GetVendor(), n.GetModel())
+	}
+	return nil
+}
+
+// VariantResult is the result of creating or deleting the topology of a
+// variant.
+type VariantResult struct {
+	*Variant
+	Namespace string `json:"namespace"`
+	Err       error  `json:"-"`
+}
+
+// CreateMatrix creates one instance of the topology for each variant of the
+// matrix. The variants are created in order and a failed variant does not stop
+// the creation of the others. The results of all variants are returned.
+func CreateMatrix(ctx context.Context, params TopologyParams, m *Matrix) ([]*VariantResult, error) {
+	return forEachVariant(params, m, func(pb *tpb.Topology, opts []Option) error {
+		return createTopology(ctx, params, pb, opts...)
+	})
+}
+
+// DeleteMatrix deletes the instances of the topology created by CreateMatrix.
+func DeleteMatrix(ctx context.Context, params TopologyParams, m *Matrix) ([]*VariantResult, error) {
+	return forEachVariant(params, m, func(pb *tpb.Topology, opts []Option) error {
+		return deleteTopology(ctx, params, pb, opts...)
+	})
+}
+
+// forEachVariant calls f with the topology and options of each variant of m.
+// An instance set by the topology options prefixes the instance name of each
+// variant.
+func forEachVariant(params TopologyParams, m *Matrix, f func(*tpb.Topology, []Option) error) ([]*VariantResult, error) {
+	topopb, err := Load(params.TopoName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %+v", params.TopoName, err)
+	}
+	vs, err := m.Variants()
+	if err != nil {
+		return nil, err
+	}
+	base := optionsManager(params.TopoNewOptions)
+	profiles, err := profile.Load(base.profileFiles...)
+	if err != nil {
+		return nil, err
+	}
+	var rs []*VariantResult
+	var pbs []*tpb.Topology
+	var opts [][]Option
+	for _, v := range vs {
+		pb, err := v.Topology(topopb, profiles)
+		if err != nil {
+			return nil, err
+		}
+		if base.instance != "" {
+			v.Instance = base.instance + "-" + v.Instance
+		}
+		o := append(append([]Option{}, params.TopoNewOptions...), WithInstance(v.Instance))
+		ns, err := Namespace(pb, o...)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, &VariantResult{Variant: v, Namespace: ns})
+		pbs = append(pbs, pb)
+		opts = append(opts, o)
+	}
+	for i, r := range rs {
+		log.Infof("Topology %q instance %q: versions %v", topopb.GetName(), r.Instance, r.Versions)
+		if r.Err = f(pbs[i], opts[i]); r.Err != nil {
+			log.Errorf("Topology %q instance %q failed: %v", topopb.GetName(), r.Instance, r.Err)
+		}
+	}
+	return rs, nil
+}
+
+// optionsManager returns a manager with only the topology options opts
+// applied, to read the profiles and instance they set.
+func optionsManager(opts []Option) *Manager {
+	m := &Manager{}
+	for _, o := range opts {
+		o(m)
+	}
+	return m
+}
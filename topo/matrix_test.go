@@ -0,0 +1,224 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/profile"
+	"k8s.io/client-go/rest"
+)
+
+func TestVariants(t *testing.T) {
+	tests := []struct {
+		desc    string
+		matrix  *Matrix
+		want    []*Variant
+		wantErr string
+	}{{
+		desc: "product",
+		matrix: &Matrix{Versions: map[string][]string{
+			"ARISTA": {"4.28.0F", "4.30.1F"},
+			"r3":     {"8.4.1"},
+		}},
+		want: []*Variant{
+			{Instance: "4-28-0f-8-4-1", Versions: map[string]string{"ARISTA": "4.28.0F", "r3": "8.4.1"}},
+			{Instance: "4-30-1f-8-4-1", Versions: map[string]string{"ARISTA": "4.30.1F", "r3": "8.4.1"}},
+		},
+	}, {
+		desc:    "empty",
+		matrix:  &Matrix{},
+		wantErr: "no versions",
+	}, {
+		desc:    "key without versions",
+		matrix:  &Matrix{Versions: map[string][]string{"ARISTA": nil}},
+		wantErr: `matrix key "ARISTA" has no versions`,
+	}, {
+		desc:    "same instance",
+		matrix:  &Matrix{Versions: map[string][]string{"ARISTA": {"4.28", "4-28"}}},
+		wantErr: "same instance name",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.matrix.Variants()
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Variants() unexpected error: %s", s)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Variants() unexpected variants (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestVariantTopology(t *testing.T) {
+	pb := &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{
+			{Name: "r1", Vendor: tpb.Vendor_ARISTA},
+			{Name: "r2", Vendor: tpb.Vendor_ARISTA},
+			{Name: "r3", Vendor: tpb.Vendor_CISCO},
+			{Name: "r4", Vendor: tpb.Vendor_FRR},
+			{Name: "r6", Vendor: tpb.Vendor_HOST},
+			{Name: "r5", Vendor: tpb.Vendor_NOKIA, Config: &tpb.Config{Image: "srlinux:22.11.1"}},
+		},
+	}
+	tests := []struct {
+		desc       string
+		versions   map[string]string
+		wantImages map[string]string
+		wantErr    string
+	}{{
+		desc:     "vendor and node versions",
+		versions: map[string]string{"ARISTA": "4.28.0F", "r2": "4.30.1F", "r3": "7.9.1", "FRR": "8.3.1"},
+		wantImages: map[string]string{
+			"r1": "ceos:4.28.0F",
+			"r2": "ceos:4.30.1F",
+			"r3": "ios-xr/xrd-control-plane:7.9.1",
+			"r4": "quay.io/frrouting/frr:8.3.1",
+			"r5": "srlinux:22.11.1",
+			"r6": "alpine:latest",
+		},
+	}, {
+		desc:     "unmatched key",
+		versions: map[string]string{"JUNIPER": "22.2R1"},
+		wantErr:  `matrix key "JUNIPER" matches no node or vendor`,
+	}, {
+		desc:     "uncatalogued version",
+		versions: map[string]string{"r1": "4.31.0F"},
+		wantErr:  `unknown version "4.31.0F"`,
+	}, {
+		desc:     "vendor without catalogue",
+		versions: map[string]string{"HOST": "3.17"},
+		wantErr:  `matrix key "HOST": node r6`,
+	}, {
+		desc:     "pinned image",
+		versions: map[string]string{"NOKIA": "23.3.1"},
+		wantErr:  `node r5 which pins image "srlinux:22.11.1"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := (&Variant{Versions: tt.versions}).Topology(pb, profile.Embedded())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Topology() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			p := profile.Embedded()
+			images := map[string]string{}
+			for _, n := range got.GetNodes() {
+				if err := p.Apply(n); err != nil {
+					t.Fatalf("Apply(%s) failed: %v", n.GetName(), err)
+				}
+				images[n.GetName()] = n.GetConfig().GetImage()
+			}
+			if d := cmp.Diff(tt.wantImages, images); d != "" {
+				t.Errorf("Topology() unexpected images (-want +got):\n%s", d)
+			}
+			for _, n := range pb.GetNodes() {
+				if n.GetVersion() != "" {
+					t.Errorf("Topology() modified the version of node %s of the topology", n.GetName())
+				}
+			}
+		})
+	}
+}
+
+// matrixTopology records the instances created by CreateMatrix.
+type matrixTopology struct {
+	defaultFakeTopology
+	m    *Manager
+	pErr error
+}
+
+func (f *matrixTopology) TopologyProto() *tpb.Topology {
+	return f.m.proto
+}
+
+func (f *matrixTopology) Namespace() string {
+	return f.m.namespace
+}
+
+func (f *matrixTopology) Push(context.Context) error {
+	return f.pErr
+}
+
+func (f *matrixTopology) Resources(context.Context) (*Resources, error) {
+	return &Resources{}, nil
+}
+
+func TestCreateMatrix(t *testing.T) {
+	origNew := new
+	defer func() {
+		new = origNew
+	}()
+	topoFile := filepath.Join(t.TempDir(), "topo.pb.txt")
+	if err := os.WriteFile(topoFile, []byte(`name: "t1" nodes: { name: "r1" vendor: ARISTA }`), 0644); err != nil {
+		t.Fatalf("failed to write topology: %v", err)
+	}
+	created := map[string]string{}
+	new = func(kubecfg string, pb *tpb.Topology, opts ...Option) (TopologyManager, error) {
+		m, err := New(kubecfg, pb, append(opts, WithClusterConfig(&rest.Config{}))...)
+		if err != nil {
+			return nil, err
+		}
+		f := &matrixTopology{m: m.(*Manager)}
+		created[f.Namespace()] = pb.GetNodes()[0].GetVersion()
+		if pb.GetNodes()[0].GetVersion() == "4.30.1F" {
+			f.pErr = fmt.Errorf("push failed")
+		}
+		return f, nil
+	}
+	m := &Matrix{Versions: map[string][]string{"ARISTA": {"4.28.0F", "4.30.1F"}}}
+	rs, err := CreateMatrix(context.Background(), TopologyParams{TopoName: topoFile, BundleDir: t.TempDir()}, m)
+	if err != nil {
+		t.Fatalf("CreateMatrix() failed: %v", err)
+	}
+	want := map[string]string{"t1-4-28-0f": "4.28.0F", "t1-4-30-1f": "4.30.1F"}
+	if d := cmp.Diff(want, created); d != "" {
+		t.Errorf("CreateMatrix() unexpected instances (-want +got):\n%s", d)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("CreateMatrix() got %d results, want 2", len(rs))
+	}
+	if rs[0].Namespace != "t1-4-28-0f" || rs[0].Err != nil {
+		t.Errorf("CreateMatrix() got result %s: %v, want t1-4-28-0f: <nil>", rs[0].Namespace, rs[0].Err)
+	}
+	if s := errdiff.Substring(rs[1].Err, "push failed"); s != "" {
+		t.Errorf("CreateMatrix() unexpected error of %s: %s", rs[1].Namespace, s)
+	}
+	created = map[string]string{}
+	rs, err = CreateMatrix(context.Background(), TopologyParams{TopoName: topoFile, BundleDir: t.TempDir(), TopoNewOptions: []Option{WithInstance("alice")}}, m)
+	if err != nil {
+		t.Fatalf("CreateMatrix() with instance failed: %v", err)
+	}
+	want = map[string]string{"t1-alice-4-28-0f": "4.28.0F", "t1-alice-4-30-1f": "4.30.1F"}
+	if d := cmp.Diff(want, created); d != "" {
+		t.Errorf("CreateMatrix() with instance unexpected instances (-want +got):\n%s", d)
+	}
+	if rs[0].Instance != "alice-4-28-0f" {
+		t.Errorf("CreateMatrix() with instance got instance %q, want %q", rs[0].Instance, "alice-4-28-0f")
+	}
+	if _, err := CreateMatrix(context.Background(), TopologyParams{TopoName: topoFile}, &Matrix{Versions: map[string][]string{"r9": {"1"}}}); err == nil {
+		t.Errorf("CreateMatrix() with unmatched key succeeded, want error")
+	}
+}
@@ -15,14 +15,18 @@ package ceos
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 	scraplibase "github.com/scrapli/scrapligo/driver/base"
 	scraplicore "github.com/scrapli/scrapligo/driver/core"
 	scraplinetwork "github.com/scrapli/scrapligo/driver/network"
@@ -43,11 +47,34 @@ type Node struct {
 
 // Add validations for interfaces the node provides
 var (
-	_ node.Certer       = (*Node)(nil)
-	_ node.ConfigPusher = (*Node)(nil)
-	_ node.Resetter     = (*Node)(nil)
+	_ node.CLIer              = (*Node)(nil)
+	_ node.Certer             = (*Node)(nil)
+	_ node.CertInstaller      = (*Node)(nil)
+	_ node.Collector          = (*Node)(nil)
+	_ node.ConfigGetter       = (*Node)(nil)
+	_ node.ConfigPusher       = (*Node)(nil)
+	_ node.Resetter           = (*Node)(nil)
+	_ node.LLDPNeighborGetter = (*Node)(nil)
+	_ node.InterfaceMapper    = (*Node)(nil)
 )
 
+// CLICommand returns the command to start the vendor CLI.
+func (n *Node) CLICommand() []string {
+	return []string{"Cli"}
+}
+
+// diagnosticCommands are the commands collected by Collect keyed by file name.
+var diagnosticCommands = map[string][]string{
+	"show-tech-support.txt":   {"Cli", "-p", "15", "-c", "show tech-support"},
+	"show-running-config.txt": {"Cli", "-p", "15", "-c", "show running-config"},
+	"show-logging.txt":        {"Cli", "-p", "15", "-c", "show logging"},
+}
+
+// Collect returns the output of the cEOS diagnostic commands.
+func (n *Node) Collect(ctx context.Context) (map[string][]byte, error) {
+	return node.CollectCommands(ctx, n, diagnosticCommands), nil
+}
+
 func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl == nil {
 		return nil, fmt.Errorf("nodeImpl cannot be nil")
@@ -185,6 +212,42 @@ func (n *Node) GenerateSelfSigned(ctx context.Context) error {
 	return resp.Failed
 }
 
+const (
+	// certDir and keyDir are the directories EOS loads certificate:<name>
+	// and sslkey:<name> from.
+	certDir = "/persist/secure/ssl/certs"
+	keyDir  = "/persist/secure/ssl/keys"
+	// caCertName is the name of the certificate of the issuing CA.
+	caCertName = "kne-ca.crt"
+)
+
+// writeFile is swapped in tests as the exec transport cannot be faked.
+var writeFile = node.WriteFile
+
+// InstallCert installs the cert, key and issuing CA of c in the EOS
+// certificate and key stores, where they can be referenced by SSL profiles.
+func (n *Node) InstallCert(ctx context.Context, c *node.Certificate) error {
+	log.Infof("%s - installing cert %s", n.Name(), c.Name)
+	files := []struct {
+		path string
+		b    []byte
+	}{
+		{path.Join(keyDir, c.KeyName), c.Key},
+		{path.Join(certDir, c.Name), c.Cert},
+		{path.Join(certDir, caCertName), c.CA},
+	}
+	for _, f := range files {
+		if len(f.b) == 0 {
+			continue
+		}
+		if err := writeFile(ctx, n, f.path, f.b); err != nil {
+			return err
+		}
+	}
+	log.Infof("%s - finished installing cert", n.Name())
+	return nil
+}
+
 func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
 	log.Infof("%s - pushing config", n.Name())
 
@@ -216,6 +279,86 @@ func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
 	return resp.Failed
 }
 
+// ConfigGet returns the running config of the node, in the startup-config
+// format accepted by ConfigPush.
+func (n *Node) ConfigGet(ctx context.Context) ([]byte, error) {
+	log.Infof("%s - getting config", n.Name())
+
+	err := n.SpawnCLIConn()
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.cliConn.Close()
+
+	resp, err := n.cliConn.SendCommand("show running-config")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, resp.Failed
+	}
+
+	log.Infof("%s - finished getting config", n.Name())
+
+	return []byte(strings.TrimSpace(resp.Result) + "\n"), nil
+}
+
+// LLDPNeighbors returns the LLDP neighbors of the node from the JSON output of
+// show lldp neighbors.
+func (n *Node) LLDPNeighbors(ctx context.Context) ([]*node.LLDPNeighbor, error) {
+	log.Infof("%s - getting LLDP neighbors", n.Name())
+
+	err := n.SpawnCLIConn()
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.cliConn.Close()
+
+	resp, err := n.cliConn.SendCommand("show lldp neighbors | json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, resp.Failed
+	}
+	var out struct {
+		LLDPNeighbors []struct {
+			Port           string `json:"port"`
+			NeighborDevice string `json:"neighborDevice"`
+			NeighborPort   string `json:"neighborPort"`
+		} `json:"lldpNeighbors"`
+	}
+	if err := json.Unmarshal([]byte(resp.Result), &out); err != nil {
+		return nil, fmt.Errorf("%s - invalid LLDP neighbors: %w", n.Name(), err)
+	}
+	var nbs []*node.LLDPNeighbor
+	for _, nb := range out.LLDPNeighbors {
+		nbs = append(nbs, &node.LLDPNeighbor{
+			Interface:  nb.Port,
+			SystemName: nb.NeighborDevice,
+			PortID:     nb.NeighborPort,
+		})
+	}
+	return nbs, nil
+}
+
+// InterfaceKey returns the interface key of the EOS interface name intf,
+// EthernetN is ethN and the breakout EthernetN/M is ethN_M.
+func (n *Node) InterfaceKey(intf string) (string, bool) {
+	id := strings.TrimPrefix(intf, "Ethernet")
+	if id == intf || id == "" {
+		return "", false
+	}
+	for _, p := range strings.Split(id, "/") {
+		if _, err := strconv.Atoi(p); err != nil {
+			return "", false
+		}
+	}
+	return "eth" + strings.ReplaceAll(id, "/", "_"), true
+}
+
 func (n *Node) ResetCfg(ctx context.Context) error {
 	log.Infof("%s resetting config", n.Name())
 
@@ -242,34 +385,15 @@ func (n *Node) ResetCfg(ctx context.Context) error {
 	return resp.Failed
 }
 
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the ARISTA vendor profile.
 func defaults(pb *tpb.Node) *tpb.Node {
 	if pb == nil {
 		pb = &tpb.Node{
 			Name: "default_ceos_node",
 		}
 	}
-	if pb.Constraints == nil {
-		pb.Constraints = map[string]string{
-			"cpu":    "0.5",
-			"memory": "1Gi",
-		}
-	}
-	if pb.Services == nil {
-		pb.Services = map[uint32]*tpb.Service{
-			443: {
-				Name:   "ssl",
-				Inside: 443,
-			},
-			22: {
-				Name:   "ssh",
-				Inside: 22,
-			},
-			6030: {
-				Name:   "gnmi",
-				Inside: 6030,
-			},
-		}
-	}
+	profile.Embedded().Fill(tpb.Vendor_ARISTA, pb)
 	if pb.Labels == nil {
 		pb.Labels = map[string]string{
 			"type":    tpb.Node_ARISTA_CEOS.String(),
@@ -295,61 +419,9 @@ func defaults(pb *tpb.Node) *tpb.Node {
 			pb.Labels["version"] = pb.Version
 		}
 	}
-	if pb.Config == nil {
-		pb.Config = &tpb.Config{}
-	}
-	if len(pb.Config.GetCommand()) == 0 {
-		pb.Config.Command = []string{
-			"/sbin/init",
-			"systemd.setenv=INTFTYPE=eth",
-			"systemd.setenv=ETBA=1",
-			"systemd.setenv=SKIP_ZEROTOUCH_BARRIER_IN_SYSDBINIT=1",
-			"systemd.setenv=CEOS=1",
-			"systemd.setenv=EOS_PLATFORM=ceoslab",
-			"systemd.setenv=container=docker",
-		}
-	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "ceos:latest"
-	}
-	if pb.Config.Env == nil {
-		pb.Config.Env = map[string]string{
-			"CEOS":                                "1",
-			"EOS_PLATFORM":                        "ceoslab",
-			"container":                           "docker",
-			"ETBA":                                "1",
-			"SKIP_ZEROTOUCH_BARRIER_IN_SYSDBINIT": "1",
-			"INTFTYPE":                            "eth",
-		}
-	} else {
-		if pb.Config.Env["CEOS"] == "" {
-			pb.Config.Env["CEOS"] = "1"
-		}
-		if pb.Config.Env["EOS_PLATFORM"] == "" {
-			pb.Config.Env["EOS_PLATFORM"] = "ceoslab"
-		}
-		if pb.Config.Env["container"] == "" {
-			pb.Config.Env["container"] = "docker"
-		}
-		if pb.Config.Env["ETBA"] == "" {
-			pb.Config.Env["ETBA"] = "1"
-		}
-		if pb.Config.Env["SKIP_ZEROTOUCH_BARRIER_IN_SYSDBINIT"] == "" {
-			pb.Config.Env["SKIP_ZEROTOUCH_BARRIER_IN_SYSDBINIT"] = "1"
-		}
-		if pb.Config.Env["INTFTYPE"] == "" {
-			pb.Config.Env["INTFTYPE"] = "eth"
-		}
-	}
 	if pb.Config.EntryCommand == "" {
 		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- Cli", pb.Name)
 	}
-	if pb.Config.ConfigPath == "" {
-		pb.Config.ConfigPath = "/mnt/flash"
-	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "startup-config"
-	}
 	return pb
 }
 
@@ -16,9 +16,11 @@ package ceos
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/h-fam/errdiff"
 	topopb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
@@ -379,3 +381,230 @@ func TestResetCfg(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigGet(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &topopb.Node{
+			Name:   "pod1",
+			Type:   2,
+			Config: &topopb.Config{},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		want     []string
+		testFile string
+	}{{
+		desc:     "success",
+		want:     []string{"hostname spine1\n", "interface Ethernet1\n", "end\n"},
+		testFile: "get_config_success",
+	}, {
+		// device returns "% Invalid input" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "% Invalid input",
+		testFile: "get_config_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne arista node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewEOSDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			got, err := n.ConfigGet(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ConfigGet() unexpected error: %s", s)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(got), w) {
+					t.Errorf("ConfigGet() missing %q, got:\n%s", w, got)
+				}
+			}
+			if strings.Contains(string(got), "spine1#") {
+				t.Errorf("ConfigGet() contains prompt, got:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestLLDPNeighbors(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &topopb.Node{
+			Name:   "pod1",
+			Type:   2,
+			Config: &topopb.Config{},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		want     []*node.LLDPNeighbor
+		testFile string
+	}{{
+		desc: "success",
+		want: []*node.LLDPNeighbor{
+			{Interface: "Ethernet1", SystemName: "leaf1", PortID: "Ethernet1"},
+			{Interface: "Ethernet2", SystemName: "leaf2", PortID: "ethernet-1/1"},
+		},
+		testFile: "lldp_neighbors_success",
+	}, {
+		// device returns "% Invalid input" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "% Invalid input",
+		testFile: "lldp_neighbors_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne arista node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewEOSDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			got, err := n.LLDPNeighbors(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("LLDPNeighbors() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("LLDPNeighbors() unexpected neighbors (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestInterfaceKey(t *testing.T) {
+	n := &Node{Impl: &node.Impl{Proto: &topopb.Node{Name: "pod1"}}}
+	tests := []struct {
+		intf   string
+		want   string
+		wantOK bool
+	}{
+		{intf: "Ethernet1", want: "eth1", wantOK: true},
+		{intf: "Ethernet12/3", want: "eth12_3", wantOK: true},
+		{intf: "Management0"},
+		{intf: "Ethernet"},
+		{intf: "Ethernet1/"},
+		{intf: "eth1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.intf, func(t *testing.T) {
+			got, ok := n.InterfaceKey(tt.intf)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("InterfaceKey(%q) got %q, %v, want %q, %v", tt.intf, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestInstallCert(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &topopb.Node{
+			Name: "pod1",
+			Type: 2,
+		},
+	}
+	tests := []struct {
+		desc    string
+		cert    *node.Certificate
+		want    map[string]string
+		wantErr string
+	}{{
+		desc: "success",
+		cert: &node.Certificate{
+			Name:    "gnmiCert.pem",
+			KeyName: "gnmiKey.pem",
+			Cert:    []byte("cert"),
+			Key:     []byte("key"),
+			CA:      []byte("ca"),
+		},
+		want: map[string]string{
+			"/persist/secure/ssl/keys/gnmiKey.pem":   "key",
+			"/persist/secure/ssl/certs/gnmiCert.pem": "cert",
+			"/persist/secure/ssl/certs/kne-ca.crt":   "ca",
+		},
+	}, {
+		desc: "no ca",
+		cert: &node.Certificate{
+			Name:    "gnmiCert.pem",
+			KeyName: "gnmiKey.pem",
+			Cert:    []byte("cert"),
+			Key:     []byte("key"),
+		},
+		want: map[string]string{
+			"/persist/secure/ssl/keys/gnmiKey.pem":   "key",
+			"/persist/secure/ssl/certs/gnmiCert.pem": "cert",
+		},
+	}, {
+		desc: "write failure",
+		cert: &node.Certificate{
+			Name:    "gnmiCert.pem",
+			KeyName: "gnmiKey.pem",
+			Cert:    []byte("cert"),
+			Key:     []byte("fail"),
+		},
+		want:    map[string]string{},
+		wantErr: "read-only file system",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne arista node")
+			}
+			n, _ := nImpl.(*Node)
+
+			got := map[string]string{}
+			oldWriteFile := writeFile
+			defer func() { writeFile = oldWriteFile }()
+			writeFile = func(_ context.Context, _ node.Execer, p string, b []byte) error {
+				if string(b) == "fail" {
+					return fmt.Errorf("failed to write %s: read-only file system", p)
+				}
+				got[p] = string(b)
+				return nil
+			}
+
+			err = n.InstallCert(context.Background(), tt.cert)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("InstallCert() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.want, got); s != "" {
+				t.Errorf("InstallCert() unexpected files (-want +got):\n%s", s)
+			}
+		})
+	}
+}
@@ -15,14 +15,26 @@ package cisco
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
+	"github.com/scrapli/scrapligo/channel"
+	scraplibase "github.com/scrapli/scrapligo/driver/base"
+	scraplicore "github.com/scrapli/scrapligo/driver/core"
+	scraplinetwork "github.com/scrapli/scrapligo/driver/network"
+	scraplitransport "github.com/scrapli/scrapligo/transport"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
@@ -34,6 +46,16 @@ const (
 	ModelXRD = "xrd"
 )
 
+// ErrIncompatibleCliConn raised when an invalid scrapligo cli transport type is found.
+var ErrIncompatibleCliConn = errors.New("incompatible cli connection in use")
+
+var (
+	// Approx timeout while we wait for cli to get ready
+	waitForCLITimeout = 500 * time.Second
+	// Interval between attempts to open the cli
+	cliRetryInterval = 2 * time.Second
+)
+
 func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl == nil {
 		return nil, fmt.Errorf("nodeImpl cannot be nil")
@@ -54,6 +76,350 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 
 type Node struct {
 	*node.Impl
+	cliConn *scraplinetwork.Driver
+}
+
+// Add validations for interfaces the node provides
+var (
+	_ node.Certer       = (*Node)(nil)
+	_ node.Collector    = (*Node)(nil)
+	_ node.ConfigGetter = (*Node)(nil)
+	_ node.ConfigPusher = (*Node)(nil)
+	_ node.Resetter     = (*Node)(nil)
+)
+
+// diagnosticCommands are the commands collected by Collect keyed by file name.
+var diagnosticCommands = map[string][]string{
+	"show-version.txt":        {"/pkg/bin/xr_cli", "show version"},
+	"show-running-config.txt": {"/pkg/bin/xr_cli", "show running-config"},
+	"show-logging.txt":        {"/pkg/bin/xr_cli", "show logging"},
+}
+
+// Collect returns the output of the IOS XR diagnostic commands. If the model
+// of the node has no CLI then status.Unimplemented will be returned.
+func (n *Node) Collect(ctx context.Context) (map[string][]byte, error) {
+	if err := n.cliSupported(); err != nil {
+		return nil, err
+	}
+	return node.CollectCommands(ctx, n, diagnosticCommands), nil
+}
+
+// WaitCLIReady attempts to open the transport channel towards a Network OS and perform scrapligo OnOpen actions
+// for a given platform. Retries till success or until ctx is done.
+func (n *Node) WaitCLIReady(ctx context.Context) error {
+	for {
+		err := n.cliConn.Open()
+		if err == nil {
+			log.Debugf("%s - Cli ready.", n.Name())
+			return nil
+		}
+		log.Debugf("%s - Cli not ready - waiting.", n.Name())
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled for target %q with cli not ready: %w", n.Name(), err)
+		case <-time.After(cliRetryInterval):
+		}
+	}
+}
+
+// cliSupported returns status.Unimplemented if the node has no CLI reachable
+// with kubectl exec, which only XRd nodes provide.
+func (n *Node) cliSupported() error {
+	if n.Proto.GetModel() != ModelXRD {
+		return status.Errorf(codes.Unimplemented, "%s - CLI of model %q not supported", n.Name(), n.Proto.GetModel())
+	}
+	return nil
+}
+
+// PatchCLIConnOpen sets the OpenCmd and ExecCmd of system transport to work with `kubectl exec` terminal.
+func (n *Node) PatchCLIConnOpen() error {
+	t, ok := n.cliConn.Transport.Impl.(scraplitransport.SystemTransport)
+	if !ok {
+		return ErrIncompatibleCliConn
+	}
+
+	t.SetExecCmd("kubectl")
+	var args []string
+	if n.Kubecfg != "" {
+		args = append(args, fmt.Sprintf("--kubeconfig=%s", n.Kubecfg))
+	}
+	args = append(args, "exec", "-it", "-n", n.Namespace, n.Name(), "--", "/pkg/bin/xr_cli.sh")
+	t.SetOpenCmd(args)
+	return nil
+}
+
+// SpawnCLIConn spawns a CLI connection towards a Network OS using `kubectl exec` terminal and ensures CLI is ready
+// to accept inputs. If the model of the node has no CLI then status.Unimplemented
+// will be returned.
+func (n *Node) SpawnCLIConn(ctx context.Context) error {
+	if err := n.cliSupported(); err != nil {
+		return err
+	}
+	d, err := scraplicore.NewCoreDriver(
+		n.Name(),
+		"cisco_iosxr",
+		scraplibase.WithAuthBypass(true),
+		// disable transport timeout
+		scraplibase.WithTimeoutTransport(0),
+	)
+	if err != nil {
+		return err
+	}
+
+	n.cliConn = d
+
+	err = n.PatchCLIConnOpen()
+	if err != nil {
+		n.cliConn = nil
+
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, waitForCLITimeout)
+	defer cancel()
+	return n.WaitCLIReady(ctx)
+}
+
+// timestampRE matches the timestamp printed by IOS XR before command output.
+var timestampRE = regexp.MustCompile(`^\w{3} \w{3} +\d+ \d+:\d+:\d+(\.\d+)? \w+$`)
+
+// ConfigGet returns the running config of the node in the format of the
+// startup config applied at boot.
+func (n *Node) ConfigGet(ctx context.Context) ([]byte, error) {
+	log.Infof("%s - getting config", n.Name())
+
+	err := n.SpawnCLIConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.cliConn.Close()
+
+	resp, err := n.cliConn.SendCommand("show running-config")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, resp.Failed
+	}
+
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(resp.Result), "\n") {
+		l = strings.TrimRight(l, "\r")
+		if l == "Building configuration..." || timestampRE.MatchString(l) {
+			continue
+		}
+		lines = append(lines, l)
+	}
+
+	log.Infof("%s - finished getting config", n.Name())
+
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// ConfigPush replaces the running config of the node with the config.
+func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
+	log.Infof("%s - pushing config", n.Name())
+
+	cfg, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(cfg))
+
+	var cmds []string
+	for _, l := range strings.Split(string(cfg), "\n") {
+		l = strings.TrimRight(l, "\r")
+		// end would leave the config mode before the commit.
+		if strings.TrimSpace(l) == "" || l == "end" {
+			continue
+		}
+		cmds = append(cmds, l)
+	}
+
+	if err := n.commitReplace(ctx, cmds); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished config push", n.Name())
+
+	return nil
+}
+
+// ResetCfg replaces the running config of the node with its startup config,
+// or with an empty config if the node has none.
+func (n *Node) ResetCfg(ctx context.Context) error {
+	log.Infof("%s - resetting config", n.Name())
+
+	var cmds []string
+	if n.Proto.GetConfig().GetConfigData() != nil {
+		cmds = append(cmds, fmt.Sprintf("load %s", filepath.Join(n.Proto.Config.ConfigPath, n.Proto.Config.ConfigFile)))
+	}
+
+	if err := n.commitReplace(ctx, cmds); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished resetting config", n.Name())
+
+	return nil
+}
+
+// commitReplace builds a candidate config with the config commands and
+// replaces the running config with it.
+func (n *Node) commitReplace(ctx context.Context, cmds []string) error {
+	err := n.SpawnCLIConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer n.cliConn.Close()
+
+	multiResp, err := n.cliConn.SendConfigs(
+		cmds,
+		scraplibase.WithSendFailedWhenContains(failedWhenContains),
+		scraplibase.WithSendStopOnFailed(true),
+	)
+	if err != nil {
+		return err
+	}
+	if multiResp.Failed != nil {
+		return multiResp.Failed
+	}
+
+	resp, err := n.cliConn.SendInteractive(
+		[]*channel.SendInteractiveEvent{
+			{
+				ChannelInput:    "commit replace",
+				ChannelResponse: "Do you wish to proceed",
+			},
+			{
+				ChannelInput: "yes",
+			},
+		},
+		scraplibase.WithDesiredPrivilegeLevel("configuration"),
+		scraplibase.WithSendFailedWhenContains(failedWhenContains),
+	)
+	if err != nil {
+		return err
+	}
+	if resp.Failed != nil {
+		// Leaving the config mode with uncommitted changes prompts for
+		// them to be committed, so the candidate is aborted instead.
+		if _, err := scraplicore.IOSXRAbortConfig(n.cliConn); err != nil {
+			log.Warnf("%s - failed to abort config: %v", n.Name(), err)
+		}
+		return resp.Failed
+	}
+
+	return nil
+}
+
+// failedWhenContains are the IOS XR errors of the config and crypto commands,
+// which report commit and enrollment failures in addition to the CLI errors.
+var failedWhenContains = []string{
+	"% Ambiguous command",
+	"% Incomplete command",
+	"% Invalid input detected",
+	"% Unknown command",
+	"% Failed to commit",
+	"% Error",
+}
+
+// GenerateSelfSigned generates an RSA key pair with the key name and enrolls
+// a self-signed certificate for it in a trustpoint with the cert name.
+func (n *Node) GenerateSelfSigned(ctx context.Context) error {
+	selfSigned := n.Proto.GetConfig().GetCert().GetSelfSigned()
+	if selfSigned == nil {
+		log.Infof("%s - no cert config", n.Name())
+		return nil
+	}
+	log.Infof("%s - generating self signed certs", n.Name())
+
+	err := n.SpawnCLIConn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer n.cliConn.Close()
+
+	keySize := selfSigned.GetKeySize()
+	if keySize == 0 {
+		keySize = 2048
+	}
+	commonName := selfSigned.GetCommonName()
+	if commonName == "" {
+		commonName = n.Name()
+	}
+
+	resp, err := n.cliConn.SendInteractive(
+		[]*channel.SendInteractiveEvent{
+			{
+				ChannelInput:    fmt.Sprintf("crypto key generate rsa %s", selfSigned.GetKeyName()),
+				ChannelResponse: "How many bits in the modulus",
+			},
+			{
+				ChannelInput: strconv.Itoa(int(keySize)),
+			},
+		},
+		scraplibase.WithSendFailedWhenContains(failedWhenContains),
+	)
+	if err != nil {
+		return err
+	}
+	if resp.Failed != nil {
+		return resp.Failed
+	}
+
+	multiResp, err := n.cliConn.SendConfigs(
+		[]string{
+			fmt.Sprintf("crypto ca trustpoint %s", selfSigned.GetCertName()),
+			"enrollment url self",
+			fmt.Sprintf("subject-name CN=%s", commonName),
+			fmt.Sprintf("rsakeypair %s", selfSigned.GetKeyName()),
+			"commit",
+		},
+		scraplibase.WithSendFailedWhenContains(failedWhenContains),
+		scraplibase.WithSendStopOnFailed(true),
+	)
+	if err != nil {
+		return err
+	}
+	if multiResp.Failed != nil {
+		return multiResp.Failed
+	}
+
+	resp, err = n.cliConn.SendInteractive(
+		[]*channel.SendInteractiveEvent{
+			{
+				ChannelInput:    fmt.Sprintf("crypto ca enroll %s", selfSigned.GetCertName()),
+				ChannelResponse: "Include the router serial number",
+			},
+			{
+				ChannelInput:    "no",
+				ChannelResponse: "Include an IP address",
+			},
+			{
+				ChannelInput:    "no",
+				ChannelResponse: "Generate Self Signed Router Certificate",
+			},
+			{
+				ChannelInput: "yes",
+			},
+		},
+		scraplibase.WithSendFailedWhenContains(failedWhenContains),
+	)
+	if err != nil {
+		return err
+	}
+	if resp.Failed != nil {
+		return resp.Failed
+	}
+
+	log.Infof("%s - finished cert generation", n.Name())
+
+	return nil
 }
 
 func (n *Node) Create(ctx context.Context) error {
@@ -171,30 +537,6 @@ func (n *Node) Create(ctx context.Context) error {
 	return nil
 }
 
-func constraints(pb *tpb.Node) *tpb.Node {
-	if pb.Constraints == nil {
-		pb.Constraints = map[string]string{}
-	}
-	switch pb.Model {
-	//nolint:goconst
-	case "8201", "8201-32FH", "8202", "8101-32H", "8102-64H":
-		if pb.Constraints["cpu"] == "" {
-			pb.Constraints["cpu"] = "4"
-		}
-		if pb.Constraints["memory"] == "" {
-			pb.Constraints["memory"] = "12Gi"
-		}
-	default:
-		if pb.Constraints["cpu"] == "" {
-			pb.Constraints["cpu"] = "1"
-		}
-		if pb.Constraints["memory"] == "" {
-			pb.Constraints["memory"] = "2Gi"
-		}
-	}
-	return pb
-}
-
 func fmtInt100(eid int) string {
 	return fmt.Sprintf("HundredGigE0/0/0/%d", eid)
 }
@@ -317,49 +659,20 @@ func getCiscoInterfaceID(pb *tpb.Node, eth string) (string, error) {
 	}
 }
 
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the CISCO vendor profiles.
 func defaults(pb *tpb.Node) (*tpb.Node, error) {
 	if pb == nil {
 		pb = &tpb.Node{
 			Name: "default_cisco_node",
 		}
 	}
-	if pb.Config == nil {
-		pb.Config = &tpb.Config{}
-	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "startup.cfg"
-	}
-	if pb.Config.ConfigPath == "" {
-		pb.Config.ConfigPath = "/"
-	}
-	if pb.Model == "" {
-		pb.Model = ModelXRD
-	}
-	pb = constraints(pb)
-	if pb.Services == nil {
-		pb.Services = map[uint32]*tpb.Service{
-			443: {
-				Name:   "ssl",
-				Inside: 443,
-			},
-			22: {
-				Name:   "ssh",
-				Inside: 22,
-			},
-			6030: {
-				Name:   "gnmi",
-				Inside: 57400,
-			},
-		}
-	}
+	profile.Embedded().Fill(tpb.Vendor_CISCO, pb)
 	if pb.Labels == nil {
 		pb.Labels = map[string]string{
 			"vendor": tpb.Vendor_CISCO.String(),
 		}
 	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "ios-xr:latest"
-	}
 	if pb.Config.EntryCommand == "" {
 		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- bash", pb.Name)
 	}
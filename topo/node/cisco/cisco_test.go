@@ -15,11 +15,21 @@ package cisco
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/h-fam/errdiff"
 	"github.com/openconfig/kne/topo/node"
+	scraplibase "github.com/scrapli/scrapligo/driver/base"
+	scraplicore "github.com/scrapli/scrapligo/driver/core"
+	scraplinetwork "github.com/scrapli/scrapligo/driver/network"
+	scraplitest "github.com/scrapli/scrapligo/util/testhelper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/testing/protocmp"
 	"k8s.io/client-go/kubernetes/fake"
 
@@ -656,3 +666,338 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigGet(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name:   "pod1",
+			Model:  ModelXRD,
+			Config: &tpb.Config{},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		want     string
+		testFile string
+	}{{
+		desc: "success",
+		want: `!! IOS XR Configuration 7.5.1
+!! Last configuration change at Wed Jun  1 10:10:00 2022 by cisco
+!
+hostname xrd
+username cisco
+ group root-lr
+ group cisco-support
+ secret 10 $6$1n9ZY/Ly1e9n8Y/.$Tz/YxXnOj7y6s/fN19PTEEH1SJ9KIExpvdQhzZSVUMc8DwfAHS7cYavRU9ADmXwwQ3L6tdtOqVxNdvvZd6WJB1
+!
+interface MgmtEth0/RP0/CPU0/0
+ ipv4 address 172.20.20.2 255.255.255.0
+!
+interface GigabitEthernet0/0/0/0
+ ipv4 address 10.10.10.1 255.255.255.254
+!
+grpc
+ port 57400
+!
+end
+`,
+		testFile: "get_config_success",
+	}, {
+		// device returns "% Invalid input detected" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "% Invalid input detected",
+		testFile: "get_config_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne cisco node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewIOSXRDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			got, err := n.ConfigGet(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ConfigGet() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if s := cmp.Diff(tt.want, string(got)); s != "" {
+				t.Errorf("ConfigGet() unexpected config (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestGenerateSelfSigned(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name:  "pod1",
+			Model: ModelXRD,
+			Config: &tpb.Config{
+				Cert: &tpb.CertificateCfg{
+					Config: &tpb.CertificateCfg_SelfSigned{
+						SelfSigned: &tpb.SelfSignedCertCfg{
+							CertName: "my_cert",
+							KeyName:  "my_key",
+							KeySize:  2048,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		testFile string
+	}{{
+		desc:     "success",
+		testFile: "generate_certificate_success",
+	}, {
+		// device returns "% Failed to commit" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "% Failed to commit",
+		testFile: "generate_certificate_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne cisco node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewIOSXRDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			err = n.GenerateSelfSigned(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GenerateSelfSigned() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestConfigPush(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name:   "pod1",
+			Model:  ModelXRD,
+			Config: &tpb.Config{},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		cfg      string
+		wantErr  string
+		testFile string
+	}{{
+		desc:     "success",
+		cfg:      "hostname xrd\ninterface GigabitEthernet0/0/0/0\n ipv4 address 10.10.10.1 255.255.255.254\n!\ngrpc\n port 57400\n!\nend\n",
+		testFile: "config_push_success",
+	}, {
+		// device returns "% Invalid input detected" -- we expect to fail
+		desc:     "failure",
+		cfg:      "hostname xrd\ninterface GigabitEthernet0/0/0/0\n ipv4 addres 10.10.10.1 255.255.255.254\n!\nend\n",
+		wantErr:  "% Invalid input detected",
+		testFile: "config_push_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne cisco node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewIOSXRDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			err = n.ConfigPush(context.Background(), strings.NewReader(tt.cfg))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ConfigPush() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestResetCfg(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name:  "pod1",
+			Model: ModelXRD,
+			Config: &tpb.Config{
+				ConfigData: &tpb.Config_Data{
+					Data: []byte("hostname xrd\n"),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		testFile string
+	}{{
+		desc:     "success",
+		testFile: "reset_config_success",
+	}, {
+		// device returns "% Failed to commit" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "% Failed to commit",
+		testFile: "reset_config_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne cisco node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewIOSXRDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			err = n.ResetCfg(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ResetCfg() unexpected error: %s", s)
+			}
+		})
+	}
+}
+
+func TestCLIUnavailable(t *testing.T) {
+	origWait, origRetry := waitForCLITimeout, cliRetryInterval
+	defer func() {
+		waitForCLITimeout, cliRetryInterval = origWait, origRetry
+	}()
+	waitForCLITimeout, cliRetryInterval = 100*time.Millisecond, 10*time.Millisecond
+	// An empty session never shows a prompt, so the CLI is never ready.
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatalf("failed to write session: %v", err)
+	}
+	oldNewCoreDriver := scraplicore.NewCoreDriver
+	defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+	scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+		return scraplicore.NewIOSXRDriver(
+			host,
+			scraplibase.WithAuthBypass(true),
+			scraplibase.WithTimeoutOps(10*time.Millisecond),
+			scraplitest.WithPatchedTransport(empty),
+		)
+	}
+
+	tests := []struct {
+		desc     string
+		model    string
+		wantErr  string
+		wantCode codes.Code
+	}{{
+		desc:     "unsupported model",
+		model:    "8201",
+		wantErr:  `CLI of model "8201" not supported`,
+		wantCode: codes.Unimplemented,
+	}, {
+		desc:     "cli not ready",
+		model:    ModelXRD,
+		wantErr:  "cli not ready",
+		wantCode: codes.Unknown,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(&node.Impl{
+				KubeClient: fake.NewSimpleClientset(),
+				Namespace:  "test",
+				Proto:      &tpb.Node{Name: "pod1", Model: tt.model},
+			})
+			if err != nil {
+				t.Fatalf("failed creating kne cisco node")
+			}
+			n, _ := nImpl.(*Node)
+			for name, f := range map[string]func() error{
+				"ResetCfg":   func() error { return n.ResetCfg(context.Background()) },
+				"ConfigPush": func() error { return n.ConfigPush(context.Background(), strings.NewReader("hostname r1\n")) },
+				"ConfigGet": func() error {
+					_, err := n.ConfigGet(context.Background())
+					return err
+				},
+			} {
+				err := f()
+				if s := errdiff.Substring(err, tt.wantErr); s != "" {
+					t.Errorf("%s() unexpected error: %s", name, s)
+				}
+				if got := status.Code(err); got != tt.wantCode {
+					t.Errorf("%s() got code %v, want %v", name, got, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectUnsupportedModel(t *testing.T) {
+	nImpl, err := New(&node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto:      &tpb.Node{Name: "pod1", Model: "8201"},
+	})
+	if err != nil {
+		t.Fatalf("failed creating kne cisco node")
+	}
+	if _, err := nImpl.(*Node).Collect(context.Background()); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Collect() got error %v, want code %v", err, codes.Unimplemented)
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 	scraplicfg "github.com/scrapli/scrapligo/cfg"
 	scraplibase "github.com/scrapli/scrapligo/driver/base"
 	scraplicore "github.com/scrapli/scrapligo/driver/core"
@@ -39,6 +41,58 @@ const (
 	defaultInitContainerImage = "networkop/init-wait:latest"
 )
 
+const (
+	// ModelCPTX is the containerized PTX, the default model.
+	ModelCPTX = "cptx"
+	// ModelCRPD is the containerized routing protocol daemon, which routes
+	// over the Linux interfaces of its pod.
+	ModelCRPD = "crpd"
+	// ModelVMX is the vMX virtual machine run in a vrnetlab container.
+	ModelVMX = "vmx"
+)
+
+// modelDefaults are the computed defaults of a Juniper model, the static
+// defaults are taken from the JUNIPER vendor profiles.
+type modelDefaults struct {
+	// nodeType is the type label of the node, if the model has a type.
+	nodeType     tpb.Node_Type
+	entryCommand string
+	// intfPrefix is the prefix of the Junos interface names, eth1 is mapped
+	// to the first port. Linux interface names are kept if empty.
+	intfPrefix string
+	// keepIntfs leaves the interface names of the node unset, as cPTX
+	// topologies set the names of the interfaces they rename themselves.
+	keepIntfs bool
+}
+
+var models = map[string]*modelDefaults{
+	ModelCPTX: {
+		nodeType:     tpb.Node_JUNIPER_CEVO,
+		entryCommand: "kubectl exec -it %s -- cli -c",
+		keepIntfs:    true,
+	},
+	ModelCRPD: {
+		entryCommand: "kubectl exec -it %s -- cli",
+	},
+	ModelVMX: {
+		nodeType:     tpb.Node_JUNIPER_VMX,
+		entryCommand: "kubectl exec -it %s -- telnet 127.0.0.1 5000",
+		intfPrefix:   "ge-0/0/",
+	},
+}
+
+// model returns the model of the node, which defaults to vMX for the
+// JUNIPER_VMX type and cPTX otherwise.
+func model(pb *tpb.Node) string {
+	switch {
+	case pb.GetModel() != "":
+		return pb.GetModel()
+	case pb.GetType() == tpb.Node_JUNIPER_VMX:
+		return ModelVMX
+	}
+	return ModelCPTX
+}
+
 func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl == nil {
 		return nil, fmt.Errorf("nodeImpl cannot be nil")
@@ -46,8 +100,15 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl.Proto == nil {
 		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
 	}
+	if _, ok := models[model(nodeImpl.Proto)]; !ok {
+		return nil, fmt.Errorf("unexpected model %q", nodeImpl.Proto.Model)
+	}
 	cfg := defaults(nodeImpl.Proto)
 	nodeImpl.Proto = cfg
+	fixInterfaces(cfg)
+	if cfg.Model == ModelVMX {
+		return &VMXNode{Impl: nodeImpl}, nil
+	}
 	n := &Node{
 		Impl: nodeImpl,
 	}
@@ -61,9 +122,30 @@ type Node struct {
 
 // Add validations for interfaces the node provides
 var (
+	_ node.CLIer        = (*Node)(nil)
+	_ node.Certer       = (*Node)(nil)
+	_ node.Collector    = (*Node)(nil)
+	_ node.ConfigGetter = (*Node)(nil)
 	_ node.ConfigPusher = (*Node)(nil)
 )
 
+// CLICommand returns the command to start the vendor CLI.
+func (n *Node) CLICommand() []string {
+	return []string{"cli"}
+}
+
+// diagnosticCommands are the commands collected by Collect keyed by file name.
+var diagnosticCommands = map[string][]string{
+	"request-support-information.txt": {"cli", "-c", "request support information"},
+	"show-configuration.txt":          {"cli", "-c", "show configuration"},
+	"show-log-messages.txt":           {"cli", "-c", "show log messages"},
+}
+
+// Collect returns the output of the cPTX diagnostic commands.
+func (n *Node) Collect(ctx context.Context) (map[string][]byte, error) {
+	return node.CollectCommands(ctx, n, diagnosticCommands), nil
+}
+
 // WaitCLIReady attempts to open the transport channel towards a Network OS and perform scrapligo OnOpen actions
 // for a given platform. Retries with exponential backoff.
 func (n *Node) WaitCLIReady(ctx context.Context) error {
@@ -142,6 +224,73 @@ func (n *Node) SpawnCLIConn(ns string) error {
 	return nil
 }
 
+// certFailedWhenContains are the Junos errors of the PKI commands, which
+// report failures with "error:" in addition to the CLI errors.
+var certFailedWhenContains = []string{
+	"error:",
+	"is ambiguous",
+	"No valid completions",
+	"unknown command",
+	"syntax error",
+}
+
+// GenerateSelfSigned generates a key pair and a self-signed local certificate
+// with the cert name as certificate ID. Junos names the key pair after the
+// certificate, so the key name is not used.
+func (n *Node) GenerateSelfSigned(ctx context.Context) error {
+	selfSigned := n.Proto.GetConfig().GetCert().GetSelfSigned()
+	if selfSigned == nil {
+		log.Infof("%s - no cert config", n.Name())
+		return nil
+	}
+	log.Infof("%s - generating self signed certs", n.Name())
+
+	err := n.SpawnCLIConn(n.Namespace)
+	if err != nil {
+		return err
+	}
+
+	defer n.cliConn.Close()
+
+	keySize := selfSigned.GetKeySize()
+	if keySize == 0 {
+		keySize = 2048
+	}
+	commonName := selfSigned.GetCommonName()
+	if commonName == "" {
+		commonName = n.Name()
+	}
+	cmds := []string{
+		fmt.Sprintf(
+			"request security pki generate-key-pair certificate-id %s size %d type rsa",
+			selfSigned.GetCertName(),
+			keySize,
+		),
+		fmt.Sprintf(
+			"request security pki local-certificate generate-self-signed certificate-id %s domain-name %s subject CN=%s",
+			selfSigned.GetCertName(),
+			n.Name(),
+			commonName,
+		),
+	}
+
+	resp, err := n.cliConn.SendCommands(
+		cmds,
+		scraplibase.WithSendFailedWhenContains(certFailedWhenContains),
+		scraplibase.WithSendStopOnFailed(true),
+	)
+	if err != nil {
+		return err
+	}
+	if resp.Failed != nil {
+		return resp.Failed
+	}
+
+	log.Infof("%s - finished cert generation", n.Name())
+
+	return nil
+}
+
 func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
 	log.Infof("%s - pushing config", n.Name())
 
@@ -209,6 +358,31 @@ func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
 	return nil
 }
 
+// ConfigGet returns the committed config of the node in the curly brace
+// format loaded by ConfigPush.
+func (n *Node) ConfigGet(ctx context.Context) ([]byte, error) {
+	log.Infof("%s - getting config", n.Name())
+
+	err := n.SpawnCLIConn(n.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	defer n.cliConn.Close()
+
+	resp, err := n.cliConn.SendCommand("show configuration")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, resp.Failed
+	}
+
+	log.Infof("%s - finished getting config", n.Name())
+
+	return []byte(strings.TrimSpace(resp.Result) + "\n"), nil
+}
+
 func (n *Node) ResetCfg(ctx context.Context) error {
 	log.Infof("%s - resetting config", n.Name())
 
@@ -242,12 +416,12 @@ func (n *Node) ResetCfg(ctx context.Context) error {
 }
 
 func (n *Node) Create(ctx context.Context) error {
-	log.Infof("Creating cPTX node resource %s", n.Name())
+	log.Infof("Creating Juniper %s node resource %s", n.Proto.Model, n.Name())
 
 	if err := n.CreateConfig(ctx); err != nil {
 		return fmt.Errorf("node %s failed to create config-map %w", n.Name(), err)
 	}
-	log.Infof("Created cPTX node %s configmap", n.Name())
+	log.Infof("Created Juniper %s node %s configmap", n.Proto.Model, n.Name())
 
 	pb := n.Proto
 	initContainerImage := pb.Config.InitImage
@@ -255,12 +429,17 @@ func (n *Node) Create(ctx context.Context) error {
 		initContainerImage = defaultInitContainerImage
 	}
 
-	// downward api - pass some useful values to container
-	if n.isChannelized() {
-		pb.Config.Env["CPTX_CHANNELIZED"] = "1"
+	if pb.Model == ModelCPTX {
+		// downward api - pass some useful values to container
+		if pb.Config.Env == nil {
+			pb.Config.Env = map[string]string{}
+		}
+		if n.isChannelized() {
+			pb.Config.Env["CPTX_CHANNELIZED"] = "1"
+		}
+		pb.Config.Env["CPTX_CPU_LIMIT"] = pb.Constraints["cpu"]
+		pb.Config.Env["CPTX_MEMORY_LIMIT"] = pb.Constraints["memory"]
 	}
-	pb.Config.Env["CPTX_CPU_LIMIT"] = pb.Constraints["cpu"]
-	pb.Config.Env["CPTX_MEMORY_LIMIT"] = pb.Constraints["memory"]
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: n.Name(),
@@ -354,11 +533,11 @@ func (n *Node) Create(ctx context.Context) error {
 		return fmt.Errorf("failed to create pod for %q: %w", pb.Name, err)
 	}
 	log.Debugf("Pod created:\n%+v\n", sPod)
-	log.Infof("Created cPTX node resource %s pod", n.Name())
+	log.Infof("Created Juniper %s node resource %s pod", pb.Model, n.Name())
 	if err := n.CreateService(ctx); err != nil {
 		return err
 	}
-	log.Infof("Created cPTX node resource %s services", n.Name())
+	log.Infof("Created Juniper %s node resource %s services", pb.Model, n.Name())
 	return nil
 }
 
@@ -368,69 +547,48 @@ func defaults(pb *tpb.Node) *tpb.Node {
 			Name: "default_cptx_node",
 		}
 	}
-	if pb.Constraints == nil {
-		pb.Constraints = map[string]string{
-			"cpu":    "8",
-			"memory": "8Gi",
-		}
-	}
-	if pb.Services == nil {
-		pb.Services = map[uint32]*tpb.Service{
-			443: {
-				Name:   "ssl",
-				Inside: 443,
-			},
-			22: {
-				Name:   "ssh",
-				Inside: 22,
-			},
-			50051: {
-				Name:   "gnmi",
-				Inside: 50051,
-			},
-		}
-	}
+	pb.Model = model(pb)
+	md := models[pb.Model]
+	profile.Embedded().Fill(tpb.Vendor_JUNIPER, pb)
 	if pb.Labels == nil {
-		pb.Labels = map[string]string{
-			"type":   tpb.Node_JUNIPER_CEVO.String(),
-			"vendor": tpb.Vendor_JUNIPER.String(),
-		}
-	} else {
-		if pb.Labels["type"] == "" {
-			pb.Labels["type"] = tpb.Node_JUNIPER_CEVO.String()
-		}
-		if pb.Labels["vendor"] == "" {
-			pb.Labels["vendor"] = tpb.Vendor_JUNIPER.String()
-		}
-	}
-	if pb.Config == nil {
-		pb.Config = &tpb.Config{}
-	}
-	if len(pb.Config.GetCommand()) == 0 {
-		pb.Config.Command = []string{
-			"/entrypoint.sh",
-		}
+		pb.Labels = map[string]string{}
 	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "cptx:latest"
+	if pb.Labels["type"] == "" && md.nodeType != tpb.Node_UNKNOWN {
+		pb.Labels["type"] = md.nodeType.String()
 	}
-	if pb.Config.Env == nil {
-		pb.Config.Env = map[string]string{
-			"CPTX": "1",
-		}
+	if pb.Labels["vendor"] == "" {
+		pb.Labels["vendor"] = tpb.Vendor_JUNIPER.String()
 	}
 	if pb.Config.EntryCommand == "" {
-		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- cli -c", pb.Name)
-	}
-	if pb.Config.ConfigPath == "" {
-		pb.Config.ConfigPath = "/home/evo/configdisk"
-	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "juniper.conf"
+		pb.Config.EntryCommand = fmt.Sprintf(md.entryCommand, pb.Name)
 	}
 	return pb
 }
 
+// fixInterfaces sets the Junos names of the eth interfaces of the node which
+// have no name, eth1 is mapped to the first port of the model. The
+// interfaces of cPTX nodes are not renamed.
+func fixInterfaces(pb *tpb.Node) {
+	if models[pb.Model].keepIntfs {
+		return
+	}
+	prefix := models[pb.Model].intfPrefix
+	for k, v := range pb.Interfaces {
+		if v.Name != "" || !strings.HasPrefix(k, "eth") {
+			continue
+		}
+		if prefix == "" {
+			v.Name = k
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(k, "eth"))
+		if err != nil || id < 1 {
+			continue
+		}
+		v.Name = fmt.Sprintf("%s%d", prefix, id-1)
+	}
+}
+
 // isChannelized is a helper function that returns 1 if cptx is channelized
 func (n *Node) isChannelized() bool {
 	interfaces := n.GetProto().GetInterfaces()
@@ -444,5 +602,6 @@ func (n *Node) isChannelized() bool {
 
 func init() {
 	node.Register(tpb.Node_JUNIPER_CEVO, New)
+	node.Register(tpb.Node_JUNIPER_VMX, New)
 	node.Vendor(tpb.Vendor_JUNIPER, New)
 }
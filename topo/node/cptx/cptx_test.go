@@ -11,6 +11,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -171,6 +172,68 @@ func TestConfigPush(t *testing.T) {
 	}
 }
 
+func TestConfigGet(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name:   "pod1",
+			Type:   2,
+			Config: &tpb.Config{},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		want     []string
+		testFile string
+	}{{
+		desc:     "success",
+		want:     []string{"host-name cptx2;\n", "address 10.10.10.2/31;\n"},
+		testFile: "get_config_success",
+	}, {
+		// device returns "unknown command" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "unknown command",
+		testFile: "get_config_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne juniper node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewJUNOSDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			got, err := n.ConfigGet(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ConfigGet() unexpected error: %s", s)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(string(got), w) {
+					t.Errorf("ConfigGet() missing %q, got:\n%s", w, got)
+				}
+			}
+			if strings.Contains(string(got), "root@cptx2>") {
+				t.Errorf("ConfigGet() contains prompt, got:\n%s", got)
+			}
+		})
+	}
+}
+
 func TestCustomPrivilegeLevel(t *testing.T) {
 	privilegePromptMap := map[string]string{
 		"exec":          "root@%s>",
@@ -266,7 +329,8 @@ func TestNew(t *testing.T) {
 			},
 		},
 		want: &tpb.Node{
-			Name: "pod1",
+			Name:  "pod1",
+			Model: ModelCPTX,
 			Constraints: map[string]string{
 				"cpu":    "8",
 				"memory": "8Gi",
@@ -313,6 +377,7 @@ func TestNew(t *testing.T) {
 			Proto:      &tpb.Node{},
 		},
 		want: &tpb.Node{
+			Model: ModelCPTX,
 			Constraints: map[string]string{
 				"cpu":    "8",
 				"memory": "8Gi",
@@ -348,6 +413,170 @@ func TestNew(t *testing.T) {
 				ConfigFile:   "juniper.conf",
 			},
 		},
+	}, {
+		desc: "cptx interfaces",
+		ni: &node.Impl{
+			KubeClient: fake.NewSimpleClientset(),
+			Namespace:  "test",
+			Proto: &tpb.Node{
+				Name: "pod1",
+				Interfaces: map[string]*tpb.Interface{
+					"eth1": {},
+					"eth2": {Name: "et-0/0/1:0"},
+				},
+			},
+		},
+		want: &tpb.Node{
+			Name:  "pod1",
+			Model: ModelCPTX,
+			Constraints: map[string]string{
+				"cpu":    "8",
+				"memory": "8Gi",
+			},
+			Services: map[uint32]*tpb.Service{
+				443: {
+					Name:   "ssl",
+					Inside: 443,
+				},
+				22: {
+					Name:   "ssh",
+					Inside: 22,
+				},
+				50051: {
+					Name:   "gnmi",
+					Inside: 50051,
+				},
+			},
+			Labels: map[string]string{
+				"type":   tpb.Node_JUNIPER_CEVO.String(),
+				"vendor": tpb.Vendor_JUNIPER.String(),
+			},
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {},
+				"eth2": {Name: "et-0/0/1:0"},
+			},
+			Config: &tpb.Config{
+				Image: "cptx:latest",
+				Command: []string{
+					"/entrypoint.sh",
+				},
+				Env: map[string]string{
+					"CPTX": "1",
+				},
+				EntryCommand: "kubectl exec -it pod1 -- cli -c",
+				ConfigPath:   "/home/evo/configdisk",
+				ConfigFile:   "juniper.conf",
+			},
+		},
+	}, {
+		desc: "crpd",
+		ni: &node.Impl{
+			KubeClient: fake.NewSimpleClientset(),
+			Namespace:  "test",
+			Proto: &tpb.Node{
+				Name:  "pod1",
+				Model: ModelCRPD,
+				Interfaces: map[string]*tpb.Interface{
+					"eth1": {},
+				},
+			},
+		},
+		want: &tpb.Node{
+			Name:  "pod1",
+			Model: ModelCRPD,
+			Constraints: map[string]string{
+				"cpu":    "1",
+				"memory": "1Gi",
+			},
+			Services: map[uint32]*tpb.Service{
+				443: {
+					Name:   "ssl",
+					Inside: 443,
+				},
+				22: {
+					Name:   "ssh",
+					Inside: 22,
+				},
+				50051: {
+					Name:   "gnmi",
+					Inside: 50051,
+				},
+			},
+			Labels: map[string]string{
+				"vendor": tpb.Vendor_JUNIPER.String(),
+			},
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Name: "eth1"},
+			},
+			Config: &tpb.Config{
+				Image:        "crpd:latest",
+				EntryCommand: "kubectl exec -it pod1 -- cli",
+				ConfigPath:   "/config",
+				ConfigFile:   "juniper.conf",
+			},
+		},
+	}, {
+		desc: "vmx type",
+		ni: &node.Impl{
+			KubeClient: fake.NewSimpleClientset(),
+			Namespace:  "test",
+			Proto: &tpb.Node{
+				Name: "pod1",
+				Type: tpb.Node_JUNIPER_VMX,
+				Interfaces: map[string]*tpb.Interface{
+					"eth1": {},
+					"eth2": {},
+				},
+			},
+		},
+		want: &tpb.Node{
+			Name:  "pod1",
+			Type:  tpb.Node_JUNIPER_VMX,
+			Model: ModelVMX,
+			Constraints: map[string]string{
+				"cpu":    "4",
+				"memory": "8Gi",
+			},
+			Services: map[uint32]*tpb.Service{
+				443: {
+					Name:   "ssl",
+					Inside: 443,
+				},
+				22: {
+					Name:   "ssh",
+					Inside: 22,
+				},
+				50051: {
+					Name:   "gnmi",
+					Inside: 50051,
+				},
+			},
+			Labels: map[string]string{
+				"type":   tpb.Node_JUNIPER_VMX.String(),
+				"vendor": tpb.Vendor_JUNIPER.String(),
+			},
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Name: "ge-0/0/0"},
+				"eth2": {Name: "ge-0/0/1"},
+			},
+			Config: &tpb.Config{
+				Image:        "vrnetlab/vr-vmx:latest",
+				EntryCommand: "kubectl exec -it pod1 -- telnet 127.0.0.1 5000",
+				ConfigPath:   "/config",
+				ConfigFile:   "startup-config.cfg",
+			},
+		},
+	}, {
+		desc: "unknown model",
+		ni: &node.Impl{
+			KubeClient: fake.NewSimpleClientset(),
+			Namespace:  "test",
+			Proto: &tpb.Node{
+				Name:  "pod1",
+				Model: "mx960",
+			},
+		},
+		wantErr: `unexpected model "mx960"`,
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -361,6 +590,9 @@ func TestNew(t *testing.T) {
 			if s := cmp.Diff(n.GetProto(), tt.want, protocmp.Transform(), protocmp.IgnoreFields(&tpb.Service{}, "node_port")); s != "" {
 				t.Fatalf("Protos not equal: %s", s)
 			}
+			if _, ok := n.(*VMXNode); ok != (tt.want.GetModel() == ModelVMX) {
+				t.Errorf("New() returned %T for model %q", n, tt.want.GetModel())
+			}
 			err = n.Create(context.Background())
 			if s := errdiff.Check(err, tt.cErr); s != "" {
 				t.Fatalf("Unexpected error: %s", s)
@@ -368,3 +600,65 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateSelfSigned(t *testing.T) {
+	ni := &node.Impl{
+		KubeClient: fake.NewSimpleClientset(),
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name: "pod1",
+			Type: 2,
+			Config: &tpb.Config{
+				Cert: &tpb.CertificateCfg{
+					Config: &tpb.CertificateCfg_SelfSigned{
+						SelfSigned: &tpb.SelfSignedCertCfg{
+							CertName: "my_cert",
+							KeyName:  "my_key",
+							KeySize:  2048,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc     string
+		wantErr  string
+		testFile string
+	}{{
+		desc:     "success",
+		testFile: "generate_certificate_success",
+	}, {
+		// device returns "error: Key pair already exists" -- we expect to fail
+		desc:     "failure",
+		wantErr:  "Key pair already exists",
+		testFile: "generate_certificate_failure",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(ni)
+			if err != nil {
+				t.Fatalf("failed creating kne juniper node")
+			}
+			n, _ := nImpl.(*Node)
+
+			oldNewCoreDriver := scraplicore.NewCoreDriver
+			defer func() { scraplicore.NewCoreDriver = oldNewCoreDriver }()
+			scraplicore.NewCoreDriver = func(host, platform string, options ...scraplibase.Option) (*scraplinetwork.Driver, error) {
+				return scraplicore.NewJUNOSDriver(
+					host,
+					scraplibase.WithAuthBypass(true),
+					scraplibase.WithTimeoutOps(1*time.Second),
+					scraplitest.WithPatchedTransport(tt.testFile),
+				)
+			}
+
+			err = n.GenerateSelfSigned(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GenerateSelfSigned() unexpected error: %s", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cptx
+
+import (
+	"github.com/openconfig/kne/topo/node"
+)
+
+// VMXNode is a Juniper vMX run in a vrnetlab container, which boots the vMX
+// VM with the startup config and connects its ports to the eth interfaces of
+// the pod. The Junos CLI runs in the VM and is reached through its console, so
+// config push, reset and certs are not supported.
+type VMXNode struct {
+	*node.Impl
+}
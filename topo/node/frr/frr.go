@@ -0,0 +1,152 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package frr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// daemons are the routing daemons enabled in the daemons file of the
+	// image, which only enables zebra and staticd.
+	daemons = "bgpd|ospfd|ospf6d|isisd|bfdd"
+	// pushedConfigFile is the file the pushed config is written to before
+	// it is applied.
+	pushedConfigFile = "/tmp/kne-frr.conf"
+	// reloadCmd applies the differences between a config and the running
+	// config.
+	reloadCmd = "/usr/lib/frr/frr-reload.py"
+)
+
+func New(nodeImpl *node.Impl) (node.Node, error) {
+	if nodeImpl == nil {
+		return nil, fmt.Errorf("nodeImpl cannot be nil")
+	}
+	if nodeImpl.Proto == nil {
+		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
+	}
+	defaults(nodeImpl.Proto)
+	n := &Node{
+		Impl: nodeImpl,
+	}
+	return n, nil
+}
+
+type Node struct {
+	*node.Impl
+}
+
+// Add validations for interfaces the node provides
+var (
+	_ node.CLIer        = (*Node)(nil)
+	_ node.ConfigPusher = (*Node)(nil)
+	_ node.Resetter     = (*Node)(nil)
+)
+
+// writeFile and run are swapped in tests as the exec transport cannot be faked.
+var (
+	writeFile = node.WriteFile
+	run       = runCommand
+)
+
+// runCommand runs cmd in the node container, the output of a failed command
+// is returned in the error.
+func runCommand(ctx context.Context, e node.Execer, cmd []string) error {
+	var stdout, stderr bytes.Buffer
+	if err := e.ExecWithOptions(ctx, cmd, &node.ExecOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		out := strings.TrimSpace(stdout.String() + "\n" + stderr.String())
+		return fmt.Errorf("%q failed: %w: %s", strings.Join(cmd, " "), err, out)
+	}
+	return nil
+}
+
+// CLICommand returns the command to start the vendor CLI.
+func (n *Node) CLICommand() []string {
+	return []string{"vtysh"}
+}
+
+// ConfigPush applies the config on top of the running config with vtysh.
+func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
+	log.Infof("%s - pushing config", n.Name())
+
+	cfg, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(cfg))
+
+	if err := writeFile(ctx, n, pushedConfigFile, cfg); err != nil {
+		return err
+	}
+	if err := run(ctx, n, []string{"vtysh", "-f", pushedConfigFile}); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished config push", n.Name())
+
+	return nil
+}
+
+// ResetCfg reloads the startup config of the node, or an empty config if the
+// node has none, removing any config pushed since.
+func (n *Node) ResetCfg(ctx context.Context) error {
+	log.Infof("%s - resetting config", n.Name())
+
+	cfgFile := filepath.Join(n.Proto.Config.ConfigPath, n.Proto.Config.ConfigFile)
+	if n.Proto.Config.ConfigData == nil {
+		cfgFile = pushedConfigFile
+		if err := writeFile(ctx, n, cfgFile, nil); err != nil {
+			return err
+		}
+	}
+	if err := run(ctx, n, []string{reloadCmd, "--reload", cfgFile}); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished resetting config", n.Name())
+
+	return nil
+}
+
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the FRR vendor profile.
+func defaults(pb *tpb.Node) *tpb.Node {
+	profile.Embedded().Fill(tpb.Vendor_FRR, pb)
+	if len(pb.GetConfig().GetCommand()) == 0 {
+		pb.Config.Command = []string{
+			"/bin/sh", "-c",
+			fmt.Sprintf(`sed -i -E 's/^(%s)=no/\1=yes/' /etc/frr/daemons && exec /sbin/tini -- /usr/lib/frr/docker-start`, daemons),
+		}
+	}
+	if pb.Config.EntryCommand == "" {
+		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- vtysh", pb.Name)
+	}
+	return pb
+}
+
+func init() {
+	node.Register(tpb.Node_FRR, New)
+	node.Vendor(tpb.Vendor_FRR, New)
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package frr
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		ni      *node.Impl
+		wantPB  *tpb.Node
+		wantErr string
+	}{{
+		desc:    "nil node impl",
+		wantErr: "nodeImpl cannot be nil",
+	}, {
+		desc:    "nil pb",
+		wantErr: "nodeImpl.Proto cannot be nil",
+		ni:      &node.Impl{},
+	}, {
+		desc: "test defaults",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name: "test_node",
+				Config: &tpb.Config{
+					Image:   "foobar",
+					Command: []string{"run", "some", "command"},
+				},
+			},
+		},
+		wantPB: &tpb.Node{
+			Name: "test_node",
+			Config: &tpb.Config{
+				Image:        "foobar",
+				Command:      []string{"run", "some", "command"},
+				EntryCommand: "kubectl exec -it test_node -- vtysh",
+				ConfigPath:   "/etc/frr",
+				ConfigFile:   "frr.conf",
+			},
+		},
+	}, {
+		desc: "valid pb",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name: "test_node",
+			},
+		},
+		wantPB: &tpb.Node{
+			Name: "test_node",
+			Config: &tpb.Config{
+				Image: "quay.io/frrouting/frr:8.4.1",
+				Command: []string{
+					"/bin/sh", "-c",
+					`sed -i -E 's/^(bgpd|ospfd|ospf6d|isisd|bfdd)=no/\1=yes/' /etc/frr/daemons && exec /sbin/tini -- /usr/lib/frr/docker-start`,
+				},
+				EntryCommand: "kubectl exec -it test_node -- vtysh",
+				ConfigPath:   "/etc/frr",
+				ConfigFile:   "frr.conf",
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			impl, err := New(tt.ni)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: got: %v, want: %s", err, s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if !proto.Equal(impl.GetProto(), tt.wantPB) {
+				t.Fatalf("New() failed: got\n%swant\n%s", prototext.Format(impl.GetProto()), prototext.Format(tt.wantPB))
+			}
+		})
+	}
+}
+
+// fakeExec records the files written and the commands run on the node.
+type fakeExec struct {
+	files   map[string]string
+	cmds    [][]string
+	failCmd string
+}
+
+func (f *fakeExec) patch(t *testing.T) {
+	oldWriteFile, oldRun := writeFile, run
+	t.Cleanup(func() { writeFile, run = oldWriteFile, oldRun })
+	writeFile = func(_ context.Context, _ node.Execer, p string, b []byte) error {
+		f.files[p] = string(b)
+		return nil
+	}
+	run = func(_ context.Context, _ node.Execer, cmd []string) error {
+		f.cmds = append(f.cmds, cmd)
+		if cmd[0] == f.failCmd {
+			return fmt.Errorf("%q failed: exit code 1: line 2: %% Unknown command: router bgpp 65001", strings.Join(cmd, " "))
+		}
+		return nil
+	}
+}
+
+func TestConfigPush(t *testing.T) {
+	cfg := "router bgp 65001\n neighbor 10.0.0.2 remote-as 65002\n"
+	tests := []struct {
+		desc      string
+		failCmd   string
+		wantErr   string
+		wantFiles map[string]string
+		wantCmds  [][]string
+	}{{
+		desc:      "success",
+		wantFiles: map[string]string{"/tmp/kne-frr.conf": cfg},
+		wantCmds:  [][]string{{"vtysh", "-f", "/tmp/kne-frr.conf"}},
+	}, {
+		desc:      "vtysh failure",
+		failCmd:   "vtysh",
+		wantErr:   "Unknown command",
+		wantFiles: map[string]string{"/tmp/kne-frr.conf": cfg},
+		wantCmds:  [][]string{{"vtysh", "-f", "/tmp/kne-frr.conf"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(&node.Impl{Proto: &tpb.Node{Name: "r1"}})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			f := &fakeExec{files: map[string]string{}, failCmd: tt.failCmd}
+			f.patch(t)
+			err = nImpl.(*Node).ConfigPush(context.Background(), strings.NewReader(cfg))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ConfigPush() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.wantFiles, f.files); s != "" {
+				t.Errorf("ConfigPush() unexpected files (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantCmds, f.cmds); s != "" {
+				t.Errorf("ConfigPush() unexpected commands (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+func TestResetCfg(t *testing.T) {
+	tests := []struct {
+		desc      string
+		pb        *tpb.Node
+		failCmd   string
+		wantErr   string
+		wantFiles map[string]string
+		wantCmds  [][]string
+	}{{
+		desc: "startup config",
+		pb: &tpb.Node{
+			Name: "r1",
+			Config: &tpb.Config{
+				ConfigData: &tpb.Config_Data{Data: []byte("hostname r1\n")},
+			},
+		},
+		wantFiles: map[string]string{},
+		wantCmds:  [][]string{{"/usr/lib/frr/frr-reload.py", "--reload", "/etc/frr/frr.conf"}},
+	}, {
+		desc:      "no startup config",
+		pb:        &tpb.Node{Name: "r1"},
+		wantFiles: map[string]string{"/tmp/kne-frr.conf": ""},
+		wantCmds:  [][]string{{"/usr/lib/frr/frr-reload.py", "--reload", "/tmp/kne-frr.conf"}},
+	}, {
+		desc:      "reload failure",
+		pb:        &tpb.Node{Name: "r1"},
+		failCmd:   "/usr/lib/frr/frr-reload.py",
+		wantErr:   "exit code 1",
+		wantFiles: map[string]string{"/tmp/kne-frr.conf": ""},
+		wantCmds:  [][]string{{"/usr/lib/frr/frr-reload.py", "--reload", "/tmp/kne-frr.conf"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			nImpl, err := New(&node.Impl{Proto: tt.pb})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			f := &fakeExec{files: map[string]string{}, failCmd: tt.failCmd}
+			f.patch(t)
+			err = nImpl.(*Node).ResetCfg(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("ResetCfg() unexpected error: %s", s)
+			}
+			if s := cmp.Diff(tt.wantFiles, f.files); s != "" {
+				t.Errorf("ResetCfg() unexpected files (-want +got):\n%s", s)
+			}
+			if s := cmp.Diff(tt.wantCmds, f.cmds); s != "" {
+				t.Errorf("ResetCfg() unexpected commands (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+type outputExecer struct{}
+
+func (outputExecer) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	fmt.Fprintln(opts.Stdout, "line 2: % Unknown command: router bgpp 65001")
+	return fmt.Errorf("exit code 1")
+}
+
+func TestRunCommand(t *testing.T) {
+	err := runCommand(context.Background(), outputExecer{}, []string{"vtysh", "-f", "/tmp/kne-frr.conf"})
+	if s := errdiff.Substring(err, "% Unknown command: router bgpp 65001"); s != "" {
+		t.Errorf("runCommand() unexpected error: %s", s)
+	}
+}
@@ -0,0 +1,320 @@
+package node
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/pki"
+)
+
+// GNMIService is the name of the node service exposing gNMI.
+const GNMIService = "gnmi"
+
+// GNMIOptions configures a config push with gNMI Set.
+type GNMIOptions struct {
+	// Username and Password are sent in the metadata of the request if set.
+	Username string
+	Password string
+	// Encoding is the encoding of the payload, JSON_IETF for OpenConfig JSON.
+	// Vendor native payloads use the encoding supported by the vendor, such
+	// as ASCII for CLI config.
+	Encoding gpb.Encoding
+	// Origin is the origin of the replaced root path, such as "cli" for
+	// vendor native CLI config. It defaults to the OpenConfig origin.
+	Origin string
+	// Address is the address of the gNMI server. It defaults to the external
+	// address of the gnmi service of the node.
+	Address string
+}
+
+// hasService returns true if the node has a service named name.
+func (n *Impl) hasService(name string) bool {
+	for _, s := range n.Proto.GetServices() {
+		if s.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GNMIAddress returns the external address of the gnmi service of the node.
+func (n *Impl) GNMIAddress(ctx context.Context) (string, error) {
+	var port uint32
+	for _, s := range n.Proto.GetServices() {
+		if s.GetName() == GNMIService {
+			port = s.GetInside()
+		}
+	}
+	if port == 0 {
+		return "", fmt.Errorf("node %q has no %s service", n.Name(), GNMIService)
+	}
+	svcs, err := n.Services(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range svcs {
+		if len(s.Status.LoadBalancer.Ingress) == 0 {
+			continue
+		}
+		return net.JoinHostPort(s.Status.LoadBalancer.Ingress[0].IP, strconv.Itoa(int(port))), nil
+	}
+	return "", fmt.Errorf("node %q has no external loadbalancer configured", n.Name())
+}
+
+// gnmiCredentials returns the transport credentials for the gNMI server of
+// the node. Nodes with a CA signed cert are verified with the CA bundle ca.
+// Nodes with other certs serve TLS which is not verified, as self-signed
+// certs cannot be, and nodes without a cert serve plaintext.
+func gnmiCredentials(pb *tpb.Node, ca []byte) (credentials.TransportCredentials, error) {
+	switch {
+	case pb.GetConfig().GetCert() == nil:
+		return insecure.NewCredentials(), nil
+	case pb.GetConfig().GetCert().GetCaSigned() != nil:
+		pool, err := pki.CertPool(ca)
+		if err != nil {
+			return nil, err
+		}
+		return credentials.NewTLS(&tls.Config{RootCAs: pool}), nil
+	}
+	return credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}), nil
+}
+
+// typedValue returns the payload b as a value of the encoding.
+func typedValue(enc gpb.Encoding, b []byte) (*gpb.TypedValue, error) {
+	switch enc {
+	case gpb.Encoding_JSON:
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("payload is not valid JSON")
+		}
+		return &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: b}}, nil
+	case gpb.Encoding_JSON_IETF:
+		if !json.Valid(b) {
+			return nil, fmt.Errorf("payload is not valid JSON")
+		}
+		return &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: b}}, nil
+	case gpb.Encoding_ASCII:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_AsciiVal{AsciiVal: string(b)}}, nil
+	case gpb.Encoding_BYTES:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{BytesVal: b}}, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %v", enc)
+}
+
+// gnmiDial dials the gNMI server of the node and returns the connection and
+// ctx with the credentials of opts.
+func (n *Impl) gnmiDial(ctx context.Context, opts *GNMIOptions) (*grpc.ClientConn, context.Context, error) {
+	addr := opts.Address
+	if addr == "" {
+		var err error
+		if addr, err = n.GNMIAddress(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	var ca []byte
+	if n.Proto.GetConfig().GetCert().GetCaSigned() != nil {
+		var err error
+		if ca, err = pki.Bundle(ctx, n.KubeClient, n.Namespace); err != nil {
+			return nil, nil, err
+		}
+	}
+	creds, err := gnmiCredentials(n.Proto, ca)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Username != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "username", opts.Username, "password", opts.Password)
+	}
+	return conn, ctx, nil
+}
+
+// gnmiSupportsEncoding checks the capabilities of the gNMI server of conn and
+// returns an Unimplemented error if the server does not implement gNMI or
+// does not support the encoding enc.
+func gnmiSupportsEncoding(ctx context.Context, name string, conn *grpc.ClientConn, enc gpb.Encoding) error {
+	resp, err := gpb.NewGNMIClient(conn).Capabilities(ctx, &gpb.CapabilityRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return status.Errorf(codes.Unimplemented, "%s - gNMI not supported: %v", name, status.Convert(err).Message())
+		}
+		return fmt.Errorf("%s - gNMI Capabilities failed: %w", name, err)
+	}
+	for _, e := range resp.GetSupportedEncodings() {
+		if e == enc {
+			return nil
+		}
+	}
+	return status.Errorf(codes.Unimplemented, "%s - gNMI encoding %v not supported, supported encodings: %v", name, enc, resp.GetSupportedEncodings())
+}
+
+// GNMIConfigPush replaces the config of the node with the payload read from r
+// using gNMI Set. The capabilities of the gNMI server are checked first and
+// an error with code Unimplemented is returned if the node has no gNMI
+// service or does not support the encoding of opts.
+func (n *Impl) GNMIConfigPush(ctx context.Context, r io.Reader, opts *GNMIOptions) error {
+	if opts == nil {
+		opts = &GNMIOptions{Encoding: gpb.Encoding_JSON_IETF}
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	tv, err := typedValue(opts.Encoding, b)
+	if err != nil {
+		return err
+	}
+	if opts.Address == "" && !n.hasService(GNMIService) {
+		return status.Errorf(codes.Unimplemented, "node %q has no %s service", n.Name(), GNMIService)
+	}
+	conn, ctx, err := n.gnmiDial(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := gnmiSupportsEncoding(ctx, n.Name(), conn, opts.Encoding); err != nil {
+		return err
+	}
+	log.Infof("%s - pushing config with gNMI to %s", n.Name(), conn.Target())
+	if _, err := gpb.NewGNMIClient(conn).Set(ctx, &gpb.SetRequest{
+		Replace: []*gpb.Update{{
+			Path: &gpb.Path{Origin: opts.Origin},
+			Val:  tv,
+		}},
+	}); err != nil {
+		return fmt.Errorf("%s - gNMI Set failed: %w", n.Name(), err)
+	}
+	log.Infof("%s - finished config push", n.Name())
+	return nil
+}
+
+// lldpNeighborsPath is the OpenConfig path of the state of the LLDP neighbors
+// of all interfaces.
+var lldpNeighborsPath = &gpb.Path{Elem: []*gpb.PathElem{
+	{Name: "lldp"},
+	{Name: "interfaces"},
+	{Name: "interface", Key: map[string]string{"name": "*"}},
+	{Name: "neighbors"},
+	{Name: "neighbor", Key: map[string]string{"id": "*"}},
+	{Name: "state"},
+}}
+
+// GNMILLDPNeighbors returns the LLDP neighbors of the node from the
+// OpenConfig LLDP state using gNMI Get. The encoding of opts is ignored. If
+// the node has no gnmi service and opts has no address then
+// status.Unimplemented will be returned.
+func (n *Impl) GNMILLDPNeighbors(ctx context.Context, opts *GNMIOptions) ([]*LLDPNeighbor, error) {
+	if opts == nil {
+		opts = &GNMIOptions{}
+	}
+	if opts.Address == "" && !n.hasService(GNMIService) {
+		return nil, status.Errorf(codes.Unimplemented, "node %q has no %s service", n.Name(), GNMIService)
+	}
+	conn, ctx, err := n.gnmiDial(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	log.Infof("%s - getting LLDP neighbors with gNMI from %s", n.Name(), conn.Target())
+	resp, err := gpb.NewGNMIClient(conn).Get(ctx, &gpb.GetRequest{
+		Path:     []*gpb.Path{lldpNeighborsPath},
+		Type:     gpb.GetRequest_STATE,
+		Encoding: gpb.Encoding_JSON_IETF,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s - gNMI Get failed: %w", n.Name(), err)
+	}
+	return lldpNeighbors(resp.GetNotification())
+}
+
+// lldpNeighbors returns the neighbors of the updates of the LLDP neighbor
+// state in ns. The state is either updated as a JSON object or leaf by leaf.
+func lldpNeighbors(ns []*gpb.Notification) ([]*LLDPNeighbor, error) {
+	type key struct{ intf, id string }
+	var keys []key
+	nbs := map[key]*LLDPNeighbor{}
+	for _, notif := range ns {
+		for _, u := range notif.GetUpdate() {
+			elems := append(append([]*gpb.PathElem{}, notif.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+			var k key
+			leaf := ""
+			for _, e := range elems {
+				switch e.GetName() {
+				case "interface":
+					k.intf = e.GetKey()["name"]
+				case "neighbor":
+					k.id = e.GetKey()["id"]
+				}
+				leaf = e.GetName()
+			}
+			if k.intf == "" {
+				continue
+			}
+			nb, ok := nbs[k]
+			if !ok {
+				nb = &LLDPNeighbor{Interface: k.intf}
+				nbs[k] = nb
+				keys = append(keys, k)
+			}
+			leaves := map[string]string{}
+			if b := jsonValue(u.GetVal()); b != nil {
+				var v interface{}
+				if err := json.Unmarshal(b, &v); err != nil {
+					return nil, fmt.Errorf("invalid LLDP neighbor state of interface %s: %w", k.intf, err)
+				}
+				switch v := v.(type) {
+				case map[string]interface{}:
+					for name, lv := range v {
+						if s, ok := lv.(string); ok {
+							leaves[name[strings.LastIndex(name, ":")+1:]] = s
+						}
+					}
+				case string:
+					leaves[leaf] = v
+				}
+			} else {
+				leaves[leaf] = u.GetVal().GetStringVal()
+			}
+			if s, ok := leaves["system-name"]; ok {
+				nb.SystemName = s
+			}
+			if s, ok := leaves["port-id"]; ok {
+				nb.PortID = s
+			}
+		}
+	}
+	var out []*LLDPNeighbor
+	for _, k := range keys {
+		out = append(out, nbs[k])
+	}
+	return out, nil
+}
+
+// jsonValue returns the JSON of tv, or nil if tv is not JSON encoded.
+func jsonValue(tv *gpb.TypedValue) []byte {
+	switch v := tv.GetValue().(type) {
+	case *gpb.TypedValue_JsonIetfVal:
+		return v.JsonIetfVal
+	case *gpb.TypedValue_JsonVal:
+		return v.JsonVal
+	}
+	return nil
+}
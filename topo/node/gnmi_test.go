@@ -0,0 +1,330 @@
+package node
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	topopb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/pki"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeGNMI struct {
+	gpb.UnimplementedGNMIServer
+	req     *gpb.SetRequest
+	user    string
+	getResp *gpb.GetResponse
+	encs    []gpb.Encoding
+}
+
+func (f *fakeGNMI) Capabilities(context.Context, *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	if f.encs == nil {
+		return nil, status.Errorf(codes.Unimplemented, "method Capabilities not implemented")
+	}
+	return &gpb.CapabilityResponse{SupportedEncodings: f.encs}, nil
+}
+
+func (f *fakeGNMI) Get(context.Context, *gpb.GetRequest) (*gpb.GetResponse, error) {
+	return f.getResp, nil
+}
+
+func (f *fakeGNMI) Set(ctx context.Context, req *gpb.SetRequest) (*gpb.SetResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["username"]) != 0 {
+		f.user = md["username"][0]
+	}
+	if req.GetReplace()[0].GetVal().GetAsciiVal() == "fail" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config")
+	}
+	f.req = req
+	return &gpb.SetResponse{}, nil
+}
+
+func TestGNMIConfigPush(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	f := &fakeGNMI{}
+	s := grpc.NewServer()
+	gpb.RegisterGNMIServer(s, f)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	allEncs := []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_ASCII}
+	tests := []struct {
+		desc     string
+		config   string
+		opts     *GNMIOptions
+		services map[uint32]*topopb.Service
+		encs     []gpb.Encoding
+		noCaps   bool
+		want     *gpb.SetRequest
+		wantUser string
+		wantErr  string
+		wantCode codes.Code
+	}{{
+		desc:   "openconfig json",
+		config: `{"openconfig-system:system": {"config": {"hostname": "r1"}}}`,
+		opts: &GNMIOptions{
+			Encoding: gpb.Encoding_JSON_IETF,
+			Username: "admin",
+			Password: "admin",
+		},
+		want: &gpb.SetRequest{
+			Replace: []*gpb.Update{{
+				Path: &gpb.Path{},
+				Val: &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{
+					JsonIetfVal: []byte(`{"openconfig-system:system": {"config": {"hostname": "r1"}}}`),
+				}},
+			}},
+		},
+		wantUser: "admin",
+	}, {
+		desc:   "vendor cli",
+		config: "hostname r1\n",
+		opts: &GNMIOptions{
+			Encoding: gpb.Encoding_ASCII,
+			Origin:   "cli",
+		},
+		want: &gpb.SetRequest{
+			Replace: []*gpb.Update{{
+				Path: &gpb.Path{Origin: "cli"},
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_AsciiVal{AsciiVal: "hostname r1\n"}},
+			}},
+		},
+	}, {
+		desc:    "invalid json",
+		config:  "hostname r1",
+		opts:    &GNMIOptions{Encoding: gpb.Encoding_JSON_IETF},
+		wantErr: "not valid JSON",
+	}, {
+		desc:    "unsupported encoding",
+		config:  "hostname r1",
+		opts:    &GNMIOptions{Encoding: gpb.Encoding_PROTO},
+		wantErr: "unsupported encoding",
+	}, {
+		desc:    "set failure",
+		config:  "fail",
+		opts:    &GNMIOptions{Encoding: gpb.Encoding_ASCII},
+		wantErr: "invalid config",
+	}, {
+		desc:     "encoding not supported by node",
+		config:   "hostname r1\n",
+		opts:     &GNMIOptions{Encoding: gpb.Encoding_ASCII},
+		encs:     []gpb.Encoding{gpb.Encoding_JSON_IETF},
+		wantErr:  "encoding ASCII not supported",
+		wantCode: codes.Unimplemented,
+	}, {
+		desc:     "gnmi not supported by node",
+		config:   "hostname r1\n",
+		opts:     &GNMIOptions{Encoding: gpb.Encoding_ASCII},
+		noCaps:   true,
+		wantErr:  "gNMI not supported",
+		wantCode: codes.Unimplemented,
+	}, {
+		desc:     "no gnmi service",
+		config:   "hostname r1\n",
+		opts:     &GNMIOptions{Encoding: gpb.Encoding_ASCII},
+		services: map[uint32]*topopb.Service{22: {Name: "ssh", Inside: 22}},
+		wantErr:  "has no gnmi service",
+		wantCode: codes.Unimplemented,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f.req, f.user, f.encs = nil, "", allEncs
+			switch {
+			case tt.noCaps:
+				f.encs = nil
+			case tt.encs != nil:
+				f.encs = tt.encs
+			}
+			if tt.services == nil {
+				tt.opts.Address = lis.Addr().String()
+			}
+			n := &Impl{Proto: &topopb.Node{Name: "r1", Services: tt.services}}
+			err := n.GNMIConfigPush(context.Background(), strings.NewReader(tt.config), tt.opts)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GNMIConfigPush() unexpected error: %s", s)
+			}
+			if tt.wantCode != codes.OK && status.Code(err) != tt.wantCode {
+				t.Fatalf("GNMIConfigPush() got code %v, want %v", status.Code(err), tt.wantCode)
+			}
+			if s := cmp.Diff(tt.want, f.req, protocmp.Transform()); s != "" {
+				t.Errorf("GNMIConfigPush() unexpected request (-want +got):\n%s", s)
+			}
+			if f.user != tt.wantUser {
+				t.Errorf("GNMIConfigPush() got username %q, want %q", f.user, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestGNMIAddress(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "service-r1", Namespace: "test"},
+	}
+	lbSvc := svc.DeepCopy()
+	lbSvc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.168.18.100"}}
+	gnmiServices := map[uint32]*topopb.Service{
+		6030: {Name: "gnmi", Inside: 6030},
+		22:   {Name: "ssh", Inside: 22},
+	}
+	tests := []struct {
+		desc     string
+		services map[uint32]*topopb.Service
+		svc      *corev1.Service
+		want     string
+		wantErr  string
+	}{{
+		desc:     "success",
+		services: gnmiServices,
+		svc:      lbSvc,
+		want:     "192.168.18.100:6030",
+	}, {
+		desc:     "no gnmi service",
+		services: map[uint32]*topopb.Service{22: {Name: "ssh", Inside: 22}},
+		svc:      lbSvc,
+		wantErr:  "no gnmi service",
+	}, {
+		desc:     "no loadbalancer",
+		services: gnmiServices,
+		svc:      svc,
+		wantErr:  "no external loadbalancer",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := &Impl{
+				Namespace:  "test",
+				KubeClient: kfake.NewSimpleClientset(tt.svc),
+				Proto:      &topopb.Node{Name: "r1", Services: tt.services},
+			}
+			got, err := n.GNMIAddress(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("GNMIAddress() unexpected error: %s", s)
+			}
+			if got != tt.want {
+				t.Errorf("GNMIAddress() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGNMICredentials(t *testing.T) {
+	ca, err := pki.NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	selfSigned := &topopb.CertificateCfg{
+		Config: &topopb.CertificateCfg_SelfSigned{SelfSigned: &topopb.SelfSignedCertCfg{CertName: "gnmiCert"}},
+	}
+	caSigned := &topopb.CertificateCfg{
+		Config: &topopb.CertificateCfg_CaSigned{CaSigned: &topopb.CASignedCertCfg{CertName: "gnmiCert"}},
+	}
+	tests := []struct {
+		desc     string
+		cert     *topopb.CertificateCfg
+		ca       []byte
+		want     string
+		wantSkip bool
+		wantErr  string
+	}{{
+		desc: "no cert",
+		want: "insecure",
+	}, {
+		desc:     "self signed",
+		cert:     selfSigned,
+		want:     "tls",
+		wantSkip: true,
+	}, {
+		desc: "ca signed",
+		cert: caSigned,
+		ca:   ca.CertPEM(),
+		want: "tls",
+	}, {
+		desc:    "ca signed without bundle",
+		cert:    caSigned,
+		wantErr: "no certificates found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := gnmiCredentials(&topopb.Node{Config: &topopb.Config{Cert: tt.cert}}, tt.ca)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("gnmiCredentials() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if p := got.Info().SecurityProtocol; p != tt.want {
+				t.Errorf("gnmiCredentials() got %q, want %q", p, tt.want)
+			}
+		})
+	}
+}
+
+func lldpPath(intf, id string, leaves ...string) *gpb.Path {
+	p := &gpb.Path{Elem: []*gpb.PathElem{
+		{Name: "lldp"},
+		{Name: "interfaces"},
+		{Name: "interface", Key: map[string]string{"name": intf}},
+		{Name: "neighbors"},
+		{Name: "neighbor", Key: map[string]string{"id": id}},
+		{Name: "state"},
+	}}
+	for _, l := range leaves {
+		p.Elem = append(p.Elem, &gpb.PathElem{Name: l})
+	}
+	return p
+}
+
+func TestGNMILLDPNeighbors(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	f := &fakeGNMI{getResp: &gpb.GetResponse{Notification: []*gpb.Notification{{
+		Update: []*gpb.Update{{
+			Path: lldpPath("Ethernet1", "1"),
+			Val: &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{
+				JsonIetfVal: []byte(`{"openconfig-lldp:system-name": "r2", "openconfig-lldp:port-id": "Ethernet1", "openconfig-lldp:ttl": 120}`),
+			}},
+		}, {
+			Path: lldpPath("Ethernet2", "2", "system-name"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "r3"}},
+		}, {
+			Path: lldpPath("Ethernet2", "2", "port-id"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`"ethernet-1/1"`)}},
+		}},
+	}}}}
+	s := grpc.NewServer()
+	gpb.RegisterGNMIServer(s, f)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	n := &Impl{Proto: &topopb.Node{Name: "r1"}}
+	got, err := n.GNMILLDPNeighbors(context.Background(), &GNMIOptions{Address: lis.Addr().String()})
+	if err != nil {
+		t.Fatalf("GNMILLDPNeighbors() failed: %v", err)
+	}
+	want := []*LLDPNeighbor{
+		{Interface: "Ethernet1", SystemName: "r2", PortID: "Ethernet1"},
+		{Interface: "Ethernet2", SystemName: "r3", PortID: "ethernet-1/1"},
+	}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Errorf("GNMILLDPNeighbors() unexpected neighbors (-want +got):\n%s", s)
+	}
+	if _, err := n.GNMILLDPNeighbors(context.Background(), nil); status.Code(err) != codes.Unimplemented {
+		t.Errorf("GNMILLDPNeighbors() of node without gnmi service got error %v, want code %v", err, codes.Unimplemented)
+	}
+}
@@ -15,9 +15,13 @@ package gobgp
 
 import (
 	"fmt"
+	"net"
+	"sort"
 
+	"github.com/ghodss/yaml"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 )
 
 func New(nodeImpl *node.Impl) (node.Node, error) {
@@ -27,7 +31,20 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	if nodeImpl.Proto == nil {
 		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
 	}
-	defaults(nodeImpl.Proto)
+	pb := nodeImpl.Proto
+	defaults(pb)
+	if pb.Config.GetBgp() != nil && pb.Config.GetConfigData() == nil {
+		b, err := config(pb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate config of node %s: %w", pb.Name, err)
+		}
+		pb.Config.ConfigData = &tpb.Config_Data{Data: b}
+		// The neighbors of the generated config are only reachable once the
+		// addresses of the interfaces are configured.
+		if pb.Config.Network == nil {
+			pb.Config.Network = &tpb.NetworkConfig{}
+		}
+	}
 	n := &Node{
 		Impl: nodeImpl,
 	}
@@ -38,26 +55,114 @@ type Node struct {
 	*node.Impl
 }
 
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the GOBGP vendor profile.
 func defaults(pb *tpb.Node) *tpb.Node {
-	if pb.Config == nil {
-		pb.Config = &tpb.Config{}
+	profile.Embedded().Fill(tpb.Vendor_GOBGP, pb)
+	if pb.Config.EntryCommand == "" {
+		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- /bin/bash", pb.Name)
 	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "hfam/gobgp:latest"
+	return pb
+}
+
+// gobgpConfig is the part of the gobgpd config file generated from the
+// topology.
+type gobgpConfig struct {
+	Global    global     `json:"global"`
+	Neighbors []neighbor `json:"neighbors,omitempty"`
+}
+
+type global struct {
+	Config struct {
+		AS       uint32 `json:"as"`
+		RouterID string `json:"router-id"`
+	} `json:"config"`
+	ApplyPolicy struct {
+		Config struct {
+			DefaultImportPolicy string `json:"default-import-policy"`
+			DefaultExportPolicy string `json:"default-export-policy"`
+		} `json:"config"`
+	} `json:"apply-policy"`
+}
+
+type neighbor struct {
+	Config struct {
+		NeighborAddress string `json:"neighbor-address"`
+		PeerAS          uint32 `json:"peer-as"`
+		Description     string `json:"description"`
+	} `json:"config"`
+	AfiSafis []afiSafi `json:"afi-safis"`
+}
+
+type afiSafi struct {
+	Config struct {
+		AfiSafiName string `json:"afi-safi-name"`
+	} `json:"config"`
+}
+
+var policies = map[tpb.BGPConfig_Policy]string{
+	tpb.BGPConfig_ACCEPT: "accept-route",
+	tpb.BGPConfig_REJECT: "reject-route",
+}
+
+// config returns the gobgpd config of the BGP parameters of pb. A neighbor is
+// configured for each peer address in the subnet of an address of the
+// interface connected to the peer.
+func config(pb *tpb.Node) ([]byte, error) {
+	bgp := pb.GetConfig().GetBgp()
+	if bgp.GetAs() == 0 {
+		return nil, fmt.Errorf("bgp as must be set")
 	}
-	if len(pb.GetConfig().GetCommand()) == 0 {
-		pb.Config.Command = []string{"/usr/local/bin/gobgpd", "-f", "/gobgp.conf", "-t", "yaml"}
+	c := &gobgpConfig{}
+	c.Global.Config.AS = bgp.GetAs()
+	c.Global.ApplyPolicy.Config.DefaultImportPolicy = policies[bgp.GetDefaultImportPolicy()]
+	c.Global.ApplyPolicy.Config.DefaultExportPolicy = policies[bgp.GetDefaultExportPolicy()]
+	var names []string
+	for name := range pb.GetInterfaces() {
+		names = append(names, name)
 	}
-	if pb.Config.EntryCommand == "" {
-		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- /bin/bash", pb.Name)
+	sort.Strings(names)
+	var routerID net.IP
+	for _, name := range names {
+		intf := pb.GetInterfaces()[name]
+		for _, a := range intf.GetAddresses() {
+			ip, _, err := net.ParseCIDR(a)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q of interface %s: %w", a, name, err)
+			}
+			if routerID == nil && ip.To4() != nil {
+				routerID = ip
+			}
+		}
+		ips, err := node.NeighborAddresses(intf)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %w", name, err)
+		}
+		for _, ip := range ips {
+			nb := neighbor{AfiSafis: []afiSafi{{}}}
+			nb.Config.NeighborAddress = ip.String()
+			nb.Config.PeerAS = bgp.GetAs()
+			if as, ok := bgp.GetPeerAs()[intf.GetPeerName()]; ok {
+				nb.Config.PeerAS = as
+			}
+			nb.Config.Description = intf.GetPeerName()
+			nb.AfiSafis[0].Config.AfiSafiName = "ipv4-unicast"
+			if ip.To4() == nil {
+				nb.AfiSafis[0].Config.AfiSafiName = "ipv6-unicast"
+			}
+			c.Neighbors = append(c.Neighbors, nb)
+		}
 	}
-	if pb.Config.ConfigPath == "" {
-		pb.Config.ConfigPath = "/"
+	if id := bgp.GetRouterId(); id != "" {
+		if routerID = net.ParseIP(id); routerID.To4() == nil {
+			return nil, fmt.Errorf("invalid bgp router id %q", id)
+		}
 	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "gobgp.conf"
+	if routerID == nil {
+		return nil, fmt.Errorf("bgp router id must be set if no interface has an IPv4 address")
 	}
-	return pb
+	c.Global.Config.RouterID = routerID.String()
+	return yaml.Marshal(c)
 }
 
 func init() {
@@ -62,6 +62,96 @@ func TestNew(t *testing.T) {
 				ConfigFile:   "gobgp.conf",
 			},
 		},
+	}, {
+		desc: "generated config",
+		ni: &node.Impl{
+			Proto: &topopb.Node{
+				Name: "test_node",
+				Config: &topopb.Config{
+					Bgp: &topopb.BGPConfig{As: 65001, RouterId: "1.1.1.1"},
+				},
+			},
+		},
+		wantPB: &topopb.Node{
+			Name: "test_node",
+			Config: &topopb.Config{
+				Image:        "hfam/gobgp:latest",
+				Command:      []string{"/usr/local/bin/gobgpd", "-f", "/gobgp.conf", "-t", "yaml"},
+				EntryCommand: "kubectl exec -it test_node -- /bin/bash",
+				ConfigPath:   "/",
+				ConfigFile:   "gobgp.conf",
+				Bgp:          &topopb.BGPConfig{As: 65001, RouterId: "1.1.1.1"},
+				Network:      &topopb.NetworkConfig{},
+				ConfigData: &topopb.Config_Data{
+					Data: []byte("global:\n  apply-policy:\n    config:\n      default-export-policy: accept-route\n      default-import-policy: accept-route\n  config:\n    as: 65001\n    router-id: 1.1.1.1\n"),
+				},
+			},
+		},
+	}, {
+		desc: "generated config with addresses",
+		ni: &node.Impl{
+			Proto: &topopb.Node{
+				Name: "test_node",
+				Config: &topopb.Config{
+					Bgp: &topopb.BGPConfig{As: 65001},
+				},
+				Interfaces: map[string]*topopb.Interface{
+					"eth2": {Addresses: []string{"192.168.1.0/31"}},
+					"eth1": {Addresses: []string{"192.168.0.0/31", "2001:db8::/127"}},
+				},
+			},
+		},
+		wantPB: &topopb.Node{
+			Name: "test_node",
+			Config: &topopb.Config{
+				Image:        "hfam/gobgp:latest",
+				Command:      []string{"/usr/local/bin/gobgpd", "-f", "/gobgp.conf", "-t", "yaml"},
+				EntryCommand: "kubectl exec -it test_node -- /bin/bash",
+				ConfigPath:   "/",
+				ConfigFile:   "gobgp.conf",
+				Bgp:          &topopb.BGPConfig{As: 65001},
+				Network:      &topopb.NetworkConfig{},
+				ConfigData: &topopb.Config_Data{
+					Data: []byte("global:\n  apply-policy:\n    config:\n      default-export-policy: accept-route\n      default-import-policy: accept-route\n  config:\n    as: 65001\n    router-id: 192.168.0.0\n"),
+				},
+			},
+			Interfaces: map[string]*topopb.Interface{
+				"eth2": {Addresses: []string{"192.168.1.0/31"}},
+				"eth1": {Addresses: []string{"192.168.0.0/31", "2001:db8::/127"}},
+			},
+		},
+	}, {
+		desc: "config file set",
+		ni: &node.Impl{
+			Proto: &topopb.Node{
+				Name: "test_node",
+				Config: &topopb.Config{
+					Bgp:        &topopb.BGPConfig{},
+					ConfigData: &topopb.Config_File{File: "gobgp.yaml"},
+				},
+			},
+		},
+		wantPB: &topopb.Node{
+			Name: "test_node",
+			Config: &topopb.Config{
+				Image:        "hfam/gobgp:latest",
+				Command:      []string{"/usr/local/bin/gobgpd", "-f", "/gobgp.conf", "-t", "yaml"},
+				EntryCommand: "kubectl exec -it test_node -- /bin/bash",
+				ConfigPath:   "/",
+				ConfigFile:   "gobgp.conf",
+				Bgp:          &topopb.BGPConfig{},
+				ConfigData:   &topopb.Config_File{File: "gobgp.yaml"},
+			},
+		},
+	}, {
+		desc: "invalid bgp",
+		ni: &node.Impl{
+			Proto: &topopb.Node{
+				Name:   "test_node",
+				Config: &topopb.Config{Bgp: &topopb.BGPConfig{}},
+			},
+		},
+		wantErr: "failed to generate config of node test_node: bgp as must be set",
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -78,3 +168,116 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pb      *topopb.Node
+		want    string
+		wantErr string
+	}{{
+		desc: "neighbors",
+		pb: &topopb.Node{
+			Name: "r1",
+			Config: &topopb.Config{
+				Bgp: &topopb.BGPConfig{
+					As:                  65001,
+					PeerAs:              map[string]uint32{"r2": 65002},
+					DefaultExportPolicy: topopb.BGPConfig_REJECT,
+				},
+			},
+			Interfaces: map[string]*topopb.Interface{
+				"eth1": {
+					PeerName:      "r2",
+					Addresses:     []string{"192.168.0.0/31", "2001:db8::/127"},
+					PeerAddresses: []string{"192.168.0.1/31", "2001:db8::1/127"},
+				},
+				"eth2": {
+					PeerName:      "r3",
+					Addresses:     []string{"192.168.1.0/31"},
+					PeerAddresses: []string{"192.168.1.1/31", "10.0.0.1/24"},
+				},
+				"eth3": {
+					PeerName: "h1",
+				},
+			},
+		},
+		want: `global:
+  apply-policy:
+    config:
+      default-export-policy: reject-route
+      default-import-policy: accept-route
+  config:
+    as: 65001
+    router-id: 192.168.0.0
+neighbors:
+- afi-safis:
+  - config:
+      afi-safi-name: ipv4-unicast
+  config:
+    description: r2
+    neighbor-address: 192.168.0.1
+    peer-as: 65002
+- afi-safis:
+  - config:
+      afi-safi-name: ipv6-unicast
+  config:
+    description: r2
+    neighbor-address: 2001:db8::1
+    peer-as: 65002
+- afi-safis:
+  - config:
+      afi-safi-name: ipv4-unicast
+  config:
+    description: r3
+    neighbor-address: 192.168.1.1
+    peer-as: 65001
+`,
+	}, {
+		desc: "router id",
+		pb: &topopb.Node{
+			Name:   "r1",
+			Config: &topopb.Config{Bgp: &topopb.BGPConfig{As: 65001, RouterId: "1.1.1.1"}},
+		},
+		want: `global:
+  apply-policy:
+    config:
+      default-export-policy: accept-route
+      default-import-policy: accept-route
+  config:
+    as: 65001
+    router-id: 1.1.1.1
+`,
+	}, {
+		desc:    "missing as",
+		pb:      &topopb.Node{Name: "r1", Config: &topopb.Config{Bgp: &topopb.BGPConfig{RouterId: "1.1.1.1"}}},
+		wantErr: "bgp as must be set",
+	}, {
+		desc:    "missing router id",
+		pb:      &topopb.Node{Name: "r1", Config: &topopb.Config{Bgp: &topopb.BGPConfig{As: 65001}}},
+		wantErr: "router id must be set",
+	}, {
+		desc:    "invalid router id",
+		pb:      &topopb.Node{Name: "r1", Config: &topopb.Config{Bgp: &topopb.BGPConfig{As: 65001, RouterId: "2001:db8::1"}}},
+		wantErr: "invalid bgp router id",
+	}, {
+		desc: "invalid address",
+		pb: &topopb.Node{
+			Name:       "r1",
+			Config:     &topopb.Config{Bgp: &topopb.BGPConfig{As: 65001}},
+			Interfaces: map[string]*topopb.Interface{"eth1": {Addresses: []string{"192.168.0.0"}}},
+		},
+		wantErr: `invalid address "192.168.0.0" of interface eth1`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := config(tt.pb)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("config() unexpected error: %s", s)
+			}
+			if string(got) != tt.want {
+				t.Errorf("config() got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,10 +14,18 @@
 package host
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
+	log "github.com/sirupsen/logrus"
+	utilexec "k8s.io/utils/exec"
 )
 
 func New(nodeImpl *node.Impl) (node.Node, error) {
@@ -29,6 +37,9 @@ func New(nodeImpl *node.Impl) (node.Node, error) {
 	}
 	cfg := defaults(nodeImpl.Proto)
 	nodeImpl.Proto = cfg
+	if _, err := node.NetworkCommands(cfg); err != nil {
+		return nil, fmt.Errorf("invalid network config of node %s: %w", cfg.Name, err)
+	}
 	n := &Node{
 		Impl: nodeImpl,
 	}
@@ -39,24 +50,66 @@ type Node struct {
 	*node.Impl
 }
 
-func defaults(pb *tpb.Node) *tpb.Node {
-	if pb.Config == nil {
-		pb.Config = &tpb.Config{}
+var (
+	_ node.ConfigPusher = (*Node)(nil)
+	_ node.Resetter     = (*Node)(nil)
+)
+
+// ConfigPush runs the config as a shell script in the node container.
+func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
+	log.Infof("%s - pushing config", n.Name())
+	if err := runScript(ctx, n, r); err != nil {
+		return err
 	}
-	if len(pb.GetConfig().GetCommand()) == 0 {
-		pb.Config.Command = []string{"/bin/sh", "-c", "sleep 2000000000000"}
+	log.Infof("%s - finished config push", n.Name())
+	return nil
+}
+
+// ResetCfg reverts the interfaces of the node to the addresses, VLANs and
+// routes configured by KNE, removing those added by pushed scripts.
+func (n *Node) ResetCfg(ctx context.Context) error {
+	log.Infof("%s - resetting config", n.Name())
+	cmds, err := node.NetworkResetCommands(n.Proto)
+	if err != nil {
+		return err
 	}
-	if pb.Config.EntryCommand == "" {
-		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- sh", pb.Name)
+	if err := runScript(ctx, n, strings.NewReader("set -e\n"+strings.Join(cmds, "\n")+"\n")); err != nil {
+		return err
+	}
+	log.Infof("%s - finished resetting config", n.Name())
+	return nil
+}
+
+// runScript runs the shell script r in the node container and logs its
+// output. The stderr and exit status of a failed script are returned.
+func runScript(ctx context.Context, e node.Execer, r io.Reader) error {
+	var stdout, stderr bytes.Buffer
+	err := e.ExecWithOptions(ctx, []string{"/bin/sh", "-s"}, &node.ExecOptions{Stdin: r, Stdout: &stdout, Stderr: &stderr})
+	if out := strings.TrimSpace(stdout.String()); out != "" {
+		log.Infof("stdout:\n%s", out)
+	}
+	if out := strings.TrimSpace(stderr.String()); out != "" {
+		log.Infof("stderr:\n%s", out)
 	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "alpine:latest"
+	if err == nil {
+		return nil
 	}
-	if pb.Config.ConfigPath == "" {
-		pb.Config.ConfigPath = "/etc"
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("script failed with exit status %d: %s", exitErr.ExitStatus(), strings.TrimSpace(stderr.String()))
+	}
+	return fmt.Errorf("failed to run script: %w", err)
+}
+
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the HOST vendor profile.
+func defaults(pb *tpb.Node) *tpb.Node {
+	profile.Embedded().Fill(tpb.Vendor_HOST, pb)
+	if pb.Config.EntryCommand == "" {
+		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- sh", pb.Name)
 	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "config"
+	if pb.Config.Network == nil {
+		pb.Config.Network = &tpb.NetworkConfig{}
 	}
 	return pb
 }
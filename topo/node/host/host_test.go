@@ -1,7 +1,10 @@
 package host
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/h-fam/errdiff"
@@ -9,6 +12,7 @@ import (
 	"github.com/openconfig/kne/topo/node"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
+	utilexec "k8s.io/utils/exec"
 )
 
 func TestNew(t *testing.T) {
@@ -36,6 +40,7 @@ func TestNew(t *testing.T) {
 				Image:        "alpine:latest",
 				ConfigPath:   "/etc",
 				ConfigFile:   "config",
+				Network:      &topopb.NetworkConfig{},
 			},
 		},
 	}, {
@@ -63,6 +68,7 @@ func TestNew(t *testing.T) {
 				Image:        "alpine:latest",
 				ConfigPath:   "/etc",
 				ConfigFile:   "config",
+				Network:      &topopb.NetworkConfig{},
 			},
 			Services: map[uint32]*topopb.Service{
 				2000: {
@@ -90,8 +96,20 @@ func TestNew(t *testing.T) {
 				Image:        "alpine:latest",
 				ConfigPath:   "/etc",
 				ConfigFile:   "config",
+				Network:      &topopb.NetworkConfig{},
 			},
 		},
+	}, {
+		desc: "invalid default gateway",
+		nImpl: &node.Impl{
+			Proto: &topopb.Node{
+				Name: "h1",
+				Config: &topopb.Config{
+					Network: &topopb.NetworkConfig{DefaultGateway: "eth1"},
+				},
+			},
+		},
+		wantErr: "default gateway interface eth1 does not exist",
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -108,3 +126,51 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+// scriptExecer records the script instead of running it, writes out to stderr
+// and returns err.
+type scriptExecer struct {
+	script string
+	out    string
+	err    error
+}
+
+func (s *scriptExecer) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	b, err := io.ReadAll(opts.Stdin)
+	if err != nil {
+		return err
+	}
+	s.script = string(b)
+	fmt.Fprint(opts.Stderr, s.out)
+	return s.err
+}
+
+func TestRunScript(t *testing.T) {
+	tests := []struct {
+		desc    string
+		e       *scriptExecer
+		wantErr string
+	}{{
+		desc: "success",
+		e:    &scriptExecer{},
+	}, {
+		desc:    "exit status",
+		e:       &scriptExecer{out: "ip: not found\n", err: utilexec.CodeExitError{Err: fmt.Errorf("exit"), Code: 127}},
+		wantErr: "script failed with exit status 127: ip: not found",
+	}, {
+		desc:    "exec failure",
+		e:       &scriptExecer{err: fmt.Errorf("pod not found")},
+		wantErr: "failed to run script: pod not found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := runScript(context.Background(), tt.e, strings.NewReader("ip addr\n"))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("runScript() unexpected error: %s", s)
+			}
+			if tt.e.script != "ip addr\n" {
+				t.Errorf("runScript() got script %q, want %q", tt.e.script, "ip addr\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package node
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+const networkInitContainerImage = "alpine:latest"
+
+// NeighborAddresses returns the addresses of the peer interface of intf which
+// are in the subnet of an address of intf.
+func NeighborAddresses(intf *tpb.Interface) ([]net.IP, error) {
+	var nets []*net.IPNet
+	for _, a := range intf.GetAddresses() {
+		_, ipNet, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", a, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	var ips []net.IP
+	for _, a := range intf.GetPeerAddresses() {
+		ip, _, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer address %q: %w", a, err)
+		}
+		for _, ipNet := range nets {
+			if ipNet.Contains(ip) {
+				ips = append(ips, ip)
+				break
+			}
+		}
+	}
+	return ips, nil
+}
+
+// linkName returns the Linux device name of the interface intf with the key
+// name, its int_name if set.
+func linkName(name string, intf *tpb.Interface) string {
+	if n := intf.GetIntName(); n != "" {
+		return n
+	}
+	return name
+}
+
+// NetworkCommands returns the commands configuring the interface addresses,
+// VLANs and routes of pb. Interfaces without addresses or VLANs are left
+// untouched, so there are no commands if none of them are set. The commands
+// can be run repeatedly.
+func NetworkCommands(pb *tpb.Node) ([]string, error) {
+	var names []string
+	for name := range pb.GetInterfaces() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var cmds []string
+	for _, name := range names {
+		intf := pb.GetInterfaces()[name]
+		if len(intf.GetAddresses()) == 0 && len(intf.GetVlans()) == 0 {
+			continue
+		}
+		dev := linkName(name, intf)
+		cmds = append(cmds, fmt.Sprintf("ip link set %s up", dev))
+		for _, a := range intf.GetAddresses() {
+			if _, _, err := net.ParseCIDR(a); err != nil {
+				return nil, fmt.Errorf("invalid address %q of interface %s: %w", a, name, err)
+			}
+			cmds = append(cmds, fmt.Sprintf("ip addr replace %s dev %s", a, dev))
+		}
+		for _, v := range intf.GetVlans() {
+			if v.GetId() == 0 || v.GetId() > 4094 {
+				return nil, fmt.Errorf("invalid vlan id %d of interface %s", v.GetId(), name)
+			}
+			sub := fmt.Sprintf("%s.%d", dev, v.GetId())
+			cmds = append(cmds,
+				fmt.Sprintf("(ip link show %s >/dev/null 2>&1 || ip link add link %s name %s type vlan id %d)", sub, dev, sub, v.GetId()),
+				fmt.Sprintf("ip link set %s up", sub),
+			)
+			for _, a := range v.GetAddresses() {
+				if _, _, err := net.ParseCIDR(a); err != nil {
+					return nil, fmt.Errorf("invalid address %q of vlan %s: %w", a, sub, err)
+				}
+				cmds = append(cmds, fmt.Sprintf("ip addr replace %s dev %s", a, sub))
+			}
+		}
+	}
+	network := pb.GetConfig().GetNetwork()
+	for _, r := range network.GetRoutes() {
+		_, prefix, err := net.ParseCIDR(r.GetPrefix())
+		if err != nil {
+			return nil, fmt.Errorf("invalid prefix %q of route: %w", r.GetPrefix(), err)
+		}
+		nh := net.ParseIP(r.GetNextHop())
+		if nh == nil {
+			return nil, fmt.Errorf("invalid next hop %q of route %s", r.GetNextHop(), r.GetPrefix())
+		}
+		if (prefix.IP.To4() == nil) != (nh.To4() == nil) {
+			return nil, fmt.Errorf("next hop %s of route %s is of another address family", nh, prefix)
+		}
+		cmds = append(cmds, fmt.Sprintf("%s route replace %s via %s", ipCommand(nh), prefix, nh))
+	}
+	if gw := network.GetDefaultGateway(); gw != "" {
+		intf, ok := pb.GetInterfaces()[gw]
+		if !ok {
+			return nil, fmt.Errorf("default gateway interface %s does not exist", gw)
+		}
+		nhs, err := NeighborAddresses(intf)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %w", gw, err)
+		}
+		if len(nhs) == 0 {
+			return nil, fmt.Errorf("default gateway interface %s has no peer address in the subnet of its addresses", gw)
+		}
+		seen := map[bool]bool{}
+		for _, nh := range nhs {
+			v4 := nh.To4() != nil
+			if seen[v4] {
+				continue
+			}
+			seen[v4] = true
+			cmds = append(cmds, fmt.Sprintf("%s route replace default via %s dev %s", ipCommand(nh), nh, linkName(gw, intf)))
+		}
+	}
+	return cmds, nil
+}
+
+// NetworkResetCommands returns the commands removing the global addresses,
+// the routes added by users and the VLAN subinterfaces of the interfaces of pb,
+// followed by the network commands of pb.
+func NetworkResetCommands(pb *tpb.Node) ([]string, error) {
+	cmds, err := NetworkCommands(pb)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range pb.GetInterfaces() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var reset []string
+	for _, name := range names {
+		dev := linkName(name, pb.GetInterfaces()[name])
+		reset = append(reset,
+			fmt.Sprintf("for l in /sys/class/net/%s.*; do if [ -e \"$l\" ]; then ip link del \"${l##*/}\"; fi; done", dev),
+			fmt.Sprintf("ip addr flush dev %s scope global", dev),
+			fmt.Sprintf("ip route flush dev %s proto boot", dev),
+			fmt.Sprintf("ip -6 route flush dev %s proto boot", dev),
+		)
+	}
+	return append(reset, cmds...), nil
+}
+
+// ipCommand returns the ip command for routes via ip.
+func ipCommand(ip net.IP) string {
+	if ip.To4() == nil {
+		return "ip -6"
+	}
+	return "ip"
+}
+
+// NetworkInitContainer returns the init container running the network
+// commands of pb, or nil if there are none. It must run after the interfaces
+// of the node are wired.
+func NetworkInitContainer(pb *tpb.Node) (*corev1.Container, error) {
+	cmds, err := NetworkCommands(pb)
+	if err != nil {
+		return nil, err
+	}
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	return &corev1.Container{
+		Name:            fmt.Sprintf("network-%s", pb.Name),
+		Image:           networkInitContainerImage,
+		Command:         []string{"/bin/sh", "-c", strings.Join(cmds, " && ")},
+		ImagePullPolicy: "IfNotPresent",
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+		},
+	}, nil
+}
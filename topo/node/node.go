@@ -1,17 +1,22 @@
 package node
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -55,16 +60,176 @@ type Certer interface {
 	GenerateSelfSigned(context.Context) error
 }
 
+// Certificate is a PEM encoded certificate and key issued for a node with the
+// CA that issued it.
+type Certificate struct {
+	// Name and KeyName are the names of the certificate and key on the node.
+	Name    string
+	KeyName string
+	Cert    []byte
+	Key     []byte
+	CA      []byte
+}
+
+// CertInstaller provides an interface for installing certs issued outside of
+// the node.
+type CertInstaller interface {
+	InstallCert(context.Context, *Certificate) error
+}
+
 // ConfigPusher provides an interface for performing config pushes to the node.
 type ConfigPusher interface {
 	ConfigPush(context.Context, io.Reader) error
 }
 
+// GNMIConfigPusher provides an interface for performing config pushes to the
+// node with gNMI Set.
+type GNMIConfigPusher interface {
+	GNMIConfigPush(ctx context.Context, r io.Reader, opts *GNMIOptions) error
+}
+
 // Resetter provides Reset interface to nodes.
 type Resetter interface {
 	ResetCfg(ctx context.Context) error
 }
 
+// ConfigGetter provides an interface for retrieving the running config of
+// the node. The config is returned in a form that can be pushed back to the
+// node as its Config.file.
+type ConfigGetter interface {
+	ConfigGet(ctx context.Context) ([]byte, error)
+}
+
+// LLDPNeighbor is a neighbor of a node interface learned with LLDP.
+type LLDPNeighbor struct {
+	// Interface is the vendor name of the local interface.
+	Interface string
+	// SystemName is the system name advertised by the neighbor.
+	SystemName string
+	// PortID is the port ID advertised by the neighbor, usually the vendor
+	// name of its interface.
+	PortID string
+}
+
+// LLDPNeighborGetter provides an interface for getting the LLDP neighbors of
+// the node through the vendor CLI.
+type LLDPNeighborGetter interface {
+	LLDPNeighbors(ctx context.Context) ([]*LLDPNeighbor, error)
+}
+
+// GNMILLDPNeighborGetter provides an interface for getting the LLDP neighbors
+// of the node with gNMI Get.
+type GNMILLDPNeighborGetter interface {
+	GNMILLDPNeighbors(ctx context.Context, opts *GNMIOptions) ([]*LLDPNeighbor, error)
+}
+
+// InterfaceMapper provides an interface for mapping the vendor names of the
+// node interfaces, such as those of its LLDP neighbors, to interface keys.
+type InterfaceMapper interface {
+	// InterfaceKey returns the interface key of the vendor interface name
+	// intf, false if intf has no key.
+	InterfaceKey(intf string) (string, bool)
+}
+
+// CLIer provides the command used to start the vendor CLI on the node.
+type CLIer interface {
+	CLICommand() []string
+}
+
+// Execer provides an interface for executing commands in the node container.
+type Execer interface {
+	ExecWithOptions(ctx context.Context, cmd []string, opts *ExecOptions) error
+}
+
+// Collector provides an interface for collecting vendor diagnostics from nodes.
+type Collector interface {
+	// Collect returns the diagnostics of the node keyed by file name.
+	Collect(ctx context.Context) (map[string][]byte, error)
+}
+
+// CollectCommands runs each command in cmds on the node and returns its
+// combined output keyed by the same name. A failed command keeps its partial
+// output followed by the error.
+func CollectCommands(ctx context.Context, e Execer, cmds map[string][]string) map[string][]byte {
+	out := map[string][]byte{}
+	for name, cmd := range cmds {
+		buf := &lockedBuffer{}
+		if err := e.ExecWithOptions(ctx, cmd, &ExecOptions{Stdout: buf, Stderr: buf}); err != nil {
+			fmt.Fprintf(buf, "\n%q failed: %v\n", strings.Join(cmd, " "), err)
+		}
+		out[name] = buf.b.Bytes()
+	}
+	return out
+}
+
+// WriteFile writes b to the file p in the node container, creating its parent
+// directory.
+func WriteFile(ctx context.Context, e Execer, p string, b []byte) error {
+	var stderr bytes.Buffer
+	cmd := []string{"sh", "-c", fmt.Sprintf("mkdir -p %q && cat > %q", path.Dir(p), p)}
+	if err := e.ExecWithOptions(ctx, cmd, &ExecOptions{Stdin: bytes.NewReader(b), Stderr: &stderr}); err != nil {
+		return fmt.Errorf("failed to write %s: %w: %s", p, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// lockedBuffer is a buffer shared by the stdout and stderr streams of an exec.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+// Debugger provides an interface for adding a debug container to the node pod.
+type Debugger interface {
+	DebugContainer(ctx context.Context, image string) (string, error)
+}
+
+// ExecOptions configures the streams and terminal of a command executed
+// in the node container.
+type ExecOptions struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// TTY allocates a terminal for the command. Stderr is merged into Stdout
+	// when a terminal is used.
+	TTY bool
+	// TerminalSizeQueue provides the terminal size when TTY is set.
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+	// Container is the container to execute the command in. It defaults
+	// to the node container.
+	Container string
+}
+
+// DebugContainerName is the name of the ephemeral container added to a node
+// pod by DebugContainer.
+const DebugContainerName = "kne-debug"
+
+// debugContainerTimeout is how long DebugContainer waits for the debug
+// container to be running.
+var debugContainerTimeout = 2 * time.Minute
+
+// CLICommand returns the command to open an interactive session on the node.
+// Nodes implementing CLIer return their vendor CLI, otherwise the command is
+// taken from the node entry command and falls back to sh.
+func CLICommand(n Node) []string {
+	if c, ok := n.(CLIer); ok {
+		return c.CLICommand()
+	}
+	ec := n.GetProto().GetConfig().GetEntryCommand()
+	if i := strings.Index(ec, " -- "); i != -1 {
+		if cmd := strings.Fields(ec[i+len(" -- "):]); len(cmd) != 0 {
+			return cmd
+		}
+	}
+	return []string{"sh"}
+}
+
 // Node is the base interface for all node implementations in KNE.
 type Node interface {
 	Interface
@@ -86,8 +251,17 @@ var (
 	mu          sync.Mutex
 	nodeTypes   = map[tpb.Node_Type]NewNodeFn{}
 	vendorTypes = map[tpb.Vendor]NewNodeFn{}
+	// pluginTypes are the node implementations of plugins, which take
+	// precedence over the registered vendors. An empty model matches all
+	// models of the vendor.
+	pluginTypes = map[pluginKey]NewNodeFn{}
 )
 
+type pluginKey struct {
+	vendor tpb.Vendor
+	model  string
+}
+
 // Register registers the node type with the topology manager.
 func Register(t tpb.Node_Type, fn NewNodeFn) {
 	mu.Lock()
@@ -108,6 +282,19 @@ func Vendor(v tpb.Vendor, fn NewNodeFn) {
 	mu.Unlock()
 }
 
+// RegisterPlugin registers a plugin implementing the model of the vendor, or
+// all its models if model is empty, with the topology manager.
+func RegisterPlugin(v tpb.Vendor, model string, fn NewNodeFn) error {
+	mu.Lock()
+	defer mu.Unlock()
+	k := pluginKey{vendor: v, model: model}
+	if _, ok := pluginTypes[k]; ok {
+		return fmt.Errorf("duplicate plugin registration for vendor %v model %q", v, model)
+	}
+	pluginTypes[k] = fn
+	return nil
+}
+
 // Impl is a topology node in the cluster.
 type Impl struct {
 	Namespace  string
@@ -308,6 +495,15 @@ func (n *Impl) CreatePod(ctx context.Context) error {
 			},
 		},
 	}
+	if pb.Config.Network != nil {
+		c, err := NetworkInitContainer(pb)
+		if err != nil {
+			return fmt.Errorf("invalid network config of node %s: %w", pb.Name, err)
+		}
+		if c != nil {
+			pod.Spec.InitContainers = append(pod.Spec.InitContainers, *c)
+		}
+	}
 	if pb.Config.ConfigData != nil {
 		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
 			Name: "startup-config-volume",
@@ -429,20 +625,32 @@ func (n *Impl) DeleteResource(ctx context.Context) error {
 // Exec will make a connection via spdy transport to the Pod and execute the provided command.
 // It will wire up stdin, stdout, stderr to provided io channels.
 func (n *Impl) Exec(ctx context.Context, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	return n.ExecWithOptions(ctx, cmd, &ExecOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		TTY:    true,
+	})
+}
+
+// ExecWithOptions will make a connection via spdy transport to the Pod and execute the
+// provided command with the streams and terminal settings in opts.
+func (n *Impl) ExecWithOptions(ctx context.Context, cmd []string, opts *ExecOptions) error {
 	req := n.KubeClient.CoreV1().RESTClient().Post().Resource("pods").Name(n.Name()).Namespace(n.Namespace).SubResource("exec")
-	opts := &corev1.PodExecOptions{
-		Command:   cmd,
-		Container: n.Name(),
-		Stdin:     true,
-		Stdout:    true,
-		Stderr:    true,
-		TTY:       true,
+	container := opts.Container
+	if container == "" {
+		container = n.Name()
 	}
-	if stdin == nil {
-		opts.Stdin = false
+	eOpts := &corev1.PodExecOptions{
+		Command:   cmd,
+		Container: container,
+		Stdin:     opts.Stdin != nil,
+		Stdout:    opts.Stdout != nil,
+		Stderr:    opts.Stderr != nil && !opts.TTY,
+		TTY:       opts.TTY,
 	}
 	req.VersionedParams(
-		opts,
+		eOpts,
 		scheme.ParameterCodec,
 	)
 
@@ -450,12 +658,113 @@ func (n *Impl) Exec(ctx context.Context, cmd []string, stdin io.Reader, stdout i
 	if err != nil {
 		return err
 	}
-	log.Infof("Execing %s on %s", cmd, n.Name())
-	return exec.Stream(remotecommand.StreamOptions{
-		Stdin:  stdin,
-		Stdout: stdout,
-		Stderr: stderr,
+	log.Infof("Execing %s on %s/%s", cmd, n.Name(), container)
+	sOpts := remotecommand.StreamOptions{
+		Stdin:             opts.Stdin,
+		Stdout:            opts.Stdout,
+		Tty:               opts.TTY,
+		TerminalSizeQueue: opts.TerminalSizeQueue,
+	}
+	if eOpts.Stderr {
+		sOpts.Stderr = opts.Stderr
+	}
+	return exec.Stream(sOpts)
+}
+
+// DebugContainer adds an ephemeral container running image to the node pod
+// and waits up to two minutes for it to be running. The container shares the network namespace
+// of the node so tools missing from the vendor image can be executed in it
+// using ExecOptions.Container. An existing debug container is reused.
+func (n *Impl) DebugContainer(ctx context.Context, image string) (string, error) {
+	pods := n.KubeClient.CoreV1().Pods(n.Namespace)
+	p, err := pods.Get(ctx, n.Name(), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if ok, err := debugContainerState(p); err != nil {
+		return "", err
+	} else if ok {
+		return DebugContainerName, nil
+	}
+	exists := false
+	for _, c := range p.Spec.EphemeralContainers {
+		if c.Name == DebugContainerName {
+			exists = true
+		}
+	}
+	if !exists {
+		log.Infof("%s - adding debug container %q", n.Name(), image)
+		ec, err := pods.GetEphemeralContainers(ctx, n.Name(), metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		ec.EphemeralContainers = append(ec.EphemeralContainers, corev1.EphemeralContainer{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+				Name:    DebugContainerName,
+				Image:   image,
+				Command: []string{"sleep", "infinity"},
+				SecurityContext: &corev1.SecurityContext{
+					Privileged: pointer.Bool(true),
+				},
+			},
+			TargetContainerName: n.Name(),
+		})
+		if _, err := pods.UpdateEphemeralContainers(ctx, n.Name(), ec, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("failed to add debug container: %w", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, debugContainerTimeout)
+	defer cancel()
+	w, err := pods.Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(
+			fields.Set{metav1.ObjectNameField: n.Name()},
+		).String(),
 	})
+	if err != nil {
+		return "", err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%s - debug container not running: %w", n.Name(), ctx.Err())
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return "", fmt.Errorf("%s - watch closed before debug container was running", n.Name())
+			}
+			p, ok := e.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			if ok, err := debugContainerState(p); err != nil {
+				return "", err
+			} else if ok {
+				return DebugContainerName, nil
+			}
+		}
+	}
+}
+
+// debugContainerState returns true if the debug container of the pod is
+// running and an error if it failed to start or has terminated.
+func debugContainerState(p *corev1.Pod) (bool, error) {
+	for _, s := range p.Status.EphemeralContainerStatuses {
+		if s.Name != DebugContainerName {
+			continue
+		}
+		switch {
+		case s.State.Running != nil:
+			return true, nil
+		case s.State.Terminated != nil:
+			return false, fmt.Errorf("%s - debug container terminated: %s %s", p.Name, s.State.Terminated.Reason, s.State.Terminated.Message)
+		case s.State.Waiting != nil:
+			switch s.State.Waiting.Reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerError", "CreateContainerConfigError":
+				return false, fmt.Errorf("%s - debug container failed to start: %s %s", p.Name, s.State.Waiting.Reason, s.State.Waiting.Message)
+			}
+		}
+	}
+	return false, nil
 }
 
 // Status returns the current node state.
@@ -513,6 +822,11 @@ func getImpl(impl *Impl) (Node, error) {
 	if impl.Proto == nil {
 		return nil, fmt.Errorf("impl.Proto cannot be nil")
 	}
+	for _, model := range []string{impl.Proto.Model, ""} {
+		if fn, ok := pluginTypes[pluginKey{vendor: impl.Proto.Vendor, model: model}]; ok {
+			return fn(impl)
+		}
+	}
 	fn, ok := vendorTypes[impl.Proto.Vendor]
 	if ok {
 		return fn(impl)
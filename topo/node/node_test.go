@@ -2,9 +2,20 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/h-fam/errdiff"
 	topopb "github.com/openconfig/kne/proto/topo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktest "k8s.io/client-go/testing"
 )
 
 func NewNR(impl *Impl) (Node, error) {
@@ -50,3 +61,434 @@ func TestReset(t *testing.T) {
 		t.Errorf("Not-Resettable node type asserted to resetter")
 	}
 }
+
+type cli struct {
+	*Impl
+}
+
+func (c *cli) CLICommand() []string {
+	return []string{"vendor_cli"}
+}
+
+func TestCLICommand(t *testing.T) {
+	tests := []struct {
+		desc string
+		n    Node
+		want []string
+	}{{
+		desc: "clier",
+		n:    &cli{Impl: &Impl{Proto: &topopb.Node{}}},
+		want: []string{"vendor_cli"},
+	}, {
+		desc: "entry command",
+		n: &Impl{Proto: &topopb.Node{
+			Config: &topopb.Config{
+				EntryCommand: "kubectl exec -it r1 -- cli -c",
+			},
+		}},
+		want: []string{"cli", "-c"},
+	}, {
+		desc: "entry command without command",
+		n: &Impl{Proto: &topopb.Node{
+			Config: &topopb.Config{
+				EntryCommand: "kubectl exec -it r1",
+			},
+		}},
+		want: []string{"sh"},
+	}, {
+		desc: "no entry command",
+		n:    &Impl{Proto: &topopb.Node{}},
+		want: []string{"sh"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := CLICommand(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("CLICommand() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugContainer(t *testing.T) {
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"},
+		Status: corev1.PodStatus{
+			EphemeralContainerStatuses: []corev1.ContainerStatus{{
+				Name:  DebugContainerName,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	waiting := func(reason string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"},
+			Status: corev1.PodStatus{
+				EphemeralContainerStatuses: []corev1.ContainerStatus{{
+					Name:  DebugContainerName,
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+				}},
+			},
+		}
+	}
+	origTimeout := debugContainerTimeout
+	defer func() { debugContainerTimeout = origTimeout }()
+	debugContainerTimeout = 100 * time.Millisecond
+	tests := []struct {
+		desc       string
+		pod        *corev1.Pod
+		watchPod   *corev1.Pod
+		updateErr  error
+		wantUpdate bool
+		wantErr    string
+	}{{
+		desc:    "no pod",
+		wantErr: "not found",
+	}, {
+		desc: "already running",
+		pod:  running,
+	}, {
+		desc:       "add container",
+		pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		wantUpdate: true,
+	}, {
+		desc:       "add container failure",
+		pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		updateErr:  fmt.Errorf("ephemeral containers disabled"),
+		wantUpdate: true,
+		wantErr:    "failed to add debug container",
+	}, {
+		desc:       "image pull failure",
+		pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		watchPod:   waiting("ImagePullBackOff"),
+		wantUpdate: true,
+		wantErr:    "ImagePullBackOff",
+	}, {
+		desc: "terminated",
+		pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		watchPod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"},
+			Status: corev1.PodStatus{
+				EphemeralContainerStatuses: []corev1.ContainerStatus{{
+					Name:  DebugContainerName,
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error"}},
+				}},
+			},
+		},
+		wantUpdate: true,
+		wantErr:    "debug container terminated",
+	}, {
+		desc:       "timeout",
+		pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "test"}},
+		watchPod:   waiting("ContainerCreating"),
+		wantUpdate: true,
+		wantErr:    "deadline exceeded",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ki := kfake.NewSimpleClientset()
+			if tt.pod != nil {
+				ki = kfake.NewSimpleClientset(tt.pod)
+			}
+			var got *corev1.EphemeralContainers
+			ki.PrependReactor("get", "pods", func(action ktest.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "ephemeralcontainers" {
+					return false, nil, nil
+				}
+				return true, &corev1.EphemeralContainers{}, nil
+			})
+			ki.PrependReactor("update", "pods", func(action ktest.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "ephemeralcontainers" {
+					return false, nil, nil
+				}
+				got = action.(ktest.UpdateAction).GetObject().(*corev1.EphemeralContainers)
+				return true, got, tt.updateErr
+			})
+			w := watch.NewFake()
+			watchPod := running
+			if tt.watchPod != nil {
+				watchPod = tt.watchPod
+			}
+			ki.PrependWatchReactor("pods", func(ktest.Action) (bool, watch.Interface, error) {
+				go w.Modify(watchPod)
+				return true, w, nil
+			})
+			n := &Impl{
+				Namespace:  "test",
+				KubeClient: ki,
+				Proto:      &topopb.Node{Name: "r1"},
+			}
+			name, err := n.DebugContainer(context.Background(), "debug:latest")
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("DebugContainer() unexpected error: %s", s)
+			}
+			if gotUpdate := got != nil; gotUpdate != tt.wantUpdate {
+				t.Fatalf("DebugContainer() updated ephemeral containers: %v, want %v", gotUpdate, tt.wantUpdate)
+			}
+			if tt.wantUpdate {
+				ec := got.EphemeralContainers
+				if len(ec) != 1 || ec[0].Name != DebugContainerName || ec[0].Image != "debug:latest" || ec[0].TargetContainerName != "r1" {
+					t.Fatalf("DebugContainer() unexpected ephemeral containers: %+v", ec)
+				}
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if name != DebugContainerName {
+				t.Fatalf("DebugContainer() got %q, want %q", name, DebugContainerName)
+			}
+		})
+	}
+}
+
+type fakeExecer struct{}
+
+func (fakeExecer) ExecWithOptions(_ context.Context, cmd []string, opts *ExecOptions) error {
+	fmt.Fprintf(opts.Stdout, "out: %s\n", cmd[0])
+	if cmd[0] == "fail" {
+		fmt.Fprintln(opts.Stderr, "bad command")
+		return fmt.Errorf("exit code 1")
+	}
+	return nil
+}
+
+func TestCollectCommands(t *testing.T) {
+	got := CollectCommands(context.Background(), fakeExecer{}, map[string][]string{
+		"ok.txt":   {"show", "version"},
+		"fail.txt": {"fail"},
+	})
+	want := map[string][]byte{
+		"ok.txt":   []byte("out: show\n"),
+		"fail.txt": []byte("out: fail\nbad command\n\n\"fail\" failed: exit code 1\n"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CollectCommands() got %q, want %q", got, want)
+	}
+}
+
+type fileExecer struct {
+	cmd   []string
+	stdin []byte
+}
+
+func (f *fileExecer) ExecWithOptions(_ context.Context, cmd []string, opts *ExecOptions) error {
+	f.cmd = cmd
+	b, err := io.ReadAll(opts.Stdin)
+	if err != nil {
+		return err
+	}
+	f.stdin = b
+	if string(b) == "fail" {
+		fmt.Fprintln(opts.Stderr, "read-only file system")
+		return fmt.Errorf("exit code 1")
+	}
+	return nil
+}
+
+func TestWriteFile(t *testing.T) {
+	f := &fileExecer{}
+	if err := WriteFile(context.Background(), f, "/persist/certs/r1.crt", []byte("cert")); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	wantCmd := []string{"sh", "-c", `mkdir -p "/persist/certs" && cat > "/persist/certs/r1.crt"`}
+	if !reflect.DeepEqual(f.cmd, wantCmd) {
+		t.Errorf("WriteFile() got cmd %q, want %q", f.cmd, wantCmd)
+	}
+	if string(f.stdin) != "cert" {
+		t.Errorf("WriteFile() got contents %q, want \"cert\"", f.stdin)
+	}
+	err := WriteFile(context.Background(), f, "/persist/certs/r1.crt", []byte("fail"))
+	if s := errdiff.Substring(err, "read-only file system"); s != "" {
+		t.Errorf("WriteFile() unexpected error: %s", s)
+	}
+}
+
+func TestNetworkCommands(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pb      *topopb.Node
+		want    []string
+		wantErr string
+	}{{
+		desc: "no interfaces",
+		pb:   &topopb.Node{Name: "h1", Config: &topopb.Config{Network: &topopb.NetworkConfig{}}},
+	}, {
+		desc: "interfaces without addresses",
+		pb: &topopb.Node{
+			Name:       "h1",
+			Config:     &topopb.Config{Network: &topopb.NetworkConfig{}},
+			Interfaces: map[string]*topopb.Interface{"eth1": {PeerName: "h2"}, "eth2": {}},
+		},
+	}, {
+		desc: "int name",
+		pb: &topopb.Node{
+			Name: "h1",
+			Config: &topopb.Config{
+				Network: &topopb.NetworkConfig{DefaultGateway: "e1"},
+			},
+			Interfaces: map[string]*topopb.Interface{
+				"e1": {
+					IntName:       "eth1",
+					Addresses:     []string{"192.168.0.0/31"},
+					PeerAddresses: []string{"192.168.0.1/31"},
+					Vlans:         []*topopb.VLAN{{Id: 100}},
+				},
+			},
+		},
+		want: []string{
+			"ip link set eth1 up",
+			"ip addr replace 192.168.0.0/31 dev eth1",
+			"(ip link show eth1.100 >/dev/null 2>&1 || ip link add link eth1 name eth1.100 type vlan id 100)",
+			"ip link set eth1.100 up",
+			"ip route replace default via 192.168.0.1 dev eth1",
+		},
+	}, {
+		desc: "addresses vlans and routes",
+		pb: &topopb.Node{
+			Name: "h1",
+			Config: &topopb.Config{
+				Network: &topopb.NetworkConfig{
+					Routes:         []*topopb.Route{{Prefix: "10.0.0.0/8", NextHop: "192.168.0.1"}},
+					DefaultGateway: "eth1",
+				},
+			},
+			Interfaces: map[string]*topopb.Interface{
+				"eth2": {Vlans: []*topopb.VLAN{{Id: 100, Addresses: []string{"172.16.0.1/24"}}}},
+				"eth1": {
+					Addresses:     []string{"192.168.0.0/31", "2001:db8::/127"},
+					PeerAddresses: []string{"192.168.0.1/31", "2001:db8::1/127", "10.1.1.1/24"},
+				},
+			},
+		},
+		want: []string{
+			"ip link set eth1 up",
+			"ip addr replace 192.168.0.0/31 dev eth1",
+			"ip addr replace 2001:db8::/127 dev eth1",
+			"ip link set eth2 up",
+			"(ip link show eth2.100 >/dev/null 2>&1 || ip link add link eth2 name eth2.100 type vlan id 100)",
+			"ip link set eth2.100 up",
+			"ip addr replace 172.16.0.1/24 dev eth2.100",
+			"ip route replace 10.0.0.0/8 via 192.168.0.1",
+			"ip route replace default via 192.168.0.1 dev eth1",
+			"ip -6 route replace default via 2001:db8::1 dev eth1",
+		},
+	}, {
+		desc: "invalid vlan",
+		pb: &topopb.Node{
+			Interfaces: map[string]*topopb.Interface{"eth1": {Vlans: []*topopb.VLAN{{Id: 4095}}}},
+		},
+		wantErr: "invalid vlan id 4095 of interface eth1",
+	}, {
+		desc: "invalid route",
+		pb: &topopb.Node{
+			Config: &topopb.Config{
+				Network: &topopb.NetworkConfig{Routes: []*topopb.Route{{Prefix: "10.0.0.0/8", NextHop: "2001:db8::1"}}},
+			},
+		},
+		wantErr: "another address family",
+	}, {
+		desc: "gateway without peer address",
+		pb: &topopb.Node{
+			Config: &topopb.Config{
+				Network: &topopb.NetworkConfig{DefaultGateway: "eth1"},
+			},
+			Interfaces: map[string]*topopb.Interface{"eth1": {Addresses: []string{"192.168.0.0/31"}}},
+		},
+		wantErr: "has no peer address",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := NetworkCommands(tt.pb)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("NetworkCommands() unexpected error: %s", s)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NetworkCommands() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePodNetwork(t *testing.T) {
+	ki := kfake.NewSimpleClientset()
+	n := &Impl{
+		Namespace:  "test",
+		KubeClient: ki,
+		Proto: &topopb.Node{
+			Name: "h1",
+			Config: &topopb.Config{
+				Image:   "alpine:latest",
+				Network: &topopb.NetworkConfig{},
+			},
+			Interfaces: map[string]*topopb.Interface{"eth1": {Addresses: []string{"192.168.0.0/31"}}},
+		},
+	}
+	if err := n.CreatePod(context.Background()); err != nil {
+		t.Fatalf("CreatePod() failed: %v", err)
+	}
+	pod, err := ki.CoreV1().Pods("test").Get(context.Background(), "h1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	var names []string
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	if want := []string{"init-h1", "network-h1"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("CreatePod() got init containers %q, want %q", names, want)
+	}
+	want := []string{"/bin/sh", "-c", "ip link set eth1 up && ip addr replace 192.168.0.0/31 dev eth1"}
+	if got := pod.Spec.InitContainers[1].Command; !reflect.DeepEqual(got, want) {
+		t.Errorf("CreatePod() got network command %q, want %q", got, want)
+	}
+}
+
+func TestCreatePodNoNetwork(t *testing.T) {
+	ki := kfake.NewSimpleClientset()
+	n := &Impl{
+		Namespace:  "test",
+		KubeClient: ki,
+		Proto: &topopb.Node{
+			Name: "h1",
+			Config: &topopb.Config{
+				Image:   "alpine:latest",
+				Network: &topopb.NetworkConfig{},
+			},
+			Interfaces: map[string]*topopb.Interface{"eth1": {PeerName: "h2", PeerIntName: "eth1"}},
+		},
+	}
+	if err := n.CreatePod(context.Background()); err != nil {
+		t.Fatalf("CreatePod() failed: %v", err)
+	}
+	pod, err := ki.CoreV1().Pods("test").Get(context.Background(), "h1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	var names []string
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	if want := []string{"init-h1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("CreatePod() got init containers %q, want %q", names, want)
+	}
+}
+
+func TestNetworkResetCommands(t *testing.T) {
+	pb := &topopb.Node{
+		Interfaces: map[string]*topopb.Interface{"e1": {IntName: "eth1", Addresses: []string{"192.168.0.0/31"}}},
+	}
+	got, err := NetworkResetCommands(pb)
+	if err != nil {
+		t.Fatalf("NetworkResetCommands() failed: %v", err)
+	}
+	want := []string{
+		`for l in /sys/class/net/eth1.*; do if [ -e "$l" ]; then ip link del "${l##*/}"; fi; done`,
+		"ip addr flush dev eth1 scope global",
+		"ip route flush dev eth1 proto boot",
+		"ip -6 route flush dev eth1 proto boot",
+		"ip link set eth1 up",
+		"ip addr replace 192.168.0.0/31 dev eth1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NetworkResetCommands() got %q, want %q", got, want)
+	}
+}
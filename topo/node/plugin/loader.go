@@ -0,0 +1,237 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/gnmi/errlist"
+	ppb "github.com/openconfig/kne/proto/plugin"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// AddressEnv is the environment variable holding the address a launched
+// plugin must serve on.
+const AddressEnv = "KNE_PLUGIN_ADDRESS"
+
+// dialTimeout is the time to wait for a plugin to accept connections.
+var dialTimeout = 30 * time.Second
+
+// Listen returns a listener on the address in AddressEnv, for use by plugins
+// launched by KNE.
+func Listen() (net.Listener, error) {
+	addr := os.Getenv(AddressEnv)
+	if addr == "" {
+		return nil, fmt.Errorf("%s is not set", AddressEnv)
+	}
+	if p := strings.TrimPrefix(addr, "unix://"); p != addr {
+		return net.Listen("unix", p)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Plugins are the node plugins loaded from a plugin directory. Plugins are
+// connected to, or launched, when the first node of their vendor is created.
+type Plugins struct {
+	plugins []*plugin
+}
+
+type plugin struct {
+	manifest *ppb.Manifest
+	dir      string
+
+	once   sync.Once
+	client ppb.NodePluginClient
+	err    error
+	conn   *grpc.ClientConn
+	cmd    *exec.Cmd
+	sock   string
+}
+
+// Load reads the plugin manifests, files ending in .textproto or .pb.txt, in
+// dir and registers the plugins for their vendors and models. A missing dir
+// has no plugins. Invalid manifests are skipped, the valid plugins are
+// returned together with an error listing the skipped manifests.
+func Load(dir string) (*Plugins, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Plugins{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ps := &Plugins{}
+	var errList errlist.List
+	for _, e := range entries {
+		if e.IsDir() || !(strings.HasSuffix(e.Name(), ".textproto") || strings.HasSuffix(e.Name(), ".pb.txt")) {
+			continue
+		}
+		pl, err := load(dir, e.Name())
+		if err != nil {
+			errList.Add(err)
+		}
+		if pl != nil {
+			ps.plugins = append(ps.plugins, pl)
+		}
+	}
+	return ps, errList.Err()
+}
+
+// load reads the plugin manifest name in dir and registers the plugin. The
+// plugin is returned with the error if only some of its models could be
+// registered, so it is closed with the other plugins.
+func load(dir, name string) (*plugin, error) {
+	p := filepath.Join(dir, name)
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	m := &ppb.Manifest{}
+	if err := prototext.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest %s: %w", p, err)
+	}
+	if err := validate(m); err != nil {
+		return nil, fmt.Errorf("invalid plugin manifest %s: %w", p, err)
+	}
+	pl := &plugin{manifest: m, dir: dir}
+	models := m.GetModels()
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	var errList errlist.List
+	registered := false
+	for _, model := range models {
+		if err := node.RegisterPlugin(m.GetVendor(), model, newNodeFn(pl.connect)); err != nil {
+			errList.Add(fmt.Errorf("plugin %s: %w", m.GetName(), err))
+			continue
+		}
+		registered = true
+	}
+	if !registered {
+		return nil, errList.Err()
+	}
+	log.Infof("Loaded plugin %s for vendor %v models %v", m.GetName(), m.GetVendor(), m.GetModels())
+	return pl, errList.Err()
+}
+
+func validate(m *ppb.Manifest) error {
+	switch {
+	case m.GetName() == "":
+		return fmt.Errorf("name must be set")
+	case m.GetVendor() == tpb.Vendor_UNKNOWN:
+		return fmt.Errorf("vendor must be set")
+	case m.GetAddress() == "" && len(m.GetCommand()) == 0:
+		return fmt.Errorf("address or command must be set")
+	}
+	return nil
+}
+
+// connect returns the client of the plugin, launching the plugin if it has no
+// address.
+func (p *plugin) connect() (ppb.NodePluginClient, error) {
+	p.once.Do(func() {
+		p.client, p.err = p.dial()
+		if p.err != nil {
+			p.err = fmt.Errorf("failed to connect to plugin %s: %w", p.manifest.GetName(), p.err)
+		}
+	})
+	return p.client, p.err
+}
+
+func (p *plugin) dial() (ppb.NodePluginClient, error) {
+	addr := p.manifest.GetAddress()
+	if addr == "" {
+		var err error
+		if addr, err = p.launch(); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return nil, err
+	}
+	p.conn = conn
+	return ppb.NewNodePluginClient(conn), nil
+}
+
+// launch starts the plugin command and returns the address it serves on.
+func (p *plugin) launch() (string, error) {
+	d, err := os.MkdirTemp("", "kne-plugin-")
+	if err != nil {
+		return "", err
+	}
+	p.sock = d
+	addr := "unix://" + filepath.Join(d, "plugin.sock")
+	args := p.manifest.GetCommand()
+	// Commands not found in the plugin directory are looked up in PATH.
+	bin := args[0]
+	if !filepath.IsAbs(bin) {
+		if _, err := os.Stat(filepath.Join(p.dir, bin)); err == nil {
+			bin = filepath.Join(p.dir, bin)
+		}
+	}
+	p.cmd = exec.Command(bin, args[1:]...)
+	p.cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", AddressEnv, addr))
+	p.cmd.Stdout = os.Stderr
+	p.cmd.Stderr = os.Stderr
+	log.Infof("Launching plugin %s: %s", p.manifest.GetName(), strings.Join(args, " "))
+	if err := p.cmd.Start(); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
+
+// Close closes the connections to the plugins and stops the launched
+// plugins.
+func (ps *Plugins) Close() error {
+	var errs []string
+	for _, p := range ps.plugins {
+		if p.conn != nil {
+			if err := p.conn.Close(); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if p.cmd != nil && p.cmd.Process != nil {
+			if err := p.cmd.Process.Kill(); err != nil {
+				errs = append(errs, err.Error())
+			}
+			// The plugin exits with the kill signal.
+			_ = p.cmd.Wait()
+		}
+		if p.sock != "" {
+			os.RemoveAll(p.sock)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to close plugins: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin implements nodes with out of tree node plugins, which serve
+// the NodePlugin gRPC service for the nodes of a vendor.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	ppb "github.com/openconfig/kne/proto/plugin"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Node is a node implemented by a plugin. The core node operations fall back
+// to the default node implementation if the plugin does not implement them.
+type Node struct {
+	*node.Impl
+	client ppb.NodePluginClient
+}
+
+// Add validations for interfaces the node provides
+var (
+	_ node.Certer       = (*Node)(nil)
+	_ node.ConfigPusher = (*Node)(nil)
+	_ node.Resetter     = (*Node)(nil)
+)
+
+// newNodeFn returns the function creating nodes with the plugin client.
+func newNodeFn(client func() (ppb.NodePluginClient, error)) node.NewNodeFn {
+	return func(nodeImpl *node.Impl) (node.Node, error) {
+		if nodeImpl == nil {
+			return nil, fmt.Errorf("nodeImpl cannot be nil")
+		}
+		if nodeImpl.Proto == nil {
+			return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
+		}
+		c, err := client()
+		if err != nil {
+			return nil, err
+		}
+		n := &Node{
+			Impl:   nodeImpl,
+			client: c,
+		}
+		resp, err := c.New(context.Background(), n.request())
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+		case err != nil:
+			return nil, fmt.Errorf("plugin failed to create node %s: %w", nodeImpl.Proto.Name, err)
+		case resp.GetNode() != nil:
+			nodeImpl.Proto = resp.GetNode()
+		}
+		return n, nil
+	}
+}
+
+func (n *Node) request() *ppb.NodeRequest {
+	return &ppb.NodeRequest{
+		Node:      n.Proto,
+		Namespace: n.Namespace,
+		Kubecfg:   n.Kubecfg,
+	}
+}
+
+// unimplemented returns true if the plugin does not implement the RPC which
+// returned err.
+func unimplemented(err error) bool {
+	return status.Code(err) == codes.Unimplemented
+}
+
+func (n *Node) TopologySpecs(ctx context.Context) ([]*topologyv1.Topology, error) {
+	resp, err := n.client.TopologySpecs(ctx, n.request())
+	if unimplemented(err) {
+		return n.Impl.TopologySpecs(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var topos []*topologyv1.Topology
+	for _, b := range resp.GetTopologies() {
+		t := &topologyv1.Topology{}
+		if err := json.Unmarshal(b, t); err != nil {
+			return nil, fmt.Errorf("invalid topology spec from plugin: %w", err)
+		}
+		topos = append(topos, t)
+	}
+	return topos, nil
+}
+
+func (n *Node) Create(ctx context.Context) error {
+	if _, err := n.client.Create(ctx, n.request()); !unimplemented(err) {
+		return err
+	}
+	log.Infof("Plugin does not implement Create, creating node %s with defaults", n.Name())
+	return n.Impl.Create(ctx)
+}
+
+func (n *Node) Status(ctx context.Context) (node.Status, error) {
+	resp, err := n.client.Status(ctx, n.request())
+	if unimplemented(err) {
+		return n.Impl.Status(ctx)
+	}
+	if err != nil {
+		return node.StatusUnknown, err
+	}
+	switch resp.GetStatus() {
+	case ppb.StatusResponse_PENDING:
+		return node.StatusPending, nil
+	case ppb.StatusResponse_RUNNING:
+		return node.StatusRunning, nil
+	case ppb.StatusResponse_FAILED:
+		return node.StatusFailed, nil
+	default:
+		return node.StatusUnknown, nil
+	}
+}
+
+func (n *Node) Delete(ctx context.Context) error {
+	if _, err := n.client.Delete(ctx, n.request()); !unimplemented(err) {
+		return err
+	}
+	return n.Impl.Delete(ctx)
+}
+
+func (n *Node) Pods(ctx context.Context) ([]*corev1.Pod, error) {
+	resp, err := n.client.Pods(ctx, n.request())
+	if unimplemented(err) {
+		return n.Impl.Pods(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for _, b := range resp.GetPods() {
+		p := &corev1.Pod{}
+		if err := json.Unmarshal(b, p); err != nil {
+			return nil, fmt.Errorf("invalid pod from plugin: %w", err)
+		}
+		pods = append(pods, p)
+	}
+	return pods, nil
+}
+
+func (n *Node) Services(ctx context.Context) ([]*corev1.Service, error) {
+	resp, err := n.client.Services(ctx, n.request())
+	if unimplemented(err) {
+		return n.Impl.Services(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var svcs []*corev1.Service
+	for _, b := range resp.GetServices() {
+		s := &corev1.Service{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("invalid service from plugin: %w", err)
+		}
+		svcs = append(svcs, s)
+	}
+	return svcs, nil
+}
+
+func (n *Node) GenerateSelfSigned(ctx context.Context) error {
+	_, err := n.client.GenerateSelfSigned(ctx, n.request())
+	return err
+}
+
+func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return err
+	}
+	_, err := n.client.ConfigPush(ctx, &ppb.ConfigPushRequest{
+		Node:      n.Proto,
+		Namespace: n.Namespace,
+		Kubecfg:   n.Kubecfg,
+		Config:    buf.Bytes(),
+	})
+	return err
+}
+
+func (n *Node) ResetCfg(ctx context.Context) error {
+	_, err := n.client.ResetCfg(ctx, n.request())
+	return err
+}
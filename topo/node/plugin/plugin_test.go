@@ -0,0 +1,254 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/h-fam/errdiff"
+	ppb "github.com/openconfig/kne/proto/plugin"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// serveEnv makes the test binary serve the fake plugin when launched as a
+// plugin.
+const serveEnv = "KNE_PLUGIN_TEST_SERVE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(serveEnv) != "" && os.Getenv(AddressEnv) != "" {
+		lis, err := Listen()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		s := grpc.NewServer()
+		ppb.RegisterNodePluginServer(s, &fakePlugin{})
+		if err := s.Serve(lis); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakePlugin implements nodes with a default image, which are always running
+// and accept any config but "bad".
+type fakePlugin struct {
+	ppb.UnimplementedNodePluginServer
+	configs []string
+}
+
+func (f *fakePlugin) New(_ context.Context, req *ppb.NodeRequest) (*ppb.NewResponse, error) {
+	n := req.GetNode()
+	if n.Config == nil {
+		n.Config = &tpb.Config{}
+	}
+	if n.Config.Image == "" {
+		n.Config.Image = "plugin:latest"
+	}
+	return &ppb.NewResponse{Node: n}, nil
+}
+
+func (f *fakePlugin) Status(context.Context, *ppb.NodeRequest) (*ppb.StatusResponse, error) {
+	return &ppb.StatusResponse{Status: ppb.StatusResponse_RUNNING}, nil
+}
+
+func (f *fakePlugin) Pods(_ context.Context, req *ppb.NodeRequest) (*ppb.PodsResponse, error) {
+	b, err := json.Marshal(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: req.GetNode().GetName(), Namespace: req.GetNamespace()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ppb.PodsResponse{Pods: [][]byte{b}}, nil
+}
+
+func (f *fakePlugin) ConfigPush(_ context.Context, req *ppb.ConfigPushRequest) (*ppb.ConfigPushResponse, error) {
+	if string(req.GetConfig()) == "bad" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config")
+	}
+	f.configs = append(f.configs, string(req.GetConfig()))
+	return &ppb.ConfigPushResponse{}, nil
+}
+
+func writeManifest(t *testing.T, manifest string) string {
+	t.Helper()
+	d := t.TempDir()
+	if err := os.WriteFile(filepath.Join(d, "plugin.textproto"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	return d
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		desc     string
+		manifest string
+		wantErr  string
+	}{{
+		desc:     "no name",
+		manifest: `vendor: KEYSIGHT address: "localhost:1"`,
+		wantErr:  "name must be set",
+	}, {
+		desc:     "no vendor",
+		manifest: `name: "p" address: "localhost:1"`,
+		wantErr:  "vendor must be set",
+	}, {
+		desc:     "no address or command",
+		manifest: `name: "p" vendor: KEYSIGHT`,
+		wantErr:  "address or command must be set",
+	}, {
+		desc:     "invalid manifest",
+		manifest: `name: "p" vendor: BOGUS`,
+		wantErr:  "invalid plugin manifest",
+	}, {
+		desc:     "valid",
+		manifest: `name: "p" vendor: KEYSIGHT models: "load" address: "localhost:1"`,
+	}, {
+		desc:     "duplicate",
+		manifest: `name: "p" vendor: KEYSIGHT models: "load" address: "localhost:1"`,
+		wantErr:  "duplicate plugin registration",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Load(writeManifest(t, tt.manifest))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Load() unexpected error: %s", s)
+			}
+		})
+	}
+	ps, err := Load(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("Load() of missing dir failed: %v", err)
+	}
+	if len(ps.plugins) != 0 {
+		t.Errorf("Load() of missing dir got %d plugins, want 0", len(ps.plugins))
+	}
+	// Invalid manifests are skipped and do not prevent loading the others.
+	d := writeManifest(t, `name: "bad" vendor: KEYSIGHT`)
+	if err := os.WriteFile(filepath.Join(d, "good.textproto"), []byte(`name: "good" vendor: KEYSIGHT models: "skip" address: "localhost:1"`), 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	ps, err = Load(d)
+	if s := errdiff.Substring(err, "address or command must be set"); s != "" {
+		t.Fatalf("Load() unexpected error: %s", s)
+	}
+	if len(ps.plugins) != 1 || ps.plugins[0].manifest.GetName() != "good" {
+		t.Errorf("Load() got %d plugins, want only plugin good", len(ps.plugins))
+	}
+}
+
+func TestNode(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := grpc.NewServer()
+	f := &fakePlugin{}
+	ppb.RegisterNodePluginServer(s, f)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	ps, err := Load(writeManifest(t, fmt.Sprintf(`name: "fake" vendor: KEYSIGHT models: "address" address: %q`, lis.Addr().String())))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	defer ps.Close()
+
+	ctx := context.Background()
+	ki := fake.NewSimpleClientset()
+	n, err := node.New("test", &tpb.Node{Name: "r1", Vendor: tpb.Vendor_KEYSIGHT, Model: "address"}, ki, nil, "", "")
+	if err != nil {
+		t.Fatalf("node.New() failed: %v", err)
+	}
+	if _, ok := n.(*Node); !ok {
+		t.Fatalf("node.New() got %T, want *Node", n)
+	}
+	if got, want := n.GetProto().GetConfig().GetImage(), "plugin:latest"; got != want {
+		t.Errorf("node.New() got image %q, want %q", got, want)
+	}
+	if err := n.Create(ctx); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if _, err := ki.CoreV1().Pods("test").Get(ctx, "r1", metav1.GetOptions{}); err != nil {
+		t.Errorf("Create() did not fall back to creating the pod: %v", err)
+	}
+	if got, err := n.Status(ctx); err != nil || got != node.StatusRunning {
+		t.Errorf("Status() got %v, %v, want %v", got, err, node.StatusRunning)
+	}
+	pods, err := n.Pods(ctx)
+	if err != nil {
+		t.Fatalf("Pods() failed: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "r1" || pods[0].Namespace != "test" {
+		t.Errorf("Pods() got %v, want pod test/r1", pods)
+	}
+	topos, err := n.TopologySpecs(ctx)
+	if err != nil || len(topos) != 1 {
+		t.Errorf("TopologySpecs() got %v, %v, want 1 default topology", topos, err)
+	}
+	cp := n.(node.ConfigPusher)
+	if err := cp.ConfigPush(ctx, strings.NewReader("hostname r1")); err != nil {
+		t.Errorf("ConfigPush() failed: %v", err)
+	}
+	if err := cp.ConfigPush(ctx, strings.NewReader("bad")); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ConfigPush() got error %v, want InvalidArgument", err)
+	}
+	if len(f.configs) != 1 || f.configs[0] != "hostname r1" {
+		t.Errorf("ConfigPush() pushed %q, want [hostname r1]", f.configs)
+	}
+	if err := n.(node.Resetter).ResetCfg(ctx); status.Code(err) != codes.Unimplemented {
+		t.Errorf("ResetCfg() got error %v, want Unimplemented", err)
+	}
+}
+
+func TestLaunch(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to get test binary: %v", err)
+	}
+	t.Setenv(serveEnv, "1")
+	ps, err := Load(writeManifest(t, fmt.Sprintf(`name: "launched" vendor: KEYSIGHT models: "command" command: %q`, exe)))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	n, err := node.New("test", &tpb.Node{Name: "r1", Vendor: tpb.Vendor_KEYSIGHT, Model: "command"}, fake.NewSimpleClientset(), nil, "", "")
+	if err != nil {
+		t.Fatalf("node.New() failed: %v", err)
+	}
+	if got, err := n.Status(context.Background()); err != nil || got != node.StatusRunning {
+		t.Errorf("Status() got %v, %v, want %v", got, err, node.StatusRunning)
+	}
+	if err := ps.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+	if p := ps.plugins[0]; p.cmd.ProcessState == nil {
+		t.Errorf("Close() did not stop the plugin")
+	}
+}
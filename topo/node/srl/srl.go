@@ -1,13 +1,17 @@
 package srl
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	topopb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 	scraplibase "github.com/scrapli/scrapligo/driver/base"
 	scraplinetwork "github.com/scrapli/scrapligo/driver/network"
 	scraplitransport "github.com/scrapli/scrapligo/transport"
@@ -45,9 +49,32 @@ type Node struct {
 
 // Add validations for interfaces the node provides
 var (
-	_ node.Certer = (*Node)(nil)
+	_ node.CLIer         = (*Node)(nil)
+	_ node.Certer        = (*Node)(nil)
+	_ node.CertInstaller = (*Node)(nil)
+	_ node.Collector     = (*Node)(nil)
+	_ node.ConfigGetter  = (*Node)(nil)
+	_ node.ConfigPusher  = (*Node)(nil)
+	_ node.Resetter      = (*Node)(nil)
 )
 
+// CLICommand returns the command to start the vendor CLI.
+func (n *Node) CLICommand() []string {
+	return []string{"sr_cli"}
+}
+
+// diagnosticCommands are the commands collected by Collect keyed by file name.
+var diagnosticCommands = map[string][]string{
+	"show-version.txt":         {"sr_cli", "-d", "show version"},
+	"info-flat-running.txt":    {"sr_cli", "-d", "info flat from running"},
+	"show-interface-brief.txt": {"sr_cli", "-d", "show interface brief"},
+}
+
+// Collect returns the output of the SR Linux diagnostic commands.
+func (n *Node) Collect(ctx context.Context) (map[string][]byte, error) {
+	return node.CollectCommands(ctx, n, diagnosticCommands), nil
+}
+
 func (n *Node) GenerateSelfSigned(ctx context.Context) error {
 	selfSigned := n.Proto.GetConfig().GetCert().GetSelfSigned()
 	if selfSigned == nil {
@@ -85,6 +112,174 @@ func (n *Node) GenerateSelfSigned(ctx context.Context) error {
 	return err
 }
 
+// InstallCert configures the TLS server profile named after the cert with the
+// cert, key and issuing CA of c.
+func (n *Node) InstallCert(ctx context.Context, c *node.Certificate) error {
+	log.Infof("%s - installing cert %s", n.Name(), c.Name)
+
+	if err := n.SpawnCLIConn(n.Namespace); err != nil {
+		return err
+	}
+
+	defer n.cliConn.Close()
+
+	profile := fmt.Sprintf("/ system tls server-profile %s", c.Name)
+	multiResp, err := n.cliConn.SendConfigs([]string{
+		fmt.Sprintf("set %s", profile),
+		fmt.Sprintf("set %s authenticate-client false", profile),
+	})
+	if err != nil {
+		return err
+	}
+	if multiResp.Failed != nil {
+		return multiResp.Failed
+	}
+	// PEM blocks span multiple lines so they are sent eagerly without
+	// waiting for each line to be echoed.
+	for _, leaf := range []struct {
+		name string
+		pem  []byte
+	}{
+		{"key", c.Key},
+		{"certificate", c.Cert},
+		{"trust-anchor", c.CA},
+	} {
+		if len(leaf.pem) == 0 {
+			continue
+		}
+		if _, err := n.cliConn.SendConfig(
+			fmt.Sprintf("set %s %s \"%s\"", profile, leaf.name, strings.TrimSpace(string(leaf.pem))),
+			scraplibase.WithSendEager(true),
+		); err != nil {
+			return err
+		}
+	}
+	// Errors in the eagerly sent leaves are reported by the commit.
+	multiResp, err = n.cliConn.SendConfigs([]string{"commit save"})
+	if err != nil {
+		return err
+	}
+	if multiResp.Failed != nil {
+		return multiResp.Failed
+	}
+
+	log.Infof("%s - finished installing cert", n.Name())
+
+	return nil
+}
+
+// ConfigGet returns the running config of the node as JSON, the format of the
+// config.json startup config.
+func (n *Node) ConfigGet(ctx context.Context) ([]byte, error) {
+	log.Infof("%s - getting config", n.Name())
+
+	if err := n.SpawnCLIConn(n.Namespace); err != nil {
+		return nil, err
+	}
+
+	defer n.cliConn.Close()
+
+	resp, err := n.cliConn.SendCommand("info from running / | as json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, resp.Failed
+	}
+
+	log.Infof("%s - finished getting config", n.Name())
+
+	return []byte(strings.TrimSpace(resp.Result) + "\n"), nil
+}
+
+// configFile is the path in the container of the JSON configs loaded by
+// ConfigPush.
+const configFile = "/tmp/kne-config.json"
+
+// factoryCheckpoint is the checkpoint SR Linux generates of its initial
+// config when it first boots.
+const factoryCheckpoint = "initial"
+
+// configFailedWhenContains are the SR Linux errors of config commands, which
+// report unknown commands as parsing errors.
+var configFailedWhenContains = []string{
+	"Error:",
+	"Parsing error:",
+}
+
+// writeFile is swapped in tests as the exec transport cannot be faked.
+var writeFile = node.WriteFile
+
+// ConfigPush loads the config into a candidate and commits it. JSON configs,
+// the format of the config.json startup config, replace the running config.
+// CLI configs are applied on top of it.
+func (n *Node) ConfigPush(ctx context.Context, r io.Reader) error {
+	log.Infof("%s - pushing config", n.Name())
+
+	cfg, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	log.Debug(string(cfg))
+
+	var cmds []string
+	if bytes.HasPrefix(bytes.TrimSpace(cfg), []byte("{")) {
+		if err := writeFile(ctx, n, configFile, cfg); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		cmds = append(cmds, fmt.Sprintf("load file %s", configFile))
+	} else {
+		for _, l := range strings.Split(string(cfg), "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				cmds = append(cmds, l)
+			}
+		}
+	}
+
+	if err := n.commit(cmds); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished config push", n.Name())
+
+	return nil
+}
+
+// ResetCfg loads the factory checkpoint into a candidate and commits it. The
+// startup config is not changed.
+func (n *Node) ResetCfg(ctx context.Context) error {
+	log.Infof("%s - resetting config", n.Name())
+
+	if err := n.commit([]string{fmt.Sprintf("load checkpoint name %s", factoryCheckpoint)}); err != nil {
+		return err
+	}
+
+	log.Infof("%s - finished resetting config", n.Name())
+
+	return nil
+}
+
+// commit sends the config commands to a private candidate and commits them.
+func (n *Node) commit(cmds []string) error {
+	if err := n.SpawnCLIConn(n.Namespace); err != nil {
+		return err
+	}
+
+	defer n.cliConn.Close()
+
+	multiResp, err := n.cliConn.SendConfigs(
+		append(cmds, "commit now"),
+		scraplibase.WithSendFailedWhenContains(configFailedWhenContains),
+		scraplibase.WithSendStopOnFailed(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	return multiResp.Failed
+}
+
 // Create creates a Nokia SR Linux node by interfacing with srl-labs/srl-controller
 func (n *Node) Create(ctx context.Context) error {
 	log.Infof("Creating Srlinux node resource %s", n.Name())
@@ -184,41 +379,16 @@ func (n *Node) Delete(ctx context.Context) error {
 	return nil
 }
 
+// defaults sets the computed defaults of the node, the static defaults are
+// taken from the NOKIA vendor profile.
 func defaults(pb *topopb.Node) *topopb.Node {
-	if pb.Config == nil {
-		pb.Config = &topopb.Config{}
-	}
-	if pb.Services == nil {
-		pb.Services = map[uint32]*topopb.Service{
-			443: {
-				Name:   "ssl",
-				Inside: 443,
-			},
-			22: {
-				Name:   "ssh",
-				Inside: 22,
-			},
-			57400: {
-				Name:   "gnmi",
-				Inside: 57400,
-			},
-		}
-	}
+	profile.Embedded().Fill(topopb.Vendor_NOKIA, pb)
 	if pb.Labels == nil {
 		pb.Labels = map[string]string{}
 	}
 	if pb.Labels["type"] == "" {
 		pb.Labels["type"] = topopb.Node_NOKIA_SRL.String()
 	}
-	if pb.Config == nil {
-		pb.Config = &topopb.Config{}
-	}
-	if pb.Config.Image == "" {
-		pb.Config.Image = "ghcr.io/nokia/srlinux:latest"
-	}
-	if pb.Config.ConfigFile == "" {
-		pb.Config.ConfigFile = "config.json"
-	}
 	return pb
 }
 
@@ -0,0 +1,324 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vm implements nodes which boot a VM disk image with qemu inside the
+// node pod, for vendor images which are only shipped as VMs.
+package vm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	defaultInitContainerImage = "networkop/init-wait:latest"
+	// consolePort is the port of the serial console of the VM in the pod.
+	consolePort = 5000
+	// consoleLog is the file the console output of the VM is logged to, it
+	// is matched against the ready pattern of the VM.
+	consoleLog = "/tmp/console.log"
+	// configISO is the CD image holding the startup config of the VM.
+	configISO = "/tmp/config.iso"
+	// memoryOverheadMB is the memory reserved for qemu on top of the VM memory.
+	memoryOverheadMB = 512
+)
+
+func New(nodeImpl *node.Impl) (node.Node, error) {
+	if nodeImpl == nil {
+		return nil, fmt.Errorf("nodeImpl cannot be nil")
+	}
+	if nodeImpl.Proto == nil {
+		return nil, fmt.Errorf("nodeImpl.Proto cannot be nil")
+	}
+	cfg, err := defaults(nodeImpl.Proto)
+	if err != nil {
+		return nil, err
+	}
+	nodeImpl.Proto = cfg
+	n := &Node{
+		Impl: nodeImpl,
+	}
+	return n, nil
+}
+
+// Node is a VM booted by a qemu launcher in the node pod. The pod image must
+// contain qemu-system-x86_64, iproute2 and the disk image of the VM, plus
+// genisoimage if the node has a startup config. The VM runs with KVM if the
+// host provides it and with software emulation otherwise.
+type Node struct {
+	*node.Impl
+}
+
+// Create creates the pod of the node, which only becomes ready once the VM
+// has booted, and its services.
+func (n *Node) Create(ctx context.Context) error {
+	log.Infof("Creating VM node resource %s", n.Name())
+
+	if err := n.CreateConfig(ctx); err != nil {
+		return fmt.Errorf("node %s failed to create config-map %w", n.Name(), err)
+	}
+	pb := n.Proto
+	initContainerImage := pb.Config.InitImage
+	if initContainerImage == "" {
+		initContainerImage = defaultInitContainerImage
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: n.Name(),
+			Labels: map[string]string{
+				"app":  n.Name(),
+				"topo": n.Namespace,
+			},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name:  fmt.Sprintf("init-%s", n.Name()),
+				Image: initContainerImage,
+				Args: []string{
+					fmt.Sprintf("%d", len(pb.GetInterfaces())+1),
+					fmt.Sprintf("%d", pb.GetConfig().Sleep),
+				},
+				ImagePullPolicy: "IfNotPresent",
+			}},
+			Containers: []corev1.Container{{
+				Name:            n.Name(),
+				Image:           pb.Config.Image,
+				Command:         pb.Config.Command,
+				Args:            pb.Config.Args,
+				Env:             node.ToEnvVar(pb.Config.Env),
+				Resources:       node.ToResourceRequirements(pb.Constraints),
+				ImagePullPolicy: "IfNotPresent",
+				SecurityContext: &corev1.SecurityContext{
+					Privileged: pointer.Bool(true),
+				},
+				ReadinessProbe: &corev1.Probe{
+					Handler: corev1.Handler{
+						Exec: &corev1.ExecAction{
+							Command: []string{"grep", "-qF", pb.Config.Vm.ReadyPattern, consoleLog},
+						},
+					},
+					PeriodSeconds: 10,
+				},
+			}},
+			TerminationGracePeriodSeconds: pointer.Int64(0),
+			NodeSelector:                  map[string]string{},
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{{
+									Key:      "topo",
+									Operator: "In",
+									Values:   []string{pb.Name},
+								}},
+							},
+							TopologyKey: "kubernetes.io/hostname",
+						},
+					}},
+				},
+			},
+		},
+	}
+	if pb.Config.ConfigData != nil {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: "startup-config-volume",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf("%s-config", pb.Name),
+					},
+				},
+			},
+		})
+		for i, c := range pod.Spec.Containers {
+			pod.Spec.Containers[i].VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+				Name:      "startup-config-volume",
+				MountPath: pb.Config.ConfigPath + "/" + pb.Config.ConfigFile,
+				SubPath:   pb.Config.ConfigFile,
+				ReadOnly:  true,
+			})
+		}
+	}
+	sPod, err := n.KubeClient.CoreV1().Pods(n.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create pod for %q: %w", pb.Name, err)
+	}
+	log.Debugf("Pod created:\n%+v\n", sPod)
+	log.Infof("Created VM node resource %s pod", n.Name())
+	if err := n.CreateService(ctx); err != nil {
+		return err
+	}
+	log.Infof("Created VM node resource %s services", n.Name())
+	return nil
+}
+
+// Status returns the current node state. A running pod is pending until the
+// VM has booted and the pod is ready.
+func (n *Node) Status(ctx context.Context) (node.Status, error) {
+	s, err := n.Impl.Status(ctx)
+	if err != nil || s != node.StatusRunning {
+		return s, err
+	}
+	p, err := n.Pods(ctx)
+	if err != nil {
+		return node.StatusUnknown, err
+	}
+	for _, c := range p[0].Status.Conditions {
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+			return node.StatusRunning, nil
+		}
+	}
+	return node.StatusPending, nil
+}
+
+// launcher returns the shell script which connects the eth interfaces of the
+// pod to tap interfaces of the VM and boots the VM with qemu. eth1 is the
+// second NIC of the VM, the first being the management NIC, and missing eth
+// interfaces are left unconnected so NIC order matches eth numbering. The
+// service ports of the node are forwarded to the management NIC, except the
+// console port which is served by qemu.
+func launcher(pb *tpb.Node) (string, error) {
+	vm := pb.Config.Vm
+	var max int
+	for name := range pb.Interfaces {
+		id, err := ethID(name)
+		if err != nil {
+			return "", err
+		}
+		if id > max {
+			max = id
+		}
+	}
+	var ports []int
+	for _, s := range pb.Services {
+		if s.Inside != consolePort {
+			ports = append(ports, int(s.Inside))
+		}
+	}
+	sort.Ints(ports)
+	mac := macPrefix(pb.Name)
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	b.WriteString("ACCEL='-accel tcg'\n")
+	b.WriteString("if [ -c /dev/kvm ]; then ACCEL='-enable-kvm -cpu host'; fi\n")
+	for i := 1; i <= max; i++ {
+		fmt.Fprintf(&b, "ip tuntap add tap%d mode tap\n", i)
+		fmt.Fprintf(&b, "ip link set tap%d up\n", i)
+		if _, ok := pb.Interfaces[fmt.Sprintf("eth%d", i)]; !ok {
+			continue
+		}
+		for _, d := range [][2]string{{fmt.Sprintf("eth%d", i), fmt.Sprintf("tap%d", i)}, {fmt.Sprintf("tap%d", i), fmt.Sprintf("eth%d", i)}} {
+			fmt.Fprintf(&b, "tc qdisc add dev %s ingress\n", d[0])
+			fmt.Fprintf(&b, "tc filter add dev %s parent ffff: protocol all u32 match u8 0 0 action mirred egress redirect dev %s\n", d[0], d[1])
+		}
+	}
+	if pb.Config.ConfigData != nil {
+		fmt.Fprintf(&b, "genisoimage -quiet -l -o %s %s\n", configISO, pb.Config.ConfigPath)
+	}
+	args := []string{
+		"exec qemu-system-x86_64 $ACCEL -display none",
+		fmt.Sprintf("-smp %d -m %d", vm.Cpus, vm.MemoryMb),
+		fmt.Sprintf("-drive if=ide,file=%s -snapshot", vm.DiskImage),
+		fmt.Sprintf("-chardev socket,id=console,host=0.0.0.0,port=%d,server=on,wait=off,telnet=on,logfile=%s -serial chardev:console", consolePort, consoleLog),
+	}
+	mgmt := "-netdev user,id=mgmt"
+	for _, p := range ports {
+		mgmt += fmt.Sprintf(",hostfwd=tcp::%d-:%d", p, p)
+	}
+	args = append(args, fmt.Sprintf("%s -device %s,netdev=mgmt,mac=%s:00", mgmt, vm.NicModel, mac))
+	for i := 1; i <= max; i++ {
+		args = append(args, fmt.Sprintf("-netdev tap,id=net%d,ifname=tap%d,script=no,downscript=no -device %s,netdev=net%d,mac=%s:%02x", i, i, vm.NicModel, i, mac, i))
+	}
+	if pb.Config.ConfigData != nil {
+		args = append(args, fmt.Sprintf("-cdrom %s", configISO))
+	}
+	b.WriteString(strings.Join(args, " \\\n  "))
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// ethID returns the number of an ethN interface name.
+func ethID(name string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(name, "eth"))
+	if err != nil || !strings.HasPrefix(name, "eth") || id < 1 {
+		return 0, fmt.Errorf("interface %q is not an ethN interface", name)
+	}
+	return id, nil
+}
+
+// macPrefix returns the locally administered MAC prefix of the VM NICs of a
+// node, derived from the node name so links between VMs don't share MACs.
+func macPrefix(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	s := h.Sum32()
+	return fmt.Sprintf("52:54:%02x:%02x:%02x", byte(s>>16), byte(s>>8), byte(s))
+}
+
+// defaults sets the computed defaults of the node, such as its services on
+// the console port and constraints fitting the VM, the static defaults are
+// taken from the QEMU vendor profile.
+func defaults(pb *tpb.Node) (*tpb.Node, error) {
+	profile.Embedded().Fill(tpb.Vendor_QEMU, pb)
+	if pb.Config.EntryCommand == "" {
+		pb.Config.EntryCommand = fmt.Sprintf("kubectl exec -it %s -- telnet 127.0.0.1 %d", pb.Name, consolePort)
+	}
+	vm := pb.Config.Vm
+	if pb.Services == nil {
+		pb.Services = map[uint32]*tpb.Service{
+			22: {
+				Name:   "ssh",
+				Inside: 22,
+			},
+			consolePort: {
+				Name:   "console",
+				Inside: consolePort,
+			},
+		}
+	}
+	if pb.Constraints == nil {
+		pb.Constraints = map[string]string{
+			"cpu":    strconv.Itoa(int(vm.Cpus)),
+			"memory": fmt.Sprintf("%dMi", vm.MemoryMb+memoryOverheadMB),
+		}
+	}
+	if len(pb.Config.Command) == 0 {
+		script, err := launcher(pb)
+		if err != nil {
+			return nil, err
+		}
+		pb.Config.Command = []string{"/bin/sh", "-c", script}
+	}
+	return pb, nil
+}
+
+func init() {
+	node.Register(tpb.Node_CISCO_CSR, New)
+	node.Vendor(tpb.Vendor_QEMU, New)
+}
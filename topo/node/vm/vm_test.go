@@ -0,0 +1,284 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		desc    string
+		ni      *node.Impl
+		wantPB  *tpb.Node
+		wantErr string
+	}{{
+		desc:    "nil node impl",
+		wantErr: "nodeImpl cannot be nil",
+	}, {
+		desc:    "nil pb",
+		wantErr: "nodeImpl.Proto cannot be nil",
+		ni:      &node.Impl{},
+	}, {
+		desc: "csr defaults",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name: "csr1",
+				Type: tpb.Node_CISCO_CSR,
+				Config: &tpb.Config{
+					Command: []string{"launch"},
+				},
+			},
+		},
+		wantPB: &tpb.Node{
+			Name:  "csr1",
+			Type:  tpb.Node_CISCO_CSR,
+			Model: "csr",
+			Config: &tpb.Config{
+				Command:      []string{"launch"},
+				Image:        "csr:latest",
+				ConfigPath:   "/config",
+				ConfigFile:   "iosxe_config.txt",
+				EntryCommand: "kubectl exec -it csr1 -- telnet 127.0.0.1 5000",
+				Vm: &tpb.VMConfig{
+					DiskImage:    "/disk.qcow2",
+					Cpus:         1,
+					MemoryMb:     4096,
+					NicModel:     "virtio-net-pci",
+					ReadyPattern: "Press RETURN to get started",
+				},
+			},
+			Services: map[uint32]*tpb.Service{
+				22:   {Name: "ssh", Inside: 22},
+				5000: {Name: "console", Inside: 5000},
+			},
+			Constraints: map[string]string{
+				"cpu":    "1",
+				"memory": "4608Mi",
+			},
+		},
+	}, {
+		desc: "vm config",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name:   "vm1",
+				Vendor: tpb.Vendor_QEMU,
+				Config: &tpb.Config{
+					Command: []string{"launch"},
+					Image:   "appliance:1.0",
+					Vm: &tpb.VMConfig{
+						DiskImage: "/images/appliance.qcow2",
+						Cpus:      2,
+						MemoryMb:  1024,
+						NicModel:  "e1000",
+					},
+				},
+				Services: map[uint32]*tpb.Service{
+					443: {Name: "ssl", Inside: 443},
+				},
+			},
+		},
+		wantPB: &tpb.Node{
+			Name:   "vm1",
+			Vendor: tpb.Vendor_QEMU,
+			Config: &tpb.Config{
+				Command:      []string{"launch"},
+				Image:        "appliance:1.0",
+				ConfigPath:   "/config",
+				ConfigFile:   "startup-config.cfg",
+				EntryCommand: "kubectl exec -it vm1 -- telnet 127.0.0.1 5000",
+				Vm: &tpb.VMConfig{
+					DiskImage:    "/images/appliance.qcow2",
+					Cpus:         2,
+					MemoryMb:     1024,
+					NicModel:     "e1000",
+					ReadyPattern: "login:",
+				},
+			},
+			Services: map[uint32]*tpb.Service{
+				443: {Name: "ssl", Inside: 443},
+			},
+			Constraints: map[string]string{
+				"cpu":    "2",
+				"memory": "1536Mi",
+			},
+		},
+	}, {
+		desc: "invalid interface",
+		ni: &node.Impl{
+			Proto: &tpb.Node{
+				Name: "vm1",
+				Interfaces: map[string]*tpb.Interface{
+					"ge-0/0/0": {},
+				},
+			},
+		},
+		wantErr: `interface "ge-0/0/0" is not an ethN interface`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			impl, err := New(tt.ni)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("unexpected error: got: %v, want: %s", err, s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if !proto.Equal(impl.GetProto(), tt.wantPB) {
+				t.Fatalf("New() failed: got\n%swant\n%s", prototext.Format(impl.GetProto()), prototext.Format(tt.wantPB))
+			}
+		})
+	}
+}
+
+func TestLauncher(t *testing.T) {
+	impl, err := New(&node.Impl{
+		Proto: &tpb.Node{
+			Name: "vm1",
+			Config: &tpb.Config{
+				ConfigData: &tpb.Config_Data{Data: []byte("hostname vm1\n")},
+			},
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {},
+				"eth3": {},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	cmd := impl.GetProto().GetConfig().GetCommand()
+	if len(cmd) != 3 || cmd[0] != "/bin/sh" || cmd[1] != "-c" {
+		t.Fatalf("New() unexpected command: %q", cmd)
+	}
+	script := cmd[2]
+	mac := macPrefix("vm1")
+	for _, want := range []string{
+		"if [ -c /dev/kvm ]; then ACCEL='-enable-kvm -cpu host'; fi\n",
+		"ip tuntap add tap1 mode tap\n",
+		"tc filter add dev eth1 parent ffff: protocol all u32 match u8 0 0 action mirred egress redirect dev tap1\n",
+		"tc filter add dev tap1 parent ffff: protocol all u32 match u8 0 0 action mirred egress redirect dev eth1\n",
+		"ip tuntap add tap2 mode tap\n",
+		"tc filter add dev eth3 parent ffff: protocol all u32 match u8 0 0 action mirred egress redirect dev tap3\n",
+		"genisoimage -quiet -l -o /tmp/config.iso /config\n",
+		"exec qemu-system-x86_64 $ACCEL -display none",
+		"-smp 1 -m 2048",
+		"-drive if=ide,file=/disk.qcow2 -snapshot",
+		"port=5000,server=on,wait=off,telnet=on,logfile=/tmp/console.log -serial chardev:console",
+		"-netdev user,id=mgmt,hostfwd=tcp::22-:22 -device virtio-net-pci,netdev=mgmt,mac=" + mac + ":00",
+		"-netdev tap,id=net2,ifname=tap2,script=no,downscript=no -device virtio-net-pci,netdev=net2,mac=" + mac + ":02",
+		"-cdrom /tmp/config.iso\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("launcher script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "dev eth2") {
+		t.Errorf("launcher script connects missing interface eth2:\n%s", script)
+	}
+	if macPrefix("vm2") == mac {
+		t.Errorf("macPrefix() returned the same prefix %q for different nodes", mac)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	ki := fake.NewSimpleClientset()
+	impl, err := New(&node.Impl{
+		KubeClient: ki,
+		Namespace:  "test",
+		Proto: &tpb.Node{
+			Name: "csr1",
+			Type: tpb.Node_CISCO_CSR,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := impl.Create(context.Background()); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	p, err := ki.CoreV1().Pods("test").Get(context.Background(), "csr1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	want := []string{"grep", "-qF", "Press RETURN to get started", "/tmp/console.log"}
+	if s := cmp.Diff(want, p.Spec.Containers[0].ReadinessProbe.Exec.Command); s != "" {
+		t.Errorf("Create() unexpected readiness probe (-want +got):\n%s", s)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		desc   string
+		status corev1.PodStatus
+		want   node.Status
+	}{{
+		desc:   "pending",
+		status: corev1.PodStatus{Phase: corev1.PodPending},
+		want:   node.StatusPending,
+	}, {
+		desc:   "booting",
+		status: corev1.PodStatus{Phase: corev1.PodRunning},
+		want:   node.StatusPending,
+	}, {
+		desc: "booted",
+		status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodReady,
+				Status: corev1.ConditionTrue,
+			}},
+		},
+		want: node.StatusRunning,
+	}, {
+		desc:   "failed",
+		status: corev1.PodStatus{Phase: corev1.PodFailed},
+		want:   node.StatusFailed,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ki := fake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "vm1", Namespace: "test"},
+				Status:     tt.status,
+			})
+			impl, err := New(&node.Impl{
+				KubeClient: ki,
+				Namespace:  "test",
+				Proto:      &tpb.Node{Name: "vm1"},
+			})
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			got, err := impl.Status(context.Background())
+			if err != nil {
+				t.Fatalf("Status() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Status() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
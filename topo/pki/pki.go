@@ -0,0 +1,207 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pki provides the certificate authority of a topology, which issues
+// the certificates of the nodes so clients can verify the node servers.
+package pki
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// SecretName is the name of the Secret holding the CA of a topology in
+	// the topology namespace.
+	SecretName = "kne-ca"
+	// DefaultKeySize is the RSA key size used when none is requested.
+	DefaultKeySize = 2048
+	// Validity is the validity period of the CA and the issued certificates.
+	Validity = 365 * 24 * time.Hour
+)
+
+// CA is a certificate authority issuing node certificates.
+type CA struct {
+	cert    *x509.Certificate
+	key     *rsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// NewCA returns a new self-signed CA with the common name cn.
+func NewCA(cn string) (*CA, error) {
+	key, err := rsa.GenerateKey(rand.Reader, DefaultKeySize)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"KNE"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(Validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM)
+}
+
+// Parse returns the CA of the PEM encoded certificate and key.
+func Parse(certPEM, keyPEM []byte) (*CA, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA: %w", err)
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid CA: key is not an RSA key")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("invalid CA: certificate %q is not a CA", cert.Subject.CommonName)
+	}
+	return &CA{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}, nil
+}
+
+// CertPEM returns the PEM encoded certificate of the CA, the bundle clients
+// use to verify the node servers.
+func (c *CA) CertPEM() []byte {
+	return c.certPEM
+}
+
+// Request is a request for a node certificate.
+type Request struct {
+	// CommonName is the common name of the certificate.
+	CommonName string
+	// KeySize is the RSA key size, it defaults to DefaultKeySize.
+	KeySize int
+	// DNSNames and IPs are the subject alternative names of the certificate.
+	DNSNames []string
+	IPs      []net.IP
+}
+
+// Issue returns a new PEM encoded server certificate signed by the CA and its
+// PKCS #8 key.
+func (c *CA) Issue(req *Request) (certPEM, keyPEM []byte, err error) {
+	size := req.KeySize
+	if size == 0 {
+		size = DefaultKeySize
+	}
+	key, err := rsa.GenerateKey(rand.Reader, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: req.CommonName, Organization: []string{"KNE"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     req.DNSNames,
+		IPAddresses:  req.IPs,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, c.cert, &key.PublicKey, c.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if keyPEM, err = encodeKey(key); err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// Secret returns the Secret storing the CA in namespace.
+func (c *CA) Secret(namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       c.certPEM,
+			corev1.TLSPrivateKeyKey: c.keyPEM,
+		},
+	}
+}
+
+// FromSecret returns the CA stored in s.
+func FromSecret(s *corev1.Secret) (*CA, error) {
+	return Parse(s.Data[corev1.TLSCertKey], s.Data[corev1.TLSPrivateKeyKey])
+}
+
+// Bundle returns the PEM encoded certificate of the CA stored in namespace.
+func Bundle(ctx context.Context, kClient kubernetes.Interface, namespace string) ([]byte, error) {
+	s, err := kClient.CoreV1().Secrets(namespace).Get(ctx, SecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CA of %q: %w", namespace, err)
+	}
+	return s.Data[corev1.TLSCertKey], nil
+}
+
+// CertPool returns a pool of the PEM encoded CA certificates in b.
+func CertPool(b []byte) (*x509.CertPool, error) {
+	p := x509.NewCertPool()
+	if !p.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found in CA bundle")
+	}
+	return p, nil
+}
+
+func encodeKey(key *rsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
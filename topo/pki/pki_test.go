@@ -0,0 +1,150 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package pki
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	corev1 "k8s.io/api/core/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIssue(t *testing.T) {
+	ca, err := NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	certPEM, keyPEM, err := ca.Issue(&Request{
+		CommonName: "r1",
+		KeySize:    1024,
+		DNSNames:   []string{"r1"},
+		IPs:        []net.IP{net.ParseIP("10.1.1.1"), net.ParseIP("192.168.18.100")},
+	})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatalf("Issue() returned mismatched cert and key: %v", err)
+	}
+	if b, _ := pem.Decode(keyPEM); b == nil || b.Type != "PRIVATE KEY" {
+		t.Errorf("Issue() returned key that is not PKCS #8: %s", keyPEM)
+	}
+	b, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		t.Fatalf("Issue() returned invalid cert: %v", err)
+	}
+	if got := cert.PublicKey.(interface{ Size() int }).Size() * 8; got != 1024 {
+		t.Errorf("Issue() got key size %d, want 1024", got)
+	}
+	pool, err := CertPool(ca.CertPEM())
+	if err != nil {
+		t.Fatalf("CertPool() failed: %v", err)
+	}
+	for _, name := range []string{"r1", "10.1.1.1", "192.168.18.100"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: name, Roots: pool}); err != nil {
+			t.Errorf("Verify(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "r2", Roots: pool}); err == nil {
+		t.Errorf("Verify(\"r2\") succeeded, want error")
+	}
+}
+
+func TestSecret(t *testing.T) {
+	ca, err := NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	s := ca.Secret("test")
+	if s.Name != SecretName || s.Namespace != "test" {
+		t.Errorf("Secret() got %s/%s, want test/%s", s.Namespace, s.Name, SecretName)
+	}
+	got, err := FromSecret(s)
+	if err != nil {
+		t.Fatalf("FromSecret() failed: %v", err)
+	}
+	if s := cmp.Diff(ca.CertPEM(), got.CertPEM()); s != "" {
+		t.Errorf("FromSecret() unexpected CA (-want +got):\n%s", s)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	ca, err := NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	kClient := kfake.NewSimpleClientset(ca.Secret("test"))
+	got, err := Bundle(context.Background(), kClient, "test")
+	if err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+	if s := cmp.Diff(ca.CertPEM(), got); s != "" {
+		t.Errorf("Bundle() unexpected bundle (-want +got):\n%s", s)
+	}
+	_, err = Bundle(context.Background(), kClient, "other")
+	if s := errdiff.Substring(err, "failed to get CA"); s != "" {
+		t.Errorf("Bundle() unexpected error: %s", s)
+	}
+}
+
+func TestParse(t *testing.T) {
+	ca, err := NewCA("test CA")
+	if err != nil {
+		t.Fatalf("NewCA() failed: %v", err)
+	}
+	leaf, leafKey, err := ca.Issue(&Request{CommonName: "r1", KeySize: 1024})
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	s := ca.Secret("test")
+	tests := []struct {
+		desc    string
+		cert    []byte
+		key     []byte
+		wantErr string
+	}{{
+		desc: "valid",
+		cert: s.Data[corev1.TLSCertKey],
+		key:  s.Data[corev1.TLSPrivateKeyKey],
+	}, {
+		desc:    "mismatched key",
+		cert:    s.Data[corev1.TLSCertKey],
+		key:     leafKey,
+		wantErr: "invalid CA",
+	}, {
+		desc:    "not a CA",
+		cert:    leaf,
+		key:     leafKey,
+		wantErr: "is not a CA",
+	}, {
+		desc:    "empty",
+		wantErr: "invalid CA",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Parse(tt.cert, tt.key)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Errorf("Parse() unexpected error: %s", s)
+			}
+		})
+	}
+}
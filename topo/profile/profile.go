@@ -0,0 +1,345 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package profile implements vendor profiles, the default node configs of the
+// vendors. The profiles embedded in KNE can be overridden by profile files.
+package profile
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//go:embed profiles.yaml
+var embedded []byte
+
+// embeddedProfiles are the profiles embedded in KNE, which are checked by the
+// tests so they cannot fail to parse.
+var embeddedProfiles = func() *Profiles {
+	p, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return p
+}()
+
+// Embedded returns the profiles embedded in KNE. Vendor implementations
+// default nodes created without a topology manager from them.
+func Embedded() *Profiles {
+	return embeddedProfiles
+}
+
+// Key identifies the nodes a profile applies to. An empty model or version
+// matches all models or versions.
+type Key struct {
+	Vendor  tpb.Vendor
+	Model   string
+	Version string
+}
+
+// matches returns true if the profile key k applies to the node key n.
+func (k Key) matches(n Key) bool {
+	return k.Vendor == n.Vendor && (k.Model == "" || k.Model == n.Model) && (k.Version == "" || k.Version == n.Version)
+}
+
+// specificity orders the profiles matching a node from least to most
+// specific.
+func (k Key) specificity() int {
+	s := 0
+	if k.Model != "" {
+		s += 2
+	}
+	if k.Version != "" {
+		s++
+	}
+	return s
+}
+
+// Profile is the default node config of the nodes matching its key. Nodes
+// without model of one of Types get the model of the profile.
+type Profile struct {
+	Key
+	Types []tpb.Node_Type
+	Node  *tpb.Node
+}
+
+// Profiles are layers of profiles, later layers override earlier ones.
+type Profiles struct {
+	layers [][]*Profile
+}
+
+type profileFile struct {
+	Profiles []struct {
+		Vendor  string          `json:"vendor"`
+		Model   string          `json:"model"`
+		Version string          `json:"version"`
+		Types   []string        `json:"types"`
+		Node    json.RawMessage `json:"node"`
+	} `json:"profiles"`
+}
+
+// parse parses the YAML profiles in b.
+func parse(b []byte) ([]*Profile, error) {
+	var f profileFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	var ps []*Profile
+	for _, p := range f.Profiles {
+		v, ok := tpb.Vendor_value[p.Vendor]
+		if !ok || v == int32(tpb.Vendor_UNKNOWN) {
+			return nil, fmt.Errorf("invalid vendor %q", p.Vendor)
+		}
+		var types []tpb.Node_Type
+		for _, t := range p.Types {
+			tv, ok := tpb.Node_Type_value[t]
+			if !ok || tv == int32(tpb.Node_UNKNOWN) {
+				return nil, fmt.Errorf("invalid type %q of %s profile", t, p.Vendor)
+			}
+			types = append(types, tpb.Node_Type(tv))
+		}
+		if len(types) != 0 && p.Model == "" {
+			return nil, fmt.Errorf("types of %s profile without model", p.Vendor)
+		}
+		n := &tpb.Node{}
+		if len(p.Node) != 0 {
+			if err := protojson.Unmarshal(p.Node, n); err != nil {
+				return nil, fmt.Errorf("invalid node of %s profile: %w", p.Vendor, err)
+			}
+		}
+		ps = append(ps, &Profile{
+			Key:   Key{Vendor: tpb.Vendor(v), Model: p.Model, Version: p.Version},
+			Types: types,
+			Node:  n,
+		})
+	}
+	return ps, nil
+}
+
+// Load returns the embedded profiles overridden by the profiles of files, in
+// order.
+func Load(files ...string) (*Profiles, error) {
+	ps, err := parse(embedded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embedded profiles: %w", err)
+	}
+	p := &Profiles{layers: [][]*Profile{ps}}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		ps, err := parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profiles %s: %w", f, err)
+		}
+		p.layers = append(p.layers, ps)
+	}
+	return p, nil
+}
+
+// Keys returns the keys of all profiles, sorted by vendor, model and version.
+func (p *Profiles) Keys() []Key {
+	seen := map[Key]bool{}
+	var keys []Key
+	for _, l := range p.layers {
+		for _, pr := range l {
+			if !seen[pr.Key] {
+				seen[pr.Key] = true
+				keys = append(keys, pr.Key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Vendor != b.Vendor {
+			return a.Vendor < b.Vendor
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Version < b.Version
+	})
+	return keys
+}
+
+// Defaults returns the effective defaults of the nodes with key k. Each layer
+// overrides the earlier layers and, within a layer, more specific profiles
+// override less specific ones.
+func (p *Profiles) Defaults(k Key) *tpb.Node {
+	n := &tpb.Node{}
+	for _, l := range p.layers {
+		var ps []*Profile
+		for _, pr := range l {
+			if pr.matches(k) {
+				ps = append(ps, pr)
+			}
+		}
+		sort.SliceStable(ps, func(i, j int) bool { return ps[i].specificity() < ps[j].specificity() })
+		for _, pr := range ps {
+			overlay(n.ProtoReflect(), proto.Clone(pr.Node).ProtoReflect())
+		}
+	}
+	return n
+}
+
+// Versions returns the catalogued versions of the model of the vendor, the
+// versions of its profiles.
+func (p *Profiles) Versions(v tpb.Vendor, model string) []string {
+	seen := map[string]bool{}
+	var versions []string
+	for _, k := range p.Keys() {
+		if k.Vendor == v && k.Version != "" && (k.Model == "" || k.Model == model) && !seen[k.Version] {
+			seen[k.Version] = true
+			versions = append(versions, k.Version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Apply sets the fields n leaves unset to the defaults of its profiles. The
+// model of n is defaulted first as it selects the profiles. An error is
+// returned if n has a version which is not catalogued for its vendor and
+// model. Vendors without profiles, such as those of plugins, interpret the
+// version themselves and are not checked.
+func (p *Profiles) Apply(n *tpb.Node) error {
+	if n.GetVendor() == tpb.Vendor_UNKNOWN {
+		return nil
+	}
+	p.defaultModel(n.Vendor, n)
+	if n.Version != "" && p.hasVendor(n.Vendor) {
+		versions := p.Versions(n.Vendor, n.Model)
+		known := false
+		for _, v := range versions {
+			known = known || v == n.Version
+		}
+		switch {
+		case len(versions) == 0:
+			return fmt.Errorf("node %s: unknown version %q of vendor %v model %q, the model has no catalogued versions", n.Name, n.Version, n.Vendor, n.Model)
+		case !known:
+			return fmt.Errorf("node %s: unknown version %q of vendor %v model %q, known versions: %s", n.Name, n.Version, n.Vendor, n.Model, strings.Join(versions, ", "))
+		}
+	}
+	p.Fill(n.Vendor, n)
+	return nil
+}
+
+// hasVendor returns true if there are profiles of vendor v.
+func (p *Profiles) hasVendor(v tpb.Vendor) bool {
+	for _, k := range p.Keys() {
+		if k.Vendor == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill sets the fields n leaves unset to the defaults of the profiles of
+// vendor v, which may differ from the vendor of n for nodes selected by type.
+// Unlike Apply the version of n is not checked against the catalogue.
+func (p *Profiles) Fill(v tpb.Vendor, n *tpb.Node) {
+	p.defaultModel(v, n)
+	fill(n.ProtoReflect(), p.Defaults(Key{Vendor: v, Model: n.Model, Version: n.Version}).ProtoReflect())
+}
+
+// defaultModel sets the model of n to the model of its type, or else to the
+// default model of vendor v, if unset, as the model selects the profiles.
+func (p *Profiles) defaultModel(v tpb.Vendor, n *tpb.Node) {
+	if n.Model != "" {
+		return
+	}
+	if m := p.typeModel(v, n.Type); m != "" {
+		n.Model = m
+		return
+	}
+	n.Model = p.Defaults(Key{Vendor: v, Version: n.Version}).GetModel()
+}
+
+// typeModel returns the model of the nodes of type t of vendor v, the model
+// of the last profile of v listing t, or "" if none does.
+func (p *Profiles) typeModel(v tpb.Vendor, t tpb.Node_Type) string {
+	var model string
+	if t == tpb.Node_UNKNOWN {
+		return model
+	}
+	for _, l := range p.layers {
+		for _, pr := range l {
+			if pr.Vendor != v {
+				continue
+			}
+			for _, pt := range pr.Types {
+				if pt == t {
+					model = pr.Model
+				}
+			}
+		}
+	}
+	return model
+}
+
+// overlay sets the fields of dst set in src. Lists are replaced, maps are
+// merged by key and messages are overlaid.
+func overlay(dst, src protoreflect.Message) {
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			m := dst.Mutable(fd).Map()
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				m.Set(k, mv)
+				return true
+			})
+		case fd.Message() != nil && !fd.IsList() && dst.Has(fd):
+			overlay(dst.Mutable(fd).Message(), v.Message())
+		default:
+			dst.Set(fd, v)
+		}
+		return true
+	})
+}
+
+// fill sets the fields of dst unset in dst to those of src. Messages are
+// filled, maps of scalars such as env are filled by key, lists and other maps
+// are only set if empty and oneof fields only if no field of the oneof is
+// set.
+func fill(dst, src protoreflect.Message) {
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.ContainingOneof() != nil && dst.WhichOneof(fd.ContainingOneof()) != nil && !dst.Has(fd):
+		case fd.IsMap() && fd.MapValue().Message() == nil:
+			m := dst.Mutable(fd).Map()
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				if !m.Has(k) {
+					m.Set(k, mv)
+				}
+				return true
+			})
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap() && dst.Has(fd):
+			fill(dst.Mutable(fd).Message(), v.Message())
+		case !dst.Has(fd):
+			dst.Set(fd, v)
+		}
+		return true
+	})
+}
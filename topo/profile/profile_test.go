@@ -0,0 +1,306 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/h-fam/errdiff"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+func writeProfiles(t *testing.T, s string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(p, []byte(s), 0644); err != nil {
+		t.Fatalf("failed to write profiles: %v", err)
+	}
+	return p
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		desc    string
+		file    string
+		wantErr string
+	}{{
+		desc: "valid",
+		file: "profiles:\n- vendor: ARISTA\n  node:\n    config:\n      image: ceos:4.28\n",
+	}, {
+		desc:    "invalid vendor",
+		file:    "profiles:\n- vendor: ACME\n",
+		wantErr: `invalid vendor "ACME"`,
+	}, {
+		desc:    "invalid node",
+		file:    "profiles:\n- vendor: ARISTA\n  node:\n    image: ceos:4.28\n",
+		wantErr: "invalid node of ARISTA profile",
+	}, {
+		desc:    "invalid type",
+		file:    "profiles:\n- vendor: JUNIPER\n  model: vmx\n  types: [VMX]\n",
+		wantErr: `invalid type "VMX" of JUNIPER profile`,
+	}, {
+		desc:    "types without model",
+		file:    "profiles:\n- vendor: JUNIPER\n  types: [JUNIPER_VMX]\n",
+		wantErr: "types of JUNIPER profile without model",
+	}, {
+		desc:    "invalid yaml",
+		file:    "profiles: [",
+		wantErr: "invalid profiles",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Load(writeProfiles(t, tt.file))
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Load() unexpected error: %s", s)
+			}
+		})
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("Load() of missing file succeeded, want error")
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	p, err := Load(writeProfiles(t, `
+profiles:
+- vendor: ARISTA
+  node:
+    config:
+      image: ceos:4.28
+- vendor: ARISTA
+  version: "4.30"
+  node:
+    config:
+      image: ceos:4.30
+      env:
+        EXTRA: "1"
+    constraints:
+      memory: 2Gi
+`))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	tests := []struct {
+		desc        string
+		key         Key
+		wantImage   string
+		wantEnv     map[string]string
+		wantMemory  string
+		wantCommand int
+	}{{
+		desc:        "embedded",
+		key:         Key{Vendor: tpb.Vendor_CISCO, Model: "8201"},
+		wantImage:   "ios-xr:latest",
+		wantMemory:  "12Gi",
+		wantCommand: 0,
+	}, {
+		desc:        "vendor override",
+		key:         Key{Vendor: tpb.Vendor_ARISTA},
+		wantImage:   "ceos:4.28",
+		wantMemory:  "1Gi",
+		wantCommand: 7,
+	}, {
+		desc:        "version override",
+		key:         Key{Vendor: tpb.Vendor_ARISTA, Version: "4.30"},
+		wantImage:   "ceos:4.30",
+		wantEnv:     map[string]string{"EXTRA": "1"},
+		wantMemory:  "2Gi",
+		wantCommand: 7,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := p.Defaults(tt.key)
+			if got := n.GetConfig().GetImage(); got != tt.wantImage {
+				t.Errorf("Defaults(%v) got image %q, want %q", tt.key, got, tt.wantImage)
+			}
+			if got := n.GetConstraints()["memory"]; got != tt.wantMemory {
+				t.Errorf("Defaults(%v) got memory %q, want %q", tt.key, got, tt.wantMemory)
+			}
+			if got := len(n.GetConfig().GetCommand()); got != tt.wantCommand {
+				t.Errorf("Defaults(%v) got %d command args, want %d", tt.key, got, tt.wantCommand)
+			}
+			for k, v := range tt.wantEnv {
+				if got := n.GetConfig().GetEnv()[k]; got != v {
+					t.Errorf("Defaults(%v) got env %s=%q, want %q", tt.key, k, got, v)
+				}
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	p, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	n := &tpb.Node{
+		Name:   "r1",
+		Vendor: tpb.Vendor_CISCO,
+		Config: &tpb.Config{
+			Image:      "xrd:7.8",
+			ConfigData: &tpb.Config_File{File: "r1.cfg"},
+		},
+		Constraints: map[string]string{"cpu": "2"},
+	}
+	if err := p.Apply(n); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	want := &tpb.Node{
+		Name:   "r1",
+		Vendor: tpb.Vendor_CISCO,
+		Model:  "xrd",
+		Config: &tpb.Config{
+			Image:      "xrd:7.8",
+			ConfigPath: "/",
+			ConfigFile: "startup.cfg",
+			ConfigData: &tpb.Config_File{File: "r1.cfg"},
+		},
+		Services: map[uint32]*tpb.Service{
+			443:  {Name: "ssl", Inside: 443},
+			22:   {Name: "ssh", Inside: 22},
+			6030: {Name: "gnmi", Inside: 57400},
+		},
+		Constraints: map[string]string{"cpu": "2", "memory": "2Gi"},
+	}
+	if !proto.Equal(n, want) {
+		t.Errorf("Apply() got\n%swant\n%s", prototext.Format(n), prototext.Format(want))
+	}
+	vmx := &tpb.Node{Name: "r3", Vendor: tpb.Vendor_JUNIPER, Type: tpb.Node_JUNIPER_VMX}
+	if err := p.Apply(vmx); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if vmx.GetModel() != "vmx" || vmx.GetConfig().GetImage() != "vrnetlab/vr-vmx:latest" {
+		t.Errorf("Apply() of JUNIPER_VMX node got model %q image %q, want vmx vrnetlab/vr-vmx:latest", vmx.GetModel(), vmx.GetConfig().GetImage())
+	}
+	typed := &tpb.Node{Name: "r2", Type: tpb.Node_ARISTA_CEOS}
+	if err := p.Apply(typed); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if !proto.Equal(typed, &tpb.Node{Name: "r2", Type: tpb.Node_ARISTA_CEOS}) {
+		t.Errorf("Apply() changed node without vendor: %v", typed)
+	}
+}
+
+// TestEmbedded checks the embedded profiles parse and are the profiles
+// loaded without files.
+func TestEmbedded(t *testing.T) {
+	p, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got, want := Embedded().Keys(), p.Keys(); len(got) == 0 || len(got) != len(want) {
+		t.Fatalf("Embedded() got %d profiles, want %d", len(got), len(want))
+	}
+}
+
+func TestApplyVersion(t *testing.T) {
+	p, err := Load(writeProfiles(t, `
+profiles:
+- vendor: ARISTA
+  version: "4.28.0F"
+  node:
+    config:
+      image: registry.example.com/ceos:4.28.0F
+      env:
+        FEATURE: "1"
+- vendor: ARISTA
+  version: "4.30.1F"
+  node:
+    config:
+      image: registry.example.com/ceos:4.30.1F
+`))
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	tests := []struct {
+		desc      string
+		node      *tpb.Node
+		wantImage string
+		wantEnv   map[string]string
+		wantErr   string
+	}{{
+		desc:      "catalogued version",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Version: "4.28.0F"},
+		wantImage: "registry.example.com/ceos:4.28.0F",
+		wantEnv:   map[string]string{"FEATURE": "1", "CEOS": "1"},
+	}, {
+		desc:      "env merged",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Version: "4.28.0F", Config: &tpb.Config{Env: map[string]string{"FEATURE": "2"}}},
+		wantImage: "registry.example.com/ceos:4.28.0F",
+		wantEnv:   map[string]string{"FEATURE": "2", "CEOS": "1"},
+	}, {
+		desc:      "image set",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Version: "4.30.1F", Config: &tpb.Config{Image: "ceos:mine"}},
+		wantImage: "ceos:mine",
+	}, {
+		desc:    "unknown version",
+		node:    &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Version: "4.29.0F"},
+		wantErr: `node r1: unknown version "4.29.0F" of vendor ARISTA model "", known versions: 4.28.0F, 4.29.1F, 4.30.1F`,
+	}, {
+		desc:      "no version",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA},
+		wantImage: "ceos:latest",
+	}, {
+		desc:      "embedded cisco catalogue",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_CISCO, Version: "7.8.1"},
+		wantImage: "ios-xr/xrd-control-plane:7.8.1",
+	}, {
+		desc:      "embedded arista catalogue",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_ARISTA, Version: "4.29.1F"},
+		wantImage: "ceos:4.29.1F",
+	}, {
+		desc:    "model without catalogue",
+		node:    &tpb.Node{Name: "r1", Vendor: tpb.Vendor_CISCO, Model: "8201", Version: "7.8.1"},
+		wantErr: `unknown version "7.8.1" of vendor CISCO model "8201", the model has no catalogued versions`,
+	}, {
+		desc:    "vendor without catalogue",
+		node:    &tpb.Node{Name: "r1", Vendor: tpb.Vendor_HOST, Version: "1.0"},
+		wantErr: `unknown version "1.0" of vendor HOST model "", the model has no catalogued versions`,
+	}, {
+		desc:      "vendor without profiles",
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_KEYSIGHT, Version: "0.0.1-9999"},
+		wantImage: "",
+	}, {
+		desc:    "embedded catalogue",
+		node:    &tpb.Node{Name: "r1", Vendor: tpb.Vendor_FRR, Version: "7.5"},
+		wantErr: `unknown version "7.5" of vendor FRR`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := p.Apply(tt.node)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Apply() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if got := tt.node.GetConfig().GetImage(); got != tt.wantImage {
+				t.Errorf("Apply() got image %q, want %q", got, tt.wantImage)
+			}
+			for k, v := range tt.wantEnv {
+				if got := tt.node.GetConfig().GetEnv()[k]; got != v {
+					t.Errorf("Apply() got env %s=%q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+	if got, want := p.Versions(tpb.Vendor_ARISTA, ""), []string{"4.28.0F", "4.29.1F", "4.30.1F"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Versions() got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/openconfig/gnmi/errlist"
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// SnapshotLabel is set on the ConfigMaps storing snapshots. Its value is
+	// the name of the snapshot.
+	SnapshotLabel = "kne.openconfig.net/snapshot"
+
+	snapshotTopologyFile = "topology.pb.txt"
+	snapshotLinksFile    = "links.yaml"
+	snapshotConfigExt    = ".config"
+)
+
+// Snapshot is the saved state of a topology, which can be restored without
+// recreating the pods of the topology.
+type Snapshot struct {
+	// Name is the name of the snapshot.
+	Name string
+	// Topology is the topology the snapshot was taken from.
+	Topology *tpb.Topology
+	// Links are the meshnet topologies with the link state of each node at
+	// the time of the snapshot.
+	Links []*topologyv1.Topology
+	// Configs are the running configs of the nodes keyed by node name. Nodes
+	// not implementing node.ConfigGetter are not included.
+	Configs map[string][]byte
+}
+
+// forEachNode calls fn for each node in parallel and returns the errors of
+// the calls.
+func forEachNode(nodes []node.Node, fn func(node.Node) error) error {
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n node.Node) {
+			defer wg.Done()
+			if err := fn(n); err != nil {
+				errs[i] = fmt.Errorf("node %q: %w", n.Name(), err)
+			}
+		}(i, n)
+	}
+	wg.Wait()
+	var errList errlist.List
+	for _, err := range errs {
+		errList.Add(err)
+	}
+	return errList.Err()
+}
+
+// Snapshot captures the running config of the nodes, the link state and the
+// topology into a snapshot named name.
+func (m *Manager) Snapshot(ctx context.Context, name string) (*Snapshot, error) {
+	links, err := m.TopologyResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{
+		Name:     name,
+		Topology: proto.Clone(m.proto).(*tpb.Topology),
+		Links:    links,
+		Configs:  map[string][]byte{},
+	}
+	var getters []node.Node
+	for _, n := range m.Nodes() {
+		if _, ok := n.(node.ConfigGetter); !ok {
+			log.Infof("Skipping node %q not a ConfigGetter", n.Name())
+			continue
+		}
+		getters = append(getters, n)
+	}
+	var mu sync.Mutex
+	if err := forEachNode(getters, func(n node.Node) error {
+		log.Infof("Saving config of %q", n.Name())
+		b, err := n.(node.ConfigGetter).ConfigGet(ctx)
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			log.Infof("Skipping node %q: %v", n.Name(), err)
+			return nil
+		case err != nil:
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		s.Configs[n.Name()] = b
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Restore returns the nodes of the topology to the state in the snapshot. The
+// nodes with a config in the snapshot are reset and the saved config pushed
+// to them in parallel.
+func (m *Manager) Restore(ctx context.Context, s *Snapshot) error {
+	if got, want := s.Topology.GetName(), m.proto.GetName(); got != want {
+		return fmt.Errorf("snapshot %q is of topology %q, not %q", s.Name, got, want)
+	}
+	var nodes []node.Node
+	for _, name := range s.nodeNames() {
+		n, ok := m.nodes[name]
+		if !ok {
+			return fmt.Errorf("node %q not found", name)
+		}
+		if _, ok := n.(node.Resetter); !ok {
+			return fmt.Errorf("node %q is not resettable", name)
+		}
+		if _, ok := n.(node.ConfigPusher); !ok {
+			return fmt.Errorf("node %q is not a ConfigPusher", name)
+		}
+		nodes = append(nodes, n)
+	}
+	return forEachNode(nodes, func(n node.Node) error {
+		log.Infof("Restoring config of %q", n.Name())
+		if err := n.(node.Resetter).ResetCfg(ctx); err != nil {
+			return err
+		}
+		return n.(node.ConfigPusher).ConfigPush(ctx, bytes.NewReader(s.Configs[n.Name()]))
+	})
+}
+
+// nodeNames returns the sorted names of the nodes with a config in s.
+func (s *Snapshot) nodeNames() []string {
+	var names []string
+	for name := range s.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// files returns the snapshot as files keyed by name.
+func (s *Snapshot) files() (map[string][]byte, error) {
+	links, err := yaml.Marshal(s.Links)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{
+		snapshotTopologyFile: []byte(prototext.Format(s.Topology)),
+		snapshotLinksFile:    links,
+	}
+	for name, b := range s.Configs {
+		files[name+snapshotConfigExt] = b
+	}
+	return files, nil
+}
+
+// snapshotFromFiles returns the snapshot stored in files.
+func snapshotFromFiles(name string, files map[string][]byte) (*Snapshot, error) {
+	b, ok := files[snapshotTopologyFile]
+	if !ok {
+		return nil, fmt.Errorf("snapshot %q: missing %s", name, snapshotTopologyFile)
+	}
+	s := &Snapshot{
+		Name:     name,
+		Topology: &tpb.Topology{},
+		Configs:  map[string][]byte{},
+	}
+	if err := prototext.Unmarshal(b, s.Topology); err != nil {
+		return nil, fmt.Errorf("snapshot %q: %w", name, err)
+	}
+	if err := yaml.Unmarshal(files[snapshotLinksFile], &s.Links); err != nil {
+		return nil, fmt.Errorf("snapshot %q: %w", name, err)
+	}
+	for f, b := range files {
+		if strings.HasSuffix(f, snapshotConfigExt) {
+			s.Configs[strings.TrimSuffix(f, snapshotConfigExt)] = b
+		}
+	}
+	return s, nil
+}
+
+// WriteDir writes the snapshot to dir, one file per node config along with
+// the topology and the link state.
+func (s *Snapshot) WriteDir(dir string) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, b := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotDir reads the snapshot written to dir by WriteDir. The name of
+// the snapshot is the base name of dir.
+func ReadSnapshotDir(dir string) (*Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = b
+	}
+	return snapshotFromFiles(filepath.Base(dir), files)
+}
+
+// SnapshotConfigMapName returns the name of the ConfigMap storing the
+// snapshot name.
+func SnapshotConfigMapName(name string) string {
+	return "kne-snapshot-" + name
+}
+
+// ConfigMap returns the snapshot as a ConfigMap in the topology namespace.
+// ConfigMaps are limited to 1MiB so large configs should be saved with
+// WriteDir instead.
+func (s *Snapshot) ConfigMap() (*corev1.ConfigMap, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SnapshotConfigMapName(s.Name),
+			Namespace: s.Topology.GetName(),
+			Labels: map[string]string{
+				TopologyLabel: s.Topology.GetName(),
+				SnapshotLabel: s.Name,
+			},
+		},
+		BinaryData: files,
+	}, nil
+}
+
+// SnapshotFromConfigMap returns the snapshot stored in cm.
+func SnapshotFromConfigMap(cm *corev1.ConfigMap) (*Snapshot, error) {
+	name := cm.Labels[SnapshotLabel]
+	if name == "" {
+		return nil, fmt.Errorf("configmap %q is not a snapshot", cm.Name)
+	}
+	return snapshotFromFiles(name, cm.BinaryData)
+}
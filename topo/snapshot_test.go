@@ -0,0 +1,315 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/h-fam/errdiff"
+	topologyclientv1 "github.com/openconfig/kne/api/clientset/v1beta1"
+	tfake "github.com/openconfig/kne/api/clientset/v1beta1/fake"
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	"google.golang.org/protobuf/testing/protocmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	rfake "k8s.io/client-go/rest/fake"
+)
+
+var (
+	pushedMu sync.Mutex
+	pushed   = map[string]string{}
+)
+
+type configurable struct {
+	*node.Impl
+}
+
+func (c *configurable) ConfigGet(context.Context) ([]byte, error) {
+	if c.Name() == "fail" {
+		return nil, fmt.Errorf("show running-config failed")
+	}
+	return []byte("hostname " + c.Name() + "\n"), nil
+}
+
+func (c *configurable) ResetCfg(context.Context) error {
+	if c.Name() == "fail" {
+		return fmt.Errorf("reset failed")
+	}
+	pushedMu.Lock()
+	defer pushedMu.Unlock()
+	pushed[c.Name()] = ""
+	return nil
+}
+
+func (c *configurable) ConfigPush(_ context.Context, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	pushedMu.Lock()
+	defer pushedMu.Unlock()
+	pushed[c.Name()] += string(b)
+	return nil
+}
+
+func init() {
+	node.Register(tpb.Node_Type(1002), func(impl *node.Impl) (node.Node, error) {
+		return &configurable{Impl: impl}, nil
+	})
+}
+
+var snapshotLinks = []*topologyv1.Topology{{
+	ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "t1"},
+	Spec: topologyv1.TopologySpec{
+		Links: []topologyv1.Link{{LocalIntf: "eth1", PeerIntf: "eth1", PeerPod: "r2", UID: 1}},
+	},
+	Status: topologyv1.TopologyStatus{SrcIP: "10.0.0.1"},
+}}
+
+// snapshotManager returns a loaded manager of a topology with the nodes
+// whose meshnet topologies are snapshotLinks.
+func snapshotManager(t *testing.T, nodes ...*tpb.Node) TopologyManager {
+	t.Helper()
+	m, err := New("", &tpb.Topology{Name: "t1", Nodes: nodes},
+		WithClusterConfig(&rest.Config{}), WithKubeClient(kfake.NewSimpleClientset()), WithTopoClient(fakeTopoClient(t, snapshotLinks)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	return m
+}
+
+// fakeTopoClient returns a topology clientset listing the meshnet topologies.
+func fakeTopoClient(t *testing.T, topos []*topologyv1.Topology) *topologyclientv1.Clientset {
+	t.Helper()
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	tl := &topologyv1.TopologyList{}
+	for _, l := range topos {
+		tl.Items = append(tl.Items, *l)
+	}
+	b, err := json.Marshal(tl)
+	if err != nil {
+		t.Fatalf("failed to marshal topologies: %v", err)
+	}
+	tf.SetRestClient(&rfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		GroupVersion:         *topologyclientv1.GV(),
+		VersionedAPIPath:     topologyv1.GroupVersion,
+		Resp: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewReader(b)),
+		},
+	})
+	return tf
+}
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		desc    string
+		nodes   []*tpb.Node
+		want    map[string][]byte
+		wantErr string
+	}{{
+		desc: "success",
+		nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1002)},
+			{Name: "r2", Type: tpb.Node_Type(1002)},
+			{Name: "h1", Type: tpb.Node_HOST},
+		},
+		want: map[string][]byte{
+			"r1": []byte("hostname r1\n"),
+			"r2": []byte("hostname r2\n"),
+		},
+	}, {
+		desc: "config get failure",
+		nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1002)},
+			{Name: "fail", Type: tpb.Node_Type(1002)},
+		},
+		wantErr: `node "fail": show running-config failed`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m := snapshotManager(t, tt.nodes...)
+			s, err := m.Snapshot(context.Background(), "base")
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Snapshot() unexpected error: %s", s)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if s.Name != "base" || s.Topology.GetName() != "t1" {
+				t.Errorf("Snapshot() got name %q of topology %q, want \"base\" of \"t1\"", s.Name, s.Topology.GetName())
+			}
+			if d := cmp.Diff(tt.want, s.Configs); d != "" {
+				t.Errorf("Snapshot() unexpected configs (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(snapshotLinks, s.Links); d != "" {
+				t.Errorf("Snapshot() unexpected links (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestSnapshotStorage(t *testing.T) {
+	want := &Snapshot{
+		Name:     "base",
+		Topology: &tpb.Topology{Name: "t1", Nodes: []*tpb.Node{{Name: "r1"}}},
+		Links:    snapshotLinks,
+		Configs: map[string][]byte{
+			"r1": []byte("hostname r1\n"),
+		},
+	}
+	dir := filepath.Join(t.TempDir(), "base")
+	if err := want.WriteDir(dir); err != nil {
+		t.Fatalf("WriteDir() failed: %v", err)
+	}
+	got, err := ReadSnapshotDir(dir)
+	if err != nil {
+		t.Fatalf("ReadSnapshotDir() failed: %v", err)
+	}
+	if d := cmp.Diff(want, got, protocmp.Transform()); d != "" {
+		t.Errorf("ReadSnapshotDir() unexpected snapshot (-want +got):\n%s", d)
+	}
+	if _, err := ReadSnapshotDir(t.TempDir()); err == nil {
+		t.Errorf("ReadSnapshotDir() of empty dir succeeded, want error")
+	}
+
+	cm, err := want.ConfigMap()
+	if err != nil {
+		t.Fatalf("ConfigMap() failed: %v", err)
+	}
+	if cm.Name != "kne-snapshot-base" || cm.Namespace != "t1" {
+		t.Errorf("ConfigMap() got %s/%s, want t1/kne-snapshot-base", cm.Namespace, cm.Name)
+	}
+	got, err = SnapshotFromConfigMap(cm)
+	if err != nil {
+		t.Fatalf("SnapshotFromConfigMap() failed: %v", err)
+	}
+	if d := cmp.Diff(want, got, protocmp.Transform()); d != "" {
+		t.Errorf("SnapshotFromConfigMap() unexpected snapshot (-want +got):\n%s", d)
+	}
+	if _, err := SnapshotFromConfigMap(&corev1.ConfigMap{}); err == nil {
+		t.Errorf("SnapshotFromConfigMap() of unlabeled configmap succeeded, want error")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		desc    string
+		nodes   []*tpb.Node
+		snap    *Snapshot
+		want    map[string]string
+		wantErr string
+	}{{
+		desc: "success",
+		nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1002)},
+			{Name: "r2", Type: tpb.Node_Type(1002)},
+			{Name: "h1", Type: tpb.Node_HOST},
+		},
+		snap: &Snapshot{
+			Name:     "base",
+			Topology: &tpb.Topology{Name: "t1"},
+			Configs: map[string][]byte{
+				"r1": []byte("hostname base1\n"),
+				"r2": []byte("hostname base2\n"),
+			},
+		},
+		want: map[string]string{
+			"r1": "hostname base1\n",
+			"r2": "hostname base2\n",
+		},
+	}, {
+		desc:  "wrong topology",
+		nodes: []*tpb.Node{{Name: "r1", Type: tpb.Node_Type(1002)}},
+		snap: &Snapshot{
+			Name:     "base",
+			Topology: &tpb.Topology{Name: "t2"},
+		},
+		wantErr: `is of topology "t2"`,
+	}, {
+		desc:  "missing node",
+		nodes: []*tpb.Node{{Name: "r1", Type: tpb.Node_Type(1002)}},
+		snap: &Snapshot{
+			Name:     "base",
+			Topology: &tpb.Topology{Name: "t1"},
+			Configs:  map[string][]byte{"dne": nil},
+		},
+		wantErr: `node "dne" not found`,
+	}, {
+		desc:  "not resettable",
+		nodes: []*tpb.Node{{Name: "c1", Type: tpb.Node_Type(1001)}},
+		snap: &Snapshot{
+			Name:     "base",
+			Topology: &tpb.Topology{Name: "t1"},
+			Configs:  map[string][]byte{"c1": nil},
+		},
+		wantErr: "not resettable",
+	}, {
+		desc: "reset failure",
+		nodes: []*tpb.Node{
+			{Name: "r1", Type: tpb.Node_Type(1002)},
+			{Name: "fail", Type: tpb.Node_Type(1002)},
+		},
+		snap: &Snapshot{
+			Name:     "base",
+			Topology: &tpb.Topology{Name: "t1"},
+			Configs: map[string][]byte{
+				"r1":   []byte("hostname base1\n"),
+				"fail": []byte("hostname base2\n"),
+			},
+		},
+		want: map[string]string{
+			"r1": "hostname base1\n",
+		},
+		wantErr: `node "fail": reset failed`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pushed = map[string]string{}
+			m := snapshotManager(t, tt.nodes...)
+			err := m.Restore(context.Background(), tt.snap)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Restore() unexpected error: %s", s)
+			}
+			if tt.want == nil {
+				tt.want = map[string]string{}
+			}
+			if d := cmp.Diff(tt.want, pushed); d != "" {
+				t.Errorf("Restore() unexpected pushed configs (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -18,8 +18,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"os/user"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +30,7 @@ import (
 	"github.com/kr/pretty"
 	cpb "github.com/openconfig/kne/proto/controller"
 	"github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -34,6 +38,7 @@ import (
 	"google.golang.org/protobuf/encoding/prototext"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -45,12 +50,28 @@ import (
 	_ "github.com/openconfig/kne/topo/node/ceos"
 	_ "github.com/openconfig/kne/topo/node/cisco"
 	_ "github.com/openconfig/kne/topo/node/cptx"
+	_ "github.com/openconfig/kne/topo/node/frr"
 	_ "github.com/openconfig/kne/topo/node/gobgp"
 	_ "github.com/openconfig/kne/topo/node/host"
 	_ "github.com/openconfig/kne/topo/node/ixia"
 	_ "github.com/openconfig/kne/topo/node/srl"
+	_ "github.com/openconfig/kne/topo/node/vm"
 )
 
+const (
+	// TopologyLabel is set on every namespace created by KNE. Its value is
+	// the name of the topology deployed in the namespace.
+	TopologyLabel = "kne.openconfig.net/topology"
+	// CreatorAnnotation records the user that created the topology.
+	CreatorAnnotation = "kne.openconfig.net/creator"
+	// NodesAnnotation records the number of nodes in the topology.
+	NodesAnnotation = "kne.openconfig.net/nodes"
+)
+
+// collectTimeout is the time allowed to collect the support bundle of a
+// topology that failed to be created.
+var collectTimeout = 2 * time.Minute
+
 var protojsonUnmarshaller = protojson.UnmarshalOptions{
 	AllowPartial:   true,
 	DiscardUnknown: false,
@@ -58,9 +79,16 @@ var protojsonUnmarshaller = protojson.UnmarshalOptions{
 
 // TopologyManager manages a topology.
 type TopologyManager interface {
+	// AuditWiring compares the LLDP neighbors of the nodes with the links of
+	// the topology.
+	AuditWiring(context.Context, *node.GNMIOptions) ([]*WiringResult, error)
 	CheckNodeStatus(context.Context, time.Duration) error
+	// Collect writes a support bundle of the topology to the writer.
+	Collect(context.Context, io.Writer) error
 	ConfigPush(context.Context, string, io.Reader) error
 	Delete(context.Context) error
+	// GenerateCert creates the cert of the node from its cert config.
+	GenerateCert(context.Context, node.Node) error
 	Load(context.Context) error
 	Node(string) (node.Node, error)
 	Nodes() []node.Node
@@ -74,7 +102,17 @@ type TopologyManager interface {
 	TopologyResources(ctx context.Context) ([]*topologyv1.Topology, error)
 	Push(context.Context) error
 	Resources(context.Context) (*Resources, error)
+	// Restore resets the nodes of the topology and pushes the configs saved
+	// in the snapshot.
+	Restore(context.Context, *Snapshot) error
+	// Snapshot saves the running configs of the nodes and the link state of
+	// the topology.
+	Snapshot(context.Context, string) (*Snapshot, error)
+	// Namespace returns the namespace the topology is deployed in.
+	Namespace() string
 	TopologyProto() *tpb.Topology
+	// Verify checks that the links of the topology pass traffic.
+	Verify(context.Context) ([]*LinkResult, error)
 	Watch(context.Context) error
 }
 
@@ -87,6 +125,15 @@ type Manager struct {
 	rCfg     *rest.Config
 	proto    *tpb.Topology
 	nodes    map[string]node.Node
+	// instance is the name of the instance of the topology, if the topology
+	// is deployed more than once.
+	instance string
+	// namespace is the namespace the topology is deployed in, the topology
+	// name by default.
+	namespace string
+	// profileFiles override the embedded vendor profiles.
+	profileFiles []string
+	profiles     *profile.Profiles
 }
 
 type Option func(m *Manager)
@@ -121,6 +168,61 @@ func WithBasePath(s string) Option {
 	}
 }
 
+// WithProfiles layers the vendor profiles of files, in order, over the
+// embedded vendor profiles.
+func WithProfiles(files ...string) Option {
+	return func(m *Manager) {
+		m.profileFiles = append(m.profileFiles, files...)
+	}
+}
+
+// WithInstance names the instance of the topology. The instance is deployed in
+// the namespace of the topology suffixed with the instance name, so the same
+// topology can be deployed more than once.
+func WithInstance(name string) Option {
+	return func(m *Manager) {
+		m.instance = name
+	}
+}
+
+// WithNamespace deploys the topology in namespace instead of the namespace
+// named after the topology.
+func WithNamespace(namespace string) Option {
+	return func(m *Manager) {
+		m.namespace = namespace
+	}
+}
+
+// Namespace returns the namespace the topology pb is deployed in with opts.
+func Namespace(pb *tpb.Topology, opts ...Option) (string, error) {
+	m := &Manager{proto: pb}
+	for _, o := range opts {
+		o(m)
+	}
+	if err := m.setNamespace(); err != nil {
+		return "", err
+	}
+	return m.namespace, nil
+}
+
+// setNamespace sets the namespace of the topology from its name, namespace
+// and instance options.
+func (m *Manager) setNamespace() error {
+	if m.namespace == "" {
+		m.namespace = m.proto.GetName()
+	}
+	if m.instance != "" {
+		m.namespace = fmt.Sprintf("%s-%s", m.namespace, m.instance)
+	}
+	if m.namespace == m.proto.GetName() {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(m.namespace); len(errs) != 0 {
+		return fmt.Errorf("invalid namespace %q: %s", m.namespace, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
 // New creates a new topology manager based on the provided kubecfg and topology.
 func New(kubecfg string, pb *tpb.Topology, opts ...Option) (TopologyManager, error) {
 	m := &Manager{
@@ -134,7 +236,15 @@ func New(kubecfg string, pb *tpb.Topology, opts ...Option) (TopologyManager, err
 	if m.proto == nil {
 		return nil, fmt.Errorf("topology protobuf cannot be nil")
 	}
-	log.Infof("Creating manager for: %s", m.proto.Name)
+	if err := m.setNamespace(); err != nil {
+		return nil, err
+	}
+	profiles, err := profile.Load(m.profileFiles...)
+	if err != nil {
+		return nil, err
+	}
+	m.profiles = profiles
+	log.Infof("Creating manager for: %s in namespace %s", m.proto.Name, m.namespace)
 	if m.rCfg == nil {
 		// use the current context in kubeconfig try in-cluster first if not fallback to kubeconfig
 		log.Infof("Trying in-cluster configuration")
@@ -176,6 +286,11 @@ func (m *Manager) Load(ctx context.Context) error {
 				if n.Interfaces[k].IntName == "" {
 					n.Interfaces[k].IntName = k
 				}
+				for _, a := range n.Interfaces[k].Addresses {
+					if _, _, err := net.ParseCIDR(a); err != nil {
+						return fmt.Errorf("invalid topology: invalid address %q of interface %s:%s", a, n.Name, k)
+					}
+				}
 			}
 		}
 		nMap[n.Name] = n
@@ -213,15 +328,22 @@ func (m *Manager) Load(ctx context.Context) error {
 		}
 		aInt.PeerName = l.ZNode
 		aInt.PeerIntName = l.ZInt
+		aInt.PeerAddresses = append([]string{}, zInt.Addresses...)
 		aInt.Uid = int64(uid)
 		zInt.PeerName = l.ANode
 		zInt.PeerIntName = l.AInt
+		zInt.PeerAddresses = append([]string{}, aInt.Addresses...)
 		zInt.Uid = int64(uid)
 		uid++
 	}
 	for k, n := range nMap {
 		log.Infof("Adding Node: %s:%s:%s", n.Name, n.Vendor, n.Type)
-		nn, err := node.New(m.proto.Name, n, m.kClient, m.rCfg, m.BasePath, m.kubecfg)
+		if m.profiles != nil {
+			if err := m.profiles.Apply(n); err != nil {
+				return fmt.Errorf("failed to load topology: %w", err)
+			}
+		}
+		nn, err := node.New(m.namespace, n, m.kClient, m.rCfg, m.BasePath, m.kubecfg)
 		if err != nil {
 			return fmt.Errorf("failed to load topology: %w", err)
 		}
@@ -230,26 +352,45 @@ func (m *Manager) Load(ctx context.Context) error {
 	return nil
 }
 
-// TopologyResources gets the topology CRDs for the cluster.
+// TopologyResources gets the topology CRDs of the topology, other topologies
+// may share its namespace.
 func (m *Manager) TopologyResources(ctx context.Context) ([]*topologyv1.Topology, error) {
-	topology, err := m.tClient.Topology(m.proto.Name).List(ctx, metav1.ListOptions{})
+	topology, err := m.tClient.Topology(m.namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get topology CRDs: %v", err)
 	}
 
-	items := make([]*topologyv1.Topology, len(topology.Items))
-	for i := range items {
-		items[i] = &topology.Items[i]
+	var items []*topologyv1.Topology
+	for i := range topology.Items {
+		if m.ownsMeshnet(&topology.Items[i]) {
+			items = append(items, &topology.Items[i])
+		}
 	}
 
 	return items, nil
 }
 
+// ownsMeshnet returns true if the meshnet topology t belongs to the topology.
+// Meshnet topologies are labelled with the topology name, those created
+// without label belong to the topology if they are named after its nodes.
+func (m *Manager) ownsMeshnet(t *topologyv1.Topology) bool {
+	if name, ok := t.Labels[TopologyLabel]; ok {
+		return name == m.proto.GetName()
+	}
+	_, ok := m.nodes[t.Name]
+	return ok
+}
+
 // Topology returns the topology protobuf.
 func (m *Manager) TopologyProto() *tpb.Topology {
 	return m.proto
 }
 
+// Namespace returns the namespace the topology is deployed in.
+func (m *Manager) Namespace() string {
+	return m.namespace
+}
+
 // setLinkPeer finds the peer pod name and peer interface name for a given interface
 func setLinkPeer(nodeName string, podName string, link *topologyv1.Link, peerSpecs []*topologyv1.Topology) error {
 	for _, peerSpec := range peerSpecs {
@@ -305,11 +446,18 @@ func (m *Manager) TopologySpecs(ctx context.Context) ([]*topologyv1.Topology, er
 
 // Push pushes the current topology to k8s.
 func (m *Manager) Push(ctx context.Context) error {
-	if _, err := m.kClient.CoreV1().Namespaces().Get(ctx, m.proto.Name, metav1.GetOptions{}); err != nil {
-		log.Infof("Creating namespace for topology: %q", m.proto.Name)
+	if _, err := m.kClient.CoreV1().Namespaces().Get(ctx, m.namespace, metav1.GetOptions{}); err != nil {
+		log.Infof("Creating namespace %q for topology: %q", m.namespace, m.proto.Name)
 		ns := &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: m.proto.Name,
+				Name: m.namespace,
+				Labels: map[string]string{
+					TopologyLabel: m.proto.Name,
+				},
+				Annotations: map[string]string{
+					CreatorAnnotation: currentUser(),
+					NodesAnnotation:   strconv.Itoa(len(m.nodes)),
+				},
 			},
 		}
 		sNs, err := m.kClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
@@ -331,7 +479,7 @@ func (m *Manager) Push(ctx context.Context) error {
 		log.Infof("Node %q resource created", k)
 	}
 	for _, n := range m.nodes {
-		err := GenerateSelfSigned(ctx, n)
+		err := m.GenerateCert(ctx, n)
 		switch {
 		default:
 			return fmt.Errorf("failed to generate cert for node %s: %w", n.Name(), err)
@@ -343,15 +491,19 @@ func (m *Manager) Push(ctx context.Context) error {
 
 // CreateMeshnetTopologies creates meshnet resources for all available nodes
 func (m *Manager) CreateMeshnetTopologies(ctx context.Context) error {
-	log.Infof("Getting topology specs for namespace %s", m.proto.Name)
+	log.Infof("Getting topology specs for namespace %s", m.namespace)
 	topologies, err := m.TopologySpecs(ctx)
 	if err != nil {
 		return fmt.Errorf("could not get meshnet topologies: %v", err)
 	}
-	log.Tracef("Got topology specs for namespace %s: %+v", m.proto.Name, topologies)
+	log.Tracef("Got topology specs for namespace %s: %+v", m.namespace, topologies)
 	for _, t := range topologies {
+		if t.Labels == nil {
+			t.Labels = map[string]string{}
+		}
+		t.Labels[TopologyLabel] = m.proto.Name
 		log.Infof("Creating topology for meshnet node %s", t.ObjectMeta.Name)
-		sT, err := m.tClient.Topology(m.proto.Name).Create(ctx, t)
+		sT, err := m.tClient.Topology(m.namespace).Create(ctx, t)
 		if err != nil {
 			return fmt.Errorf("could not create topology for meshnet node %s: %v", t.ObjectMeta.Name, err)
 		}
@@ -366,7 +518,7 @@ func (m *Manager) DeleteMeshnetTopologies(ctx context.Context) error {
 	nodes, err := m.TopologyResources(ctx)
 	if err == nil {
 		for _, n := range nodes {
-			if err := m.tClient.Topology(m.proto.Name).Delete(ctx, n.ObjectMeta.Name, metav1.DeleteOptions{}); err != nil {
+			if err := m.tClient.Topology(m.namespace).Delete(ctx, n.ObjectMeta.Name, metav1.DeleteOptions{}); err != nil {
 				log.Warnf("Error meshnet node %q: %v", n.ObjectMeta.Name, err)
 			}
 		}
@@ -426,10 +578,13 @@ func GenerateSelfSigned(ctx context.Context, n node.Node) error {
 	return nCert.GenerateSelfSigned(ctx)
 }
 
-// Delete deletes the topology from k8s.
+// Delete deletes the topology from k8s. The namespace of the topology is only
+// deleted if it was created for the topology, otherwise only the resources of
+// the topology are deleted from it.
 func (m *Manager) Delete(ctx context.Context) error {
-	if _, err := m.kClient.CoreV1().Namespaces().Get(ctx, m.proto.Name, metav1.GetOptions{}); err != nil {
-		return fmt.Errorf("topology %q does not exist in cluster", m.proto.Name)
+	ns, err := m.kClient.CoreV1().Namespaces().Get(ctx, m.namespace, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("topology %q does not exist in cluster", m.namespace)
 	}
 
 	// Delete topology nodes
@@ -444,9 +599,14 @@ func (m *Manager) Delete(ctx context.Context) error {
 		return err
 	}
 
+	if ns.Labels[TopologyLabel] != m.proto.Name {
+		log.Infof("Keeping namespace %q not created for topology %q", m.namespace, m.proto.Name)
+		return m.deleteCA(ctx)
+	}
+
 	// Delete namespace
 	prop := metav1.DeletePropagationForeground
-	if err := m.kClient.CoreV1().Namespaces().Delete(ctx, m.proto.Name, metav1.DeleteOptions{
+	if err := m.kClient.CoreV1().Namespaces().Delete(ctx, m.namespace, metav1.DeleteOptions{
 		PropagationPolicy: &prop,
 	}); err != nil {
 		return err
@@ -535,12 +695,15 @@ func (m *Manager) Resources(ctx context.Context) (*Resources, error) {
 }
 
 func (m *Manager) Watch(ctx context.Context) error {
-	watcher, err := m.tClient.Topology(m.proto.Name).Watch(ctx, metav1.ListOptions{})
+	watcher, err := m.tClient.Topology(m.namespace).Watch(ctx, metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
 	ch := watcher.ResultChan()
 	for e := range ch {
+		if t, ok := e.Object.(*topologyv1.Topology); ok && !m.ownsMeshnet(t) {
+			continue
+		}
 		fmt.Println(e.Type)
 		pretty.Print(e.Object)
 		fmt.Println("")
@@ -576,6 +739,7 @@ type TopologyParams struct {
 	TopoNewOptions []Option // the options used in the TopoNewFunc
 	Timeout        time.Duration
 	DryRun         bool
+	BundleDir      string // the directory of the support bundle written when creation fails, defaults to os.TempDir()
 }
 
 // CreateTopology creates the topology and configs it.
@@ -588,7 +752,12 @@ func CreateTopology(ctx context.Context, params TopologyParams) error {
 			return fmt.Errorf("failed to load %s: %+v", params.TopoName, err)
 		}
 	}
-	t, err := New(params.Kubecfg, topopb, params.TopoNewOptions...)
+	return createTopology(ctx, params, topopb, params.TopoNewOptions...)
+}
+
+// createTopology creates the topology of topopb with opts and configs it.
+func createTopology(ctx context.Context, params TopologyParams, topopb *tpb.Topology, opts ...Option) error {
+	t, err := new(params.Kubecfg, topopb, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create topology for %s: %+v", params.TopoName, err)
 	}
@@ -601,12 +770,14 @@ func CreateTopology(ctx context.Context, params TopologyParams) error {
 	}
 
 	if err := t.Push(ctx); err != nil {
+		collectOnFailure(t, params.BundleDir)
 		return err
 	}
 	if err := t.CheckNodeStatus(ctx, params.Timeout); err != nil {
+		collectOnFailure(t, params.BundleDir)
 		return err
 	}
-	log.Infof("Topology %q created\n", t.TopologyProto().GetName())
+	log.Infof("Topology %q created in namespace %q\n", t.TopologyProto().GetName(), t.Namespace())
 	r, err := t.Resources(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check resource %s: %+v", params.TopoName, err)
@@ -621,6 +792,23 @@ func CreateTopology(ctx context.Context, params TopologyParams) error {
 	return nil
 }
 
+// collectOnFailure writes the support bundle of a topology that failed to be
+// created to dir. The creation context is usually cancelled or expired at
+// this point so the bundle is collected with a fresh one.
+func collectOnFailure(t TopologyManager, dir string) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	p, err := CollectToDir(ctx, t, dir)
+	if err != nil {
+		log.Errorf("Failed to collect support bundle: %v", err)
+		return
+	}
+	log.Errorf("Topology creation failed, support bundle written to %s", p)
+}
+
 // DeleteTopology deletes the topology.
 func DeleteTopology(ctx context.Context, params TopologyParams) error {
 	var topopb *tpb.Topology
@@ -631,7 +819,12 @@ func DeleteTopology(ctx context.Context, params TopologyParams) error {
 			return fmt.Errorf("failed to load %s: %+v", params.TopoName, err)
 		}
 	}
-	t, err := New(params.Kubecfg, topopb, params.TopoNewOptions...)
+	return deleteTopology(ctx, params, topopb, params.TopoNewOptions...)
+}
+
+// deleteTopology deletes the topology of topopb created with opts.
+func deleteTopology(ctx context.Context, params TopologyParams, topopb *tpb.Topology, opts ...Option) error {
+	t, err := New(params.Kubecfg, topopb, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to delete topology for %s: %+v", params.TopoName, err)
 	}
@@ -648,8 +841,9 @@ func DeleteTopology(ctx context.Context, params TopologyParams) error {
 	return nil
 }
 
-// serviceToProto creates the service mapping.
-func serviceToProto(s *corev1.Service, m map[uint32]*tpb.Service) error {
+// ServiceToProto adds the ports of a k8s service to the map of node services
+// keyed by inside port.
+func ServiceToProto(s *corev1.Service, m map[uint32]*tpb.Service) error {
 	if s == nil || m == nil {
 		return fmt.Errorf("service and map must not be nil")
 	}
@@ -718,6 +912,88 @@ func (s *sMap) TopoState() cpb.TopologyState {
 	return cpb.TopologyState_TOPOLOGY_STATE_UNKNOWN
 }
 
+// State returns the aggregate state of a topology given the status of each
+// of its nodes.
+func State(nodes map[string]node.Status) cpb.TopologyState {
+	s := &sMap{}
+	for name, state := range nodes {
+		s.SetNodeState(name, state)
+	}
+	return s.TopoState()
+}
+
+// currentUser returns the name of the user running KNE, used to annotate the
+// topologies it creates.
+var currentUser = func() string {
+	u, err := user.Current()
+	if err != nil {
+		log.Warnf("Failed to get current user: %v", err)
+		return ""
+	}
+	return u.Username
+}
+
+// TopologyInfo is a summary of a topology deployed in the cluster.
+type TopologyInfo struct {
+	Name      string
+	Namespace string
+	Nodes     int
+	State     cpb.TopologyState
+	Created   time.Time
+	Creator   string
+}
+
+// List returns a summary of all KNE topologies in the cluster sorted by name.
+// Only namespaces labelled by KNE are considered.
+func List(ctx context.Context, kClient kubernetes.Interface) ([]*TopologyInfo, error) {
+	nsList, err := kClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: TopologyLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topology namespaces: %w", err)
+	}
+	var infos []*TopologyInfo
+	for _, ns := range nsList.Items {
+		info := &TopologyInfo{
+			Name:      ns.Labels[TopologyLabel],
+			Namespace: ns.Name,
+			Created:   ns.CreationTimestamp.Time,
+			Creator:   ns.Annotations[CreatorAnnotation],
+		}
+		if v, ok := ns.Annotations[NodesAnnotation]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				log.Warnf("Invalid node count %q for topology %q: %v", v, info.Name, err)
+			}
+			info.Nodes = n
+		}
+		pods, err := kClient.CoreV1().Pods(ns.Name).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods for topology %q: %w", info.Name, err)
+		}
+		sMap := &sMap{}
+		for _, p := range pods.Items {
+			switch p.Status.Phase {
+			case corev1.PodFailed:
+				sMap.SetNodeState(p.Name, node.StatusFailed)
+			case corev1.PodRunning:
+				sMap.SetNodeState(p.Name, node.StatusRunning)
+			default:
+				sMap.SetNodeState(p.Name, node.StatusPending)
+			}
+		}
+		info.State = sMap.TopoState()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name != infos[j].Name {
+			return infos[i].Name < infos[j].Name
+		}
+		return infos[i].Namespace < infos[j].Namespace
+	})
+	return infos, nil
+}
+
 // GetTopologyServices returns the topology information.
 func GetTopologyServices(ctx context.Context, params TopologyParams) (*cpb.ShowTopologyResponse, error) {
 	var topopb *tpb.Topology
@@ -753,7 +1029,7 @@ func GetTopologyServices(ctx context.Context, params TopologyParams) (*cpb.ShowT
 		}
 
 		for _, svc := range services {
-			serviceToProto(svc, n.Services)
+			ServiceToProto(svc, n.Services)
 		}
 	}
 	sMap := &sMap{}
@@ -15,26 +15,38 @@
 package topo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/h-fam/errdiff"
+	topologyclientv1 "github.com/openconfig/kne/api/clientset/v1beta1"
 	tfake "github.com/openconfig/kne/api/clientset/v1beta1/fake"
 	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
 	cpb "github.com/openconfig/kne/proto/controller"
 	tpb "github.com/openconfig/kne/proto/topo"
 	"github.com/openconfig/kne/topo/node"
 	nd "github.com/openconfig/kne/topo/node"
+	"github.com/openconfig/kne/topo/profile"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	rfake "k8s.io/client-go/rest/fake"
 )
 
 func TestLoad(t *testing.T) {
@@ -156,6 +168,10 @@ func (f *defaultFakeTopology) TopologyProto() *tpb.Topology {
 	return nil
 }
 
+func (f *defaultFakeTopology) Namespace() string {
+	return ""
+}
+
 func (f *defaultFakeTopology) Push(context.Context) error {
 	return nil
 }
@@ -164,10 +180,18 @@ func (f *defaultFakeTopology) CheckNodeStatus(context.Context, time.Duration) er
 	return nil
 }
 
+func (f *defaultFakeTopology) Collect(context.Context, io.Writer) error {
+	return nil
+}
+
 func (f *defaultFakeTopology) Delete(context.Context) error {
 	return nil
 }
 
+func (f *defaultFakeTopology) GenerateCert(context.Context, node.Node) error {
+	return nil
+}
+
 func (f *defaultFakeTopology) Nodes() []node.Node {
 	return nil
 }
@@ -176,6 +200,22 @@ func (f *defaultFakeTopology) Resources(context.Context) (*Resources, error) {
 	return nil, nil
 }
 
+func (f *defaultFakeTopology) Restore(context.Context, *Snapshot) error {
+	return nil
+}
+
+func (f *defaultFakeTopology) Snapshot(context.Context, string) (*Snapshot, error) {
+	return nil, nil
+}
+
+func (f *defaultFakeTopology) Verify(context.Context) ([]*LinkResult, error) {
+	return nil, nil
+}
+
+func (f *defaultFakeTopology) AuditWiring(context.Context, *node.GNMIOptions) ([]*WiringResult, error) {
+	return nil, nil
+}
+
 func (f *defaultFakeTopology) Watch(context.Context) error {
 	return nil
 }
@@ -533,3 +573,505 @@ func TestStateMap(t *testing.T) {
 		})
 	}
 }
+
+func TestList(t *testing.T) {
+	created := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	kClient := kfake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "t2",
+				Labels:            map[string]string{TopologyLabel: "t2"},
+				Annotations:       map[string]string{CreatorAnnotation: "bob", NodesAnnotation: "2"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "t1",
+				Labels:      map[string]string{TopologyLabel: "t1"},
+				Annotations: map[string]string{CreatorAnnotation: "alice", NodesAnnotation: "1"},
+			},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "kube-system",
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "t1"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "t2"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "r2", Namespace: "t2"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		},
+	)
+	got, err := List(context.Background(), kClient)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	want := []*TopologyInfo{{
+		Name:      "t1",
+		Namespace: "t1",
+		Nodes:     1,
+		State:     cpb.TopologyState_TOPOLOGY_STATE_RUNNING,
+		Creator:   "alice",
+	}, {
+		Name:      "t2",
+		Namespace: "t2",
+		Nodes:     2,
+		State:     cpb.TopologyState_TOPOLOGY_STATE_CREATING,
+		Created:   created,
+		Creator:   "bob",
+	}}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Fatalf("List() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+func TestPushLabelsNamespace(t *testing.T) {
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	kClient := kfake.NewSimpleClientset()
+	origCurrentUser := currentUser
+	currentUser = func() string { return "alice" }
+	defer func() {
+		currentUser = origCurrentUser
+	}()
+	for _, opts := range [][]Option{nil, {WithInstance("2")}} {
+		m, err := New("", &tpb.Topology{Name: "t1"}, append(opts,
+			WithClusterConfig(&rest.Config{}),
+			WithKubeClient(kClient),
+			WithTopoClient(tf),
+		)...)
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if err := m.Load(context.Background()); err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if err := m.Push(context.Background()); err != nil {
+			t.Fatalf("Push() failed: %v", err)
+		}
+	}
+	got, err := List(context.Background(), kClient)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	want := []*TopologyInfo{{
+		Name:      "t1",
+		Namespace: "t1",
+		Creator:   "alice",
+	}, {
+		Name:      "t1",
+		Namespace: "t1-2",
+		Creator:   "alice",
+	}}
+	if s := cmp.Diff(want, got); s != "" {
+		t.Fatalf("List() unexpected diff (-want +got):\n%s", s)
+	}
+}
+
+// meshnetTopoClient returns a topology clientset storing the meshnet
+// topologies created in the returned map.
+func meshnetTopoClient(t *testing.T) (*topologyclientv1.Clientset, map[string]*topologyv1.Topology) {
+	t.Helper()
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	topos := map[string]*topologyv1.Topology{}
+	tf.SetRestClient(&rfake.RESTClient{
+		NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		GroupVersion:         *topologyclientv1.GV(),
+		VersionedAPIPath:     topologyv1.GroupVersion,
+		Client: rfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			var body any = &metav1.Status{Status: metav1.StatusSuccess}
+			switch req.Method {
+			case http.MethodPost:
+				topo := &topologyv1.Topology{}
+				if err := json.NewDecoder(req.Body).Decode(topo); err != nil {
+					return nil, err
+				}
+				topos[topo.Name] = topo
+				body = topo
+			case http.MethodDelete:
+				delete(topos, path.Base(req.URL.Path))
+			case http.MethodGet:
+				tl := &topologyv1.TopologyList{}
+				for _, topo := range topos {
+					tl.Items = append(tl.Items, *topo)
+				}
+				body = tl
+			}
+			b, err := json.Marshal(body)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(b))}, nil
+		}),
+	})
+	return tf, topos
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	tests := []struct {
+		desc      string
+		namespace *corev1.Namespace
+		wantNS    bool
+	}{{
+		desc:   "created namespace",
+		wantNS: false,
+	}, {
+		desc: "pre-existing namespace",
+		namespace: &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+		},
+		wantNS: true,
+	}, {
+		desc: "namespace of another topology",
+		namespace: &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Labels: map[string]string{TopologyLabel: "t2"}},
+		},
+		wantNS: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tf, meshnet := meshnetTopoClient(t)
+			kClient := kfake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shared"},
+			})
+			if tt.namespace != nil {
+				if _, err := kClient.CoreV1().Namespaces().Create(context.Background(), tt.namespace, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to create namespace: %v", err)
+				}
+			}
+			pb := &tpb.Topology{
+				Name: "t1",
+				Nodes: []*tpb.Node{
+					{Name: "r1", Vendor: tpb.Vendor_HOST, Interfaces: map[string]*tpb.Interface{"eth1": {}}},
+					{Name: "r2", Vendor: tpb.Vendor_HOST, Interfaces: map[string]*tpb.Interface{"eth1": {}}},
+				},
+				Links: []*tpb.Link{{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"}},
+			}
+			m, err := New("", pb,
+				WithClusterConfig(&rest.Config{}),
+				WithKubeClient(kClient),
+				WithTopoClient(tf),
+				WithNamespace("shared"),
+			)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			ctx := context.Background()
+			if err := m.Load(ctx); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if err := m.Push(ctx); err != nil {
+				t.Fatalf("Push() failed: %v", err)
+			}
+			for _, name := range []string{"r1", "r2"} {
+				if got := meshnet[name].Labels[TopologyLabel]; got != "t1" {
+					t.Fatalf("Push() got meshnet topology %s labelled %q, want t1", name, got)
+				}
+			}
+			// Meshnet topologies of other topologies in the namespace, with
+			// and without label, are kept.
+			meshnet["o1"] = &topologyv1.Topology{ObjectMeta: metav1.ObjectMeta{Name: "o1", Labels: map[string]string{TopologyLabel: "t2"}}}
+			meshnet["o2"] = &topologyv1.Topology{ObjectMeta: metav1.ObjectMeta{Name: "o2"}}
+			if err := m.Delete(ctx); err != nil {
+				t.Fatalf("Delete() failed: %v", err)
+			}
+			_, err = kClient.CoreV1().Namespaces().Get(ctx, "shared", metav1.GetOptions{})
+			if gotNS := err == nil; gotNS != tt.wantNS {
+				t.Fatalf("Delete() got namespace %v, want %v", gotNS, tt.wantNS)
+			}
+			if !tt.wantNS {
+				return
+			}
+			pods, err := kClient.CoreV1().Pods("shared").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			var names []string
+			for _, p := range pods.Items {
+				names = append(names, p.Name)
+			}
+			if d := cmp.Diff([]string{"other"}, names); d != "" {
+				t.Errorf("Delete() unexpected pods (-want +got):\n%s", d)
+			}
+			svcs, err := kClient.CoreV1().Services("shared").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list services: %v", err)
+			}
+			if len(svcs.Items) != 0 {
+				t.Errorf("Delete() kept %d services, want 0", len(svcs.Items))
+			}
+			var kept []string
+			for name := range meshnet {
+				kept = append(kept, name)
+			}
+			if d := cmp.Diff([]string{"o1", "o2"}, kept, cmpopts.SortSlices(func(a, b string) bool { return a < b })); d != "" {
+				t.Errorf("Delete() unexpected meshnet topologies (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestWithProfiles(t *testing.T) {
+	tf, err := tfake.NewSimpleClientset()
+	if err != nil {
+		t.Fatalf("cannot create fake topology clientset")
+	}
+	p := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(p, []byte("profiles:\n- vendor: HOST\n  node:\n    config:\n      image: debian:latest\n"), 0644); err != nil {
+		t.Fatalf("failed to write profiles: %v", err)
+	}
+	pb := &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{
+			{Name: "h1", Vendor: tpb.Vendor_HOST},
+			{Name: "h2", Vendor: tpb.Vendor_HOST, Config: &tpb.Config{Image: "busybox:latest"}},
+		},
+	}
+	m, err := New("", pb,
+		WithClusterConfig(&rest.Config{}),
+		WithKubeClient(kfake.NewSimpleClientset()),
+		WithTopoClient(tf),
+		WithProfiles(p),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	for name, want := range map[string]string{"h1": "debian:latest", "h2": "busybox:latest"} {
+		n, err := m.Node(name)
+		if err != nil {
+			t.Fatalf("Node(%q) failed: %v", name, err)
+		}
+		if got := n.GetProto().GetConfig().GetImage(); got != want {
+			t.Errorf("node %s got image %q, want %q", name, got, want)
+		}
+	}
+	if _, err := New("", pb, WithClusterConfig(&rest.Config{}), WithProfiles(filepath.Join(t.TempDir(), "missing.yaml"))); err == nil {
+		t.Errorf("New() with missing profiles succeeded, want error")
+	}
+	pb.Nodes = append(pb.Nodes, &tpb.Node{Name: "r1", Vendor: tpb.Vendor_FRR, Version: "0.1"})
+	m, err = New("", pb,
+		WithClusterConfig(&rest.Config{}),
+		WithKubeClient(kfake.NewSimpleClientset()),
+		WithTopoClient(tf),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err == nil {
+		t.Errorf("Load() with unknown version succeeded, want error")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	pb := &tpb.Topology{Name: "t1"}
+	tests := []struct {
+		desc    string
+		opts    []Option
+		want    string
+		wantErr string
+	}{{
+		desc: "default",
+		want: "t1",
+	}, {
+		desc: "instance",
+		opts: []Option{WithInstance("alice")},
+		want: "t1-alice",
+	}, {
+		desc: "namespace",
+		opts: []Option{WithNamespace("alice")},
+		want: "alice",
+	}, {
+		desc: "namespace and instance",
+		opts: []Option{WithNamespace("alice"), WithInstance("2")},
+		want: "alice-2",
+	}, {
+		desc:    "invalid namespace",
+		opts:    []Option{WithNamespace("alice.t1")},
+		wantErr: `invalid namespace "alice.t1"`,
+	}, {
+		desc:    "invalid instance",
+		opts:    []Option{WithInstance("Alice_1")},
+		wantErr: `invalid namespace "t1-Alice_1"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			m, err := New("", pb, append(tt.opts, WithClusterConfig(&rest.Config{}))...)
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("New() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			if got := m.Namespace(); got != tt.want {
+				t.Errorf("Namespace() got %q, want %q", got, tt.want)
+			}
+			if got, err := Namespace(pb, tt.opts...); err != nil || got != tt.want {
+				t.Errorf("Namespace(%v) got %q, %v, want %q", pb, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAddresses(t *testing.T) {
+	tests := []struct {
+		desc    string
+		pb      string
+		want    map[string][]string
+		wantErr string
+	}{{
+		desc: "peer addresses",
+		pb: `
+name: "t1"
+nodes: { name: "r1" vendor: HOST interfaces: { key: "eth1" value: { addresses: "192.168.0.0/31" addresses: "2001:db8::/127" } } }
+nodes: { name: "r2" vendor: HOST interfaces: { key: "eth1" value: { addresses: "192.168.0.1/31" } } }
+nodes: { name: "r3" vendor: HOST }
+links: { a_node: "r1" a_int: "eth1" z_node: "r2" z_int: "eth1" }
+links: { a_node: "r2" a_int: "eth2" z_node: "r3" z_int: "eth1" }
+`,
+		want: map[string][]string{
+			"r1/eth1": {"192.168.0.1/31"},
+			"r2/eth1": {"192.168.0.0/31", "2001:db8::/127"},
+			"r2/eth2": nil,
+			"r3/eth1": nil,
+		},
+	}, {
+		desc: "invalid address",
+		pb: `
+name: "t1"
+nodes: { name: "r1" vendor: HOST interfaces: { key: "eth1" value: { addresses: "192.168.0.0" } } }
+`,
+		wantErr: `invalid address "192.168.0.0" of interface r1:eth1`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tf, err := tfake.NewSimpleClientset()
+			if err != nil {
+				t.Fatalf("cannot create fake topology clientset")
+			}
+			pb := &tpb.Topology{}
+			if err := prototext.Unmarshal([]byte(tt.pb), pb); err != nil {
+				t.Fatalf("cannot unmarshal topology: %v", err)
+			}
+			m, err := New("", pb,
+				WithClusterConfig(&rest.Config{}),
+				WithKubeClient(kfake.NewSimpleClientset()),
+				WithTopoClient(tf),
+			)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			err = m.Load(context.Background())
+			if s := errdiff.Substring(err, tt.wantErr); s != "" {
+				t.Fatalf("Load() unexpected error: %s", s)
+			}
+			if err != nil {
+				return
+			}
+			got := map[string][]string{}
+			for _, n := range m.Nodes() {
+				for name, intf := range n.GetProto().GetInterfaces() {
+					got[n.Name()+"/"+name] = intf.GetPeerAddresses()
+				}
+			}
+			if s := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); s != "" {
+				t.Errorf("Load() unexpected peer addresses (-want +got):\n%s", s)
+			}
+		})
+	}
+}
+
+// TestEmbeddedProfiles checks nodes created without a topology manager get
+// the same defaults from their vendor implementations as nodes the embedded
+// profiles are applied to.
+func TestEmbeddedProfiles(t *testing.T) {
+	p, err := profile.Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	for _, k := range p.Keys() {
+		if k.Version != "" {
+			// Catalogued versions select their own images.
+			continue
+		}
+		t.Run(k.Vendor.String()+"/"+k.Model, func(t *testing.T) {
+			pb := &tpb.Node{Name: "r1", Vendor: k.Vendor, Model: k.Model, Version: k.Version}
+			want, err := node.New("test", proto.Clone(pb).(*tpb.Node), kfake.NewSimpleClientset(), nil, "", "")
+			if err != nil {
+				t.Fatalf("node.New() failed: %v", err)
+			}
+			if err := p.Apply(pb); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+			got, err := node.New("test", pb, kfake.NewSimpleClientset(), nil, "", "")
+			if err != nil {
+				t.Fatalf("node.New() with profile failed: %v", err)
+			}
+			if !proto.Equal(got.GetProto(), want.GetProto()) {
+				t.Errorf("node.New() with profile got\n%swant\n%s", prototext.Format(got.GetProto()), prototext.Format(want.GetProto()))
+			}
+		})
+	}
+	// The type of a node selects its model before the profiles apply.
+	for _, tt := range []struct {
+		node      *tpb.Node
+		wantModel string
+		wantImage string
+		wantType  string
+	}{{
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_JUNIPER, Type: tpb.Node_JUNIPER_VMX},
+		wantModel: "vmx",
+		wantImage: "vrnetlab/vr-vmx:latest",
+		wantType:  "JUNIPER_VMX",
+	}, {
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_JUNIPER, Type: tpb.Node_JUNIPER_CEVO},
+		wantModel: "cptx",
+		wantImage: "cptx:latest",
+		wantType:  "JUNIPER_CEVO",
+	}, {
+		node:      &tpb.Node{Name: "r1", Vendor: tpb.Vendor_QEMU, Type: tpb.Node_CISCO_CSR},
+		wantModel: "csr",
+		wantImage: "csr:latest",
+	}} {
+		t.Run(tt.node.GetVendor().String()+"/"+tt.node.GetType().String(), func(t *testing.T) {
+			want, err := node.New("test", proto.Clone(tt.node).(*tpb.Node), kfake.NewSimpleClientset(), nil, "", "")
+			if err != nil {
+				t.Fatalf("node.New() failed: %v", err)
+			}
+			pb := proto.Clone(tt.node).(*tpb.Node)
+			if err := p.Apply(pb); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+			got, err := node.New("test", pb, kfake.NewSimpleClientset(), nil, "", "")
+			if err != nil {
+				t.Fatalf("node.New() with profile failed: %v", err)
+			}
+			if !proto.Equal(got.GetProto(), want.GetProto()) {
+				t.Errorf("node.New() with profile got\n%swant\n%s", prototext.Format(got.GetProto()), prototext.Format(want.GetProto()))
+			}
+			gpb := got.GetProto()
+			if gpb.GetModel() != tt.wantModel || gpb.GetConfig().GetImage() != tt.wantImage || gpb.GetLabels()["type"] != tt.wantType {
+				t.Errorf("node.New() with profile got model %q image %q type label %q, want %q %q %q", gpb.GetModel(), gpb.GetConfig().GetImage(), gpb.GetLabels()["type"], tt.wantModel, tt.wantImage, tt.wantType)
+			}
+		})
+	}
+}
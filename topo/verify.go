@@ -0,0 +1,218 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+)
+
+// Statuses of the checks of a link.
+const (
+	CheckPass = "PASS"
+	CheckFail = "FAIL"
+	CheckSkip = "SKIP"
+)
+
+// Check is the result of a check of a link.
+type Check struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// LinkResult is the result of the verification of a link.
+type LinkResult struct {
+	// A and Z are the endpoints of the link as node:interface.
+	A string `json:"a"`
+	Z string `json:"z"`
+	// AInterface and ZInterface check that the interfaces exist and are up
+	// in the pods of the nodes.
+	AInterface Check `json:"a_interface"`
+	ZInterface Check `json:"z_interface"`
+	// Meshnet checks that meshnet has the link and did not skip it.
+	Meshnet Check `json:"meshnet"`
+	// Ping checks that the peer addresses of the link answer pings.
+	Ping Check `json:"ping"`
+}
+
+// CheckNames are the names of the checks of a link, in the order of Checks.
+var CheckNames = []string{"a_interface", "z_interface", "meshnet", "ping"}
+
+// Checks returns the checks of the link in the order of CheckNames.
+func (r *LinkResult) Checks() []Check {
+	return []Check{r.AInterface, r.ZInterface, r.Meshnet, r.Ping}
+}
+
+// Passed returns whether no check of the link failed.
+func (r *LinkResult) Passed() bool {
+	for _, c := range r.Checks() {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks the links of the topology in parallel. For each link both
+// interfaces must exist and be up, meshnet must have created the link and,
+// when the interfaces have addresses, the peer addresses must answer pings.
+func (m *Manager) Verify(ctx context.Context) ([]*LinkResult, error) {
+	topos, err := m.TopologyResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	meshnet := map[string]*topologyv1.Topology{}
+	for _, t := range topos {
+		meshnet[t.Name] = t
+	}
+	links := m.proto.GetLinks()
+	rs := make([]*LinkResult, len(links))
+	var wg sync.WaitGroup
+	for i, l := range links {
+		rs[i] = &LinkResult{
+			A: fmt.Sprintf("%s:%s", l.GetANode(), l.GetAInt()),
+			Z: fmt.Sprintf("%s:%s", l.GetZNode(), l.GetZInt()),
+		}
+		wg.Add(1)
+		go func(r *LinkResult, l *tpb.Link) {
+			defer wg.Done()
+			log.Infof("Verifying link %s %s", r.A, r.Z)
+			r.AInterface = m.checkInterface(ctx, l.GetANode(), l.GetAInt())
+			r.ZInterface = m.checkInterface(ctx, l.GetZNode(), l.GetZInt())
+			r.Meshnet = checkMeshnet(l, meshnet, r.AInterface.Status == CheckPass && r.ZInterface.Status == CheckPass)
+			if r.AInterface.Status != CheckPass || r.ZInterface.Status != CheckPass {
+				r.Ping = Check{Status: CheckSkip, Message: "interfaces not up"}
+				return
+			}
+			r.Ping = m.checkPing(ctx, l)
+		}(rs[i], l)
+	}
+	wg.Wait()
+	return rs, nil
+}
+
+var errNoExec = fmt.Errorf("node does not support exec")
+
+// execNode runs cmd on the node name and returns its stdout, or its stderr
+// in the error if it fails.
+func (m *Manager) execNode(ctx context.Context, name string, cmd []string) (string, error) {
+	n, ok := m.nodes[name]
+	if !ok {
+		return "", fmt.Errorf("node %q not found", name)
+	}
+	e, ok := n.(node.Execer)
+	if !ok {
+		return "", errNoExec
+	}
+	var stdout, stderr bytes.Buffer
+	if err := e.ExecWithOptions(ctx, cmd, &node.ExecOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stdout.String()+"\n"+stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// checkInterface checks that the interface intf of the node name is up.
+func (m *Manager) checkInterface(ctx context.Context, name, intf string) Check {
+	out, err := m.execNode(ctx, name, []string{"cat", fmt.Sprintf("/sys/class/net/%s/operstate", intf)})
+	switch {
+	case err == errNoExec:
+		return Check{Status: CheckSkip, Message: err.Error()}
+	case err != nil:
+		return Check{Status: CheckFail, Message: fmt.Sprintf("interface %s not found: %v", intf, err)}
+	}
+	// Interfaces without carrier detection report an unknown state.
+	switch state := strings.TrimSpace(out); state {
+	case "up", "unknown":
+		return Check{Status: CheckPass}
+	default:
+		return Check{Status: CheckFail, Message: fmt.Sprintf("interface %s is %s", intf, state)}
+	}
+}
+
+// checkMeshnet checks that the meshnet topologies of the nodes of l have the
+// link and whether meshnet skipped it. A skipped link only fails if its
+// interfaces are not up, as meshnet creates skipped links once the peer pod
+// is running.
+func checkMeshnet(l *tpb.Link, meshnet map[string]*topologyv1.Topology, up bool) Check {
+	var skipped []string
+	for _, e := range [][4]string{
+		{l.GetANode(), l.GetAInt(), l.GetZNode(), l.GetZInt()},
+		{l.GetZNode(), l.GetZInt(), l.GetANode(), l.GetAInt()},
+	} {
+		t, ok := meshnet[e[0]]
+		if !ok {
+			return Check{Status: CheckFail, Message: fmt.Sprintf("no meshnet topology of node %s", e[0])}
+		}
+		found := false
+		for _, ml := range t.Spec.Links {
+			if ml.LocalIntf == e[1] && ml.PeerPod == e[2] && ml.PeerIntf == e[3] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Check{Status: CheckFail, Message: fmt.Sprintf("meshnet topology of node %s has no link %s to %s:%s", e[0], e[1], e[2], e[3])}
+		}
+		for _, s := range t.Status.Skipped {
+			if s == e[2] {
+				skipped = append(skipped, fmt.Sprintf("%s listed as skipped in the meshnet topology of %s", e[2], e[0]))
+			}
+		}
+	}
+	if len(skipped) == 0 {
+		return Check{Status: CheckPass}
+	}
+	msg := strings.Join(skipped, ", ")
+	if !up {
+		return Check{Status: CheckFail, Message: msg}
+	}
+	return Check{Status: CheckPass, Message: msg}
+}
+
+// checkPing pings the addresses of the peer interface in the subnets of the
+// addresses of the interface of l, from the A side if it has any.
+func (m *Manager) checkPing(ctx context.Context, l *tpb.Link) Check {
+	for _, e := range [][2]string{{l.GetANode(), l.GetAInt()}, {l.GetZNode(), l.GetZInt()}} {
+		n, ok := m.nodes[e[0]]
+		if !ok {
+			continue
+		}
+		ips, err := node.NeighborAddresses(n.GetProto().GetInterfaces()[e[1]])
+		if err != nil {
+			return Check{Status: CheckFail, Message: err.Error()}
+		}
+		if len(ips) == 0 {
+			continue
+		}
+		for _, ip := range ips {
+			if _, err := m.execNode(ctx, e[0], []string{"ping", "-c", "3", "-W", "2", "-I", e[1], ip.String()}); err != nil {
+				if err == errNoExec {
+					return Check{Status: CheckSkip, Message: err.Error()}
+				}
+				return Check{Status: CheckFail, Message: fmt.Sprintf("ping %s from %s:%s failed: %v", ip, e[0], e[1], err)}
+			}
+		}
+		return Check{Status: CheckPass}
+	}
+	return Check{Status: CheckSkip, Message: "no peer addresses"}
+}
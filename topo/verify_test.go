@@ -0,0 +1,178 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	topologyv1 "github.com/openconfig/kne/api/types/v1beta1"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// linkOutputs are the outputs of the commands run on linkNodes keyed by
+// "<node>: <command>". Other commands fail.
+var linkOutputs = map[string]string{
+	"r1: cat /sys/class/net/eth1/operstate": "up\n",
+	"r1: cat /sys/class/net/eth2/operstate": "up\n",
+	"r2: cat /sys/class/net/eth1/operstate": "up\n",
+	"r2: cat /sys/class/net/eth2/operstate": "unknown\n",
+	"r3: cat /sys/class/net/eth1/operstate": "lowerlayerdown\n",
+	"r3: cat /sys/class/net/eth2/operstate": "up\n",
+	"r1: ping -c 3 -W 2 -I eth1 10.0.0.2":   "3 packets transmitted, 3 received\n",
+	"r4: cat /sys/class/net/eth1/operstate": "up\n",
+	"r5: cat /sys/class/net/eth1/operstate": "up\n",
+}
+
+type linkNode struct {
+	*node.Impl
+}
+
+func (n *linkNode) ExecWithOptions(_ context.Context, cmd []string, opts *node.ExecOptions) error {
+	out, ok := linkOutputs[fmt.Sprintf("%s: %s", n.Name(), strings.Join(cmd, " "))]
+	if !ok {
+		fmt.Fprintf(opts.Stderr, "%s failed", cmd[0])
+		return fmt.Errorf("exit status 1")
+	}
+	fmt.Fprint(opts.Stdout, out)
+	return nil
+}
+
+func init() {
+	node.Register(tpb.Node_Type(1004), func(impl *node.Impl) (node.Node, error) {
+		return &linkNode{Impl: impl}, nil
+	})
+}
+
+func meshnetTopology(name, skipped string, links ...topologyv1.Link) *topologyv1.Topology {
+	t := &topologyv1.Topology{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "t1"},
+		Spec:       topologyv1.TopologySpec{Links: links},
+	}
+	if skipped != "" {
+		t.Status.Skipped = []string{skipped}
+	}
+	return t
+}
+
+func TestVerify(t *testing.T) {
+	typ := tpb.Node_Type(1004)
+	pb := &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Addresses: []string{"10.0.0.1/30"}},
+			},
+		}, {
+			Name: "r2",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Addresses: []string{"10.0.0.2/30"}},
+			},
+		}, {
+			Name: "r3",
+			Type: typ,
+		}, {
+			Name: "r4",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Addresses: []string{"2001:db8:1::1/64"}},
+			},
+		}, {
+			Name: "r5",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth1": {Addresses: []string{"2001:db8:1::2/64"}},
+			},
+		}},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r1", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+			{ANode: "r2", AInt: "eth2", ZNode: "r3", ZInt: "eth2"},
+			{ANode: "r4", AInt: "eth1", ZNode: "r5", ZInt: "eth1"},
+		},
+	}
+	topos := []*topologyv1.Topology{
+		meshnetTopology("r1", "",
+			topologyv1.Link{LocalIntf: "eth1", PeerPod: "r2", PeerIntf: "eth1"},
+			topologyv1.Link{LocalIntf: "eth2", PeerPod: "r3", PeerIntf: "eth1"}),
+		meshnetTopology("r2", "",
+			topologyv1.Link{LocalIntf: "eth1", PeerPod: "r1", PeerIntf: "eth1"},
+			topologyv1.Link{LocalIntf: "eth2", PeerPod: "r3", PeerIntf: "eth2"}),
+		meshnetTopology("r3", "r1",
+			topologyv1.Link{LocalIntf: "eth1", PeerPod: "r1", PeerIntf: "eth2"}),
+		meshnetTopology("r4", "",
+			topologyv1.Link{LocalIntf: "eth1", PeerPod: "r5", PeerIntf: "eth1"}),
+		meshnetTopology("r5", "",
+			topologyv1.Link{LocalIntf: "eth1", PeerPod: "r4", PeerIntf: "eth1"}),
+	}
+	m, err := New("", pb, WithClusterConfig(&rest.Config{}), WithKubeClient(kfake.NewSimpleClientset()), WithTopoClient(fakeTopoClient(t, topos)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	got, err := m.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	pass := Check{Status: CheckPass}
+	want := []*LinkResult{{
+		A:          "r1:eth1",
+		Z:          "r2:eth1",
+		AInterface: pass,
+		ZInterface: pass,
+		Meshnet:    pass,
+		Ping:       pass,
+	}, {
+		A:          "r1:eth2",
+		Z:          "r3:eth1",
+		AInterface: pass,
+		ZInterface: Check{Status: CheckFail, Message: "interface eth1 is lowerlayerdown"},
+		Meshnet:    Check{Status: CheckFail, Message: "r1 listed as skipped in the meshnet topology of r3"},
+		Ping:       Check{Status: CheckSkip, Message: "interfaces not up"},
+	}, {
+		A:          "r2:eth2",
+		Z:          "r3:eth2",
+		AInterface: pass,
+		ZInterface: pass,
+		Meshnet:    Check{Status: CheckFail, Message: "meshnet topology of node r3 has no link eth2 to r2:eth2"},
+		Ping:       Check{Status: CheckSkip, Message: "no peer addresses"},
+	}, {
+		A:          "r4:eth1",
+		Z:          "r5:eth1",
+		AInterface: pass,
+		ZInterface: pass,
+		Meshnet:    pass,
+		Ping:       Check{Status: CheckFail, Message: "ping 2001:db8:1::2 from r4:eth1 failed: exit status 1: ping failed"},
+	}}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("Verify() unexpected results (-want +got):\n%s", d)
+	}
+	for i, wantPassed := range []bool{true, false, false, false} {
+		if got[i].Passed() != wantPassed {
+			t.Errorf("Passed() of link %s %s got %v, want %v", got[i].A, got[i].Z, got[i].Passed(), wantPassed)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openconfig/kne/topo/node"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Statuses of the wiring of an interface.
+const (
+	// WiringOK is the status of an interface whose LLDP neighbor is the peer
+	// of its link.
+	WiringOK = "OK"
+	// WiringMiswired is the status of an interface whose LLDP neighbor is
+	// not the peer of its link.
+	WiringMiswired = "MISWIRED"
+	// WiringMissing is the status of an interface of a link without LLDP
+	// neighbor.
+	WiringMissing = "MISSING"
+	// WiringUnexpected is the status of an interface without link whose
+	// LLDP neighbor is a node of the topology.
+	WiringUnexpected = "UNEXPECTED"
+	// WiringError is the status of the interfaces of a node whose LLDP
+	// neighbors could not be retrieved.
+	WiringError = "ERROR"
+	// WiringSkip is the status of the interfaces of a node not supporting
+	// the retrieval of its LLDP neighbors.
+	WiringSkip = "SKIP"
+)
+
+// WiringResult is the result of the audit of the wiring of an interface.
+type WiringResult struct {
+	// Node and Interface are the node and the int_name of the interface.
+	Node      string `json:"node"`
+	Interface string `json:"interface"`
+	// Expected is the peer of the interface in the topology as
+	// node:interface.
+	Expected string `json:"expected,omitempty"`
+	// Actual is the LLDP neighbor of the interface as node:interface, or as
+	// system name:port ID if it is not an interface of the topology.
+	Actual  string `json:"actual,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Passed returns whether the interface is wired as expected or was skipped.
+func (r *WiringResult) Passed() bool {
+	return r.Status == WiringOK || r.Status == WiringSkip
+}
+
+// lldpResult are the LLDP neighbors of a node or the error retrieving them.
+type lldpResult struct {
+	neighbors []*node.LLDPNeighbor
+	status    string
+	err       error
+}
+
+// AuditWiring compares the LLDP neighbors of the interfaces of the nodes with
+// the links of the topology. The neighbors are retrieved through the vendor
+// CLI if opts is nil, and with gNMI otherwise. Vendor interface names are
+// mapped back to the int_name of the interfaces.
+func (m *Manager) AuditWiring(ctx context.Context, opts *node.GNMIOptions) ([]*WiringResult, error) {
+	var names []string
+	for name := range m.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lldp := map[string]*lldpResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(n node.Node) {
+			defer wg.Done()
+			r := lldpNeighbors(ctx, n, opts)
+			mu.Lock()
+			lldp[n.Name()] = r
+			mu.Unlock()
+		}(m.nodes[name])
+	}
+	wg.Wait()
+
+	// neighbors are the LLDP neighbors of each node keyed by interface key.
+	neighbors := map[string]map[string][]*node.LLDPNeighbor{}
+	for _, name := range names {
+		nbs := map[string][]*node.LLDPNeighbor{}
+		for _, nb := range lldp[name].neighbors {
+			k, ok := m.interfaceKey(name, nb.Interface)
+			if !ok {
+				// Neighbors of interfaces not in the topology, e.g. of
+				// the management interface, are ignored.
+				continue
+			}
+			nbs[k] = append(nbs[k], nb)
+		}
+		neighbors[name] = nbs
+	}
+
+	var rs []*WiringResult
+	linked := map[string]map[string]bool{}
+	for _, l := range m.proto.GetLinks() {
+		for _, e := range [][4]string{
+			{l.GetANode(), l.GetAInt(), l.GetZNode(), l.GetZInt()},
+			{l.GetZNode(), l.GetZInt(), l.GetANode(), l.GetAInt()},
+		} {
+			if linked[e[0]] == nil {
+				linked[e[0]] = map[string]bool{}
+			}
+			linked[e[0]][e[1]] = true
+			r := &WiringResult{
+				Node:      e[0],
+				Interface: m.intName(e[0], e[1]),
+				Expected:  fmt.Sprintf("%s:%s", e[2], m.intName(e[2], e[3])),
+			}
+			rs = append(rs, r)
+			lr, ok := lldp[e[0]]
+			if !ok {
+				r.Status = WiringError
+				r.Message = fmt.Sprintf("node %q not found", e[0])
+				continue
+			}
+			if lr.status != "" {
+				r.Status = lr.status
+				r.Message = lr.err.Error()
+				continue
+			}
+			nbs := neighbors[e[0]][e[1]]
+			if len(nbs) == 0 {
+				r.Status = WiringMissing
+				r.Message = "no LLDP neighbor"
+				continue
+			}
+			var actual []string
+			r.Status = WiringMiswired
+			for _, nb := range nbs {
+				a, peer, peerInt := m.neighbor(nb)
+				actual = append(actual, a)
+				if peer == e[2] && peerInt == e[3] {
+					r.Status = WiringOK
+				}
+			}
+			r.Actual = strings.Join(actual, ", ")
+			if len(nbs) > 1 {
+				r.Message = fmt.Sprintf("%d LLDP neighbors", len(nbs))
+			}
+		}
+	}
+	for _, name := range names {
+		var keys []string
+		for k := range neighbors[name] {
+			if !linked[name][k] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			var actual []string
+			for _, nb := range neighbors[name][k] {
+				if a, peer, _ := m.neighbor(nb); peer != "" {
+					actual = append(actual, a)
+				}
+			}
+			if len(actual) == 0 {
+				continue
+			}
+			rs = append(rs, &WiringResult{
+				Node:      name,
+				Interface: m.intName(name, k),
+				Actual:    strings.Join(actual, ", "),
+				Status:    WiringUnexpected,
+				Message:   "interface has no link",
+			})
+		}
+	}
+	return rs, nil
+}
+
+// lldpNeighbors returns the LLDP neighbors of n through the vendor CLI if
+// opts is nil, and with gNMI otherwise. Nodes not supporting the transport,
+// such as nodes without gnmi service, are skipped.
+func lldpNeighbors(ctx context.Context, n node.Node, opts *node.GNMIOptions) *lldpResult {
+	log.Infof("Getting LLDP neighbors of node %s", n.Name())
+	var nbs []*node.LLDPNeighbor
+	var err error
+	if opts == nil {
+		g, ok := n.(node.LLDPNeighborGetter)
+		if !ok {
+			return &lldpResult{status: WiringSkip, err: fmt.Errorf("node does not support LLDP neighbors through the CLI")}
+		}
+		nbs, err = g.LLDPNeighbors(ctx)
+	} else {
+		g, ok := n.(node.GNMILLDPNeighborGetter)
+		if !ok {
+			return &lldpResult{status: WiringSkip, err: fmt.Errorf("node does not support LLDP neighbors with gNMI")}
+		}
+		nbs, err = g.GNMILLDPNeighbors(ctx, opts)
+	}
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		return &lldpResult{status: WiringSkip, err: err}
+	case err != nil:
+		return &lldpResult{status: WiringError, err: fmt.Errorf("failed to get LLDP neighbors: %w", err)}
+	}
+	return &lldpResult{neighbors: nbs}
+}
+
+// interfaceKey returns the key of the interface of the node name with the
+// vendor name, int_name or key intf. Vendor names of interfaces without name
+// are mapped to keys by the node if it is an InterfaceMapper.
+func (m *Manager) interfaceKey(name, intf string) (string, bool) {
+	n, ok := m.nodes[name]
+	if !ok {
+		return "", false
+	}
+	intfs := n.GetProto().GetInterfaces()
+	for k, i := range intfs {
+		if i.GetName() == intf {
+			return k, true
+		}
+	}
+	for k, i := range intfs {
+		if i.GetIntName() == intf {
+			return k, true
+		}
+	}
+	if _, ok := intfs[intf]; ok {
+		return intf, true
+	}
+	if im, ok := n.(node.InterfaceMapper); ok {
+		if k, ok := im.InterfaceKey(intf); ok {
+			if i, ok := intfs[k]; ok && (i.GetName() == "" || i.GetName() == intf) {
+				return k, true
+			}
+		}
+	}
+	return "", false
+}
+
+// intName returns the int_name of the interface with key k of the node name,
+// or k if it has none.
+func (m *Manager) intName(name, k string) string {
+	n, ok := m.nodes[name]
+	if !ok {
+		return k
+	}
+	if i := n.GetProto().GetInterfaces()[k]; i.GetIntName() != "" {
+		return i.GetIntName()
+	}
+	return k
+}
+
+// neighbor returns nb as node:int_name with the node and the interface key of
+// the neighbor if it is an interface of the topology. Otherwise it returns nb
+// as system name:port ID, with the node if the system is a node.
+func (m *Manager) neighbor(nb *node.LLDPNeighbor) (string, string, string) {
+	peer := nb.SystemName
+	if _, ok := m.nodes[peer]; !ok {
+		// Nodes may advertise their fully qualified domain name.
+		peer = strings.SplitN(peer, ".", 2)[0]
+	}
+	if _, ok := m.nodes[peer]; !ok {
+		return fmt.Sprintf("%s:%s", nb.SystemName, nb.PortID), "", ""
+	}
+	k, ok := m.interfaceKey(peer, nb.PortID)
+	if !ok {
+		return fmt.Sprintf("%s:%s", peer, nb.PortID), peer, ""
+	}
+	return fmt.Sprintf("%s:%s", peer, m.intName(peer, k)), peer, k
+}
@@ -0,0 +1,221 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package topo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tpb "github.com/openconfig/kne/proto/topo"
+	"github.com/openconfig/kne/topo/node"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// lldpOutputs are the LLDP neighbors of lldpNodes keyed by node. Getting the
+// neighbors of other nodes fails.
+var lldpOutputs = map[string][]*node.LLDPNeighbor{
+	"r1": {
+		{Interface: "Ethernet1", SystemName: "r2", PortID: "eth1"},
+		{Interface: "Ethernet2", SystemName: "r2", PortID: "eth2"},
+		{Interface: "Ethernet3", SystemName: "mgmt-sw", PortID: "Gi0/1"},
+		// Ethernet4 is not eth4, which is named Ethernet9.
+		{Interface: "Ethernet4", SystemName: "r4", PortID: "eth9"},
+		{Interface: "Management0", SystemName: "mgmt-sw", PortID: "Gi0/2"},
+	},
+	"r2": {
+		{Interface: "eth1", SystemName: "r1.example.com", PortID: "Ethernet1"},
+		{Interface: "eth2", SystemName: "r1.example.com", PortID: "Ethernet2"},
+	},
+	"r3": {
+		{Interface: "e1-2", SystemName: "r4", PortID: "eth1"},
+	},
+}
+
+type lldpNode struct {
+	*node.Impl
+}
+
+func (n *lldpNode) LLDPNeighbors(context.Context) ([]*node.LLDPNeighbor, error) {
+	nbs, ok := lldpOutputs[n.Name()]
+	if !ok {
+		return nil, fmt.Errorf("lldp not enabled")
+	}
+	return nbs, nil
+}
+
+// InterfaceKey maps EthernetN to ethN, as cEOS does.
+func (n *lldpNode) InterfaceKey(intf string) (string, bool) {
+	if !strings.HasPrefix(intf, "Ethernet") {
+		return "", false
+	}
+	return "eth" + strings.TrimPrefix(intf, "Ethernet"), true
+}
+
+func init() {
+	node.Register(tpb.Node_Type(1005), func(impl *node.Impl) (node.Node, error) {
+		return &lldpNode{Impl: impl}, nil
+	})
+}
+
+func TestAuditWiring(t *testing.T) {
+	typ := tpb.Node_Type(1005)
+	pb := &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{{
+			Name: "r1",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				// eth1 has no name, Ethernet1 is mapped to it by the node.
+				"eth1": {},
+				"eth2": {Name: "Ethernet2"},
+				"eth3": {Name: "Ethernet3"},
+				"eth4": {Name: "Ethernet9"},
+			},
+		}, {
+			Name: "r2",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth2": {},
+			},
+		}, {
+			Name: "r3",
+			Type: typ,
+			Interfaces: map[string]*tpb.Interface{
+				"eth2": {IntName: "e1-2"},
+			},
+		}, {
+			Name: "r4",
+			Type: typ,
+		}, {
+			Name: "c1",
+			Type: tpb.Node_Type(1001),
+		}},
+		Links: []*tpb.Link{
+			{ANode: "r1", AInt: "eth1", ZNode: "r2", ZInt: "eth1"},
+			{ANode: "r1", AInt: "eth2", ZNode: "r3", ZInt: "eth1"},
+			{ANode: "r1", AInt: "eth3", ZNode: "c1", ZInt: "eth1"},
+			{ANode: "r3", AInt: "eth2", ZNode: "r4", ZInt: "eth1"},
+		},
+	}
+	m, err := New("", pb, WithClusterConfig(&rest.Config{}), WithKubeClient(kfake.NewSimpleClientset()), WithTopoClient(fakeTopoClient(t, nil)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	got, err := m.AuditWiring(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("AuditWiring() failed: %v", err)
+	}
+	want := []*WiringResult{{
+		Node:      "r1",
+		Interface: "eth1",
+		Expected:  "r2:eth1",
+		Actual:    "r2:eth1",
+		Status:    WiringOK,
+	}, {
+		Node:      "r2",
+		Interface: "eth1",
+		Expected:  "r1:eth1",
+		Actual:    "r1:eth1",
+		Status:    WiringOK,
+	}, {
+		Node:      "r1",
+		Interface: "eth2",
+		Expected:  "r3:eth1",
+		Actual:    "r2:eth2",
+		Status:    WiringMiswired,
+	}, {
+		Node:      "r3",
+		Interface: "eth1",
+		Expected:  "r1:eth2",
+		Status:    WiringMissing,
+		Message:   "no LLDP neighbor",
+	}, {
+		Node:      "r1",
+		Interface: "eth3",
+		Expected:  "c1:eth1",
+		Actual:    "mgmt-sw:Gi0/1",
+		Status:    WiringMiswired,
+	}, {
+		Node:      "c1",
+		Interface: "eth1",
+		Expected:  "r1:eth3",
+		Status:    WiringSkip,
+		Message:   "node does not support LLDP neighbors through the CLI",
+	}, {
+		Node:      "r3",
+		Interface: "e1-2",
+		Expected:  "r4:eth1",
+		Actual:    "r4:eth1",
+		Status:    WiringOK,
+	}, {
+		Node:      "r4",
+		Interface: "eth1",
+		Expected:  "r3:e1-2",
+		Status:    WiringError,
+		Message:   "failed to get LLDP neighbors: lldp not enabled",
+	}, {
+		Node:      "r2",
+		Interface: "eth2",
+		Actual:    "r1:eth2",
+		Status:    WiringUnexpected,
+		Message:   "interface has no link",
+	}}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("AuditWiring() unexpected results (-want +got):\n%s", d)
+	}
+	for i, wantPassed := range []bool{true, true, false, false, false, true, true, false, false} {
+		if got[i].Passed() != wantPassed {
+			t.Errorf("Passed() of %s:%s got %v, want %v", got[i].Node, got[i].Interface, got[i].Passed(), wantPassed)
+		}
+	}
+}
+
+func TestAuditWiringGNMISkip(t *testing.T) {
+	pb := &tpb.Topology{
+		Name: "t1",
+		Nodes: []*tpb.Node{
+			{Name: "h1", Vendor: tpb.Vendor_HOST},
+			{Name: "h2", Vendor: tpb.Vendor_HOST},
+		},
+		Links: []*tpb.Link{
+			{ANode: "h1", AInt: "eth1", ZNode: "h2", ZInt: "eth1"},
+		},
+	}
+	m, err := New("", pb, WithClusterConfig(&rest.Config{}), WithKubeClient(kfake.NewSimpleClientset()), WithTopoClient(fakeTopoClient(t, nil)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := m.Load(context.Background()); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	got, err := m.AuditWiring(context.Background(), &node.GNMIOptions{})
+	if err != nil {
+		t.Fatalf("AuditWiring() failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("AuditWiring() got %d results, want 2", len(got))
+	}
+	for _, r := range got {
+		if r.Status != WiringSkip || !r.Passed() {
+			t.Errorf("AuditWiring() got %s:%s status %s: %s, want %s", r.Node, r.Interface, r.Status, r.Message, WiringSkip)
+		}
+	}
+}